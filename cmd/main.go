@@ -3,30 +3,84 @@ package main
 import (
 	"context"
 	"flag"
+	"log/slog"
+	"os"
 
 	"github.com/SHSanderland/EffMobTest/pkg/config"
 	"github.com/SHSanderland/EffMobTest/pkg/logger"
 	"github.com/SHSanderland/EffMobTest/pkg/server"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/SHSanderland/EffMobTest/pkg/storage/memory"
 	"github.com/SHSanderland/EffMobTest/pkg/storage/psql"
+	"github.com/SHSanderland/EffMobTest/pkg/tracing"
 )
 
 var configPath = flag.String("config", "", "path to config file")
 
 // @title			Subscription API
 // @version		1.0
-// @description	API для управления подписками
+// @description	API для управления подписками. Данные разделены по арендаторам: арендатор запроса
+// @description	берется из API-ключа или claim tenant_id токена (по умолчанию default). Заголовок
+// @description	X-Tenant-ID выбирает арендатора только для администратора с доступом ко всем арендаторам.
 // @host			localhost:8080
 // @BasePath		/api/v1
+//
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+// @description				API-ключ или JWT (HS256/RS256) в виде Bearer <token>
+//
+// @securityDefinitions.apikey	ApiKeyAuth
+// @in							header
+// @name						X-API-Key
+// @description				API-ключ
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case cmdExport:
+			runExport(os.Args[2:])
+
+			return
+		case cmdImport:
+			runImport(os.Args[2:])
+
+			return
+		}
+	}
+
 	flag.Parse()
 
 	cfg := config.InitConfig(*configPath)
 	log := logger.InitLogger(cfg.Env)
-	db, err := psql.InitDB(context.TODO(), log, cfg)
 
+	shutdown, err := tracing.Init(context.Background(), &cfg.Tracing)
 	if err != nil {
+		log.Error("failed to init tracing", slog.String("err", err.Error()))
+
 		panic(err)
 	}
 
-	server.InitServer(log, cfg, db)
+	server.InitServer(log, cfg, func(ctx context.Context) (storage.Storage, error) {
+		return initStorage(ctx, log, cfg)
+	})
+
+	if err := shutdown(context.Background()); err != nil {
+		log.Warn("failed to flush traces", slog.String("err", err.Error()))
+	}
+}
+
+// initStorage Инициализация хранилища по драйверу из конфига.
+func initStorage(ctx context.Context, log *slog.Logger, cfg *config.Config) (storage.Storage, error) {
+	if cfg.Driver == config.DriverMemory {
+		log.Warn("Using in-memory storage, data will be lost on shutdown!")
+
+		return memory.InitStorage(log), nil
+	}
+
+	db, err := psql.InitDB(ctx, log, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
 }
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/SHSanderland/EffMobTest/pkg/actor"
+	"github.com/SHSanderland/EffMobTest/pkg/config"
+	"github.com/SHSanderland/EffMobTest/pkg/logger"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/service"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/SHSanderland/EffMobTest/pkg/tenant"
+	"github.com/SHSanderland/EffMobTest/pkg/transfer"
+)
+
+// Команды импорта и экспорта подписок.
+const (
+	cmdExport = "export"
+	cmdImport = "import"
+)
+
+// transferFlags Общие флаги команд импорта и экспорта.
+type transferFlags struct {
+	config string
+	format string
+	file   string
+	actor  string
+	tenant string
+}
+
+// parseTransferFlags Разбор флагов команды name. Дополнительные флаги
+// команды добавляются через extra до разбора.
+func parseTransferFlags(name string, args []string, extra func(fs *flag.FlagSet)) *transferFlags {
+	tf := transferFlags{}
+
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.StringVar(&tf.config, "config", "", "path to config file")
+	fs.StringVar(&tf.format, "format", model.FormatCSV, "file format: csv or jsonl")
+	fs.StringVar(&tf.file, "file", "", "path to file, stdin or stdout if empty")
+	fs.StringVar(&tf.actor, "actor", "cli", "actor for the audit log")
+	fs.StringVar(&tf.tenant, "tenant", tenant.Default, "tenant whose subscriptions are transferred")
+
+	if extra != nil {
+		extra(fs)
+	}
+
+	_ = fs.Parse(args)
+
+	return &tf
+}
+
+// checkFormat Проверка формата так же, как параметра format в API.
+func (tf *transferFlags) checkFormat() string {
+	format, err := service.ParseFormat(tf.format)
+	if err != nil {
+		exitf("%s: %s", err, tf.format)
+	}
+
+	return format
+}
+
+// openStorage Инициализация хранилища для команды. Логи пишутся
+// в stderr, чтобы не смешиваться с выгрузкой.
+func (tf *transferFlags) openStorage() (context.Context, storage.Storage) {
+	id, err := tenant.Parse(tf.tenant)
+	if err != nil {
+		exitf("%s", err)
+	}
+
+	cfg := config.InitConfig(tf.config)
+	log := logger.NewLogger(cfg.Env, os.Stderr)
+	ctx := tenant.WithTenant(actor.WithActor(context.Background(), tf.actor), id)
+	ctx = storage.WithServiceCreation(ctx)
+
+	connCtx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+	defer cancel()
+
+	db, err := initStorage(connCtx, log, cfg)
+	if err != nil {
+		exitf("failed to open storage: %s", err)
+	}
+
+	return ctx, db
+}
+
+// runExport Команда export: выгрузка подписок в файл или stdout.
+// Фильтры задаются флагом -query в том же виде, что и параметры
+// GET /subscriptions, например -query "user_id=...&active_at=07-2025".
+func runExport(args []string) {
+	var query string
+
+	tf := parseTransferFlags(cmdExport, args, func(fs *flag.FlagSet) {
+		fs.StringVar(&query, "query", "", "list filters as URL query")
+	})
+
+	format := tf.checkFormat()
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		exitf("invalid query: %s", err)
+	}
+
+	filter, err := service.ParseListParams(values)
+	if err != nil {
+		exitf("invalid query: %s", err)
+	}
+
+	ctx, db := tf.openStorage()
+	defer db.CloseConnection()
+
+	var out io.Writer = os.Stdout
+
+	if tf.file != "" {
+		f, err := os.Create(tf.file)
+		if err != nil {
+			exitf("failed to create file: %s", err)
+		}
+		defer f.Close()
+
+		out = f
+	}
+
+	count, err := transfer.Export(ctx, db, filter, out, format)
+	if err != nil {
+		exitf("failed to export subscriptions: %s", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "exported: %d\n", count)
+}
+
+// runImport Команда import: создание подписок из файла или stdin.
+// Отклоненные строки печатаются в stderr, при наличии отклоненных
+// строк команда завершается с кодом 1.
+func runImport(args []string) {
+	tf := parseTransferFlags(cmdImport, args, nil)
+
+	format := tf.checkFormat()
+
+	ctx, db := tf.openStorage()
+	defer db.CloseConnection()
+
+	svc := service.InitService(db)
+
+	var in io.Reader = os.Stdin
+
+	if tf.file != "" {
+		f, err := os.Open(tf.file)
+		if err != nil {
+			exitf("failed to open file: %s", err)
+		}
+		defer f.Close()
+
+		in = f
+	}
+
+	report, err := transfer.Import(ctx, db, svc.CheckBody, in, format)
+	if err != nil {
+		exitf("failed to import subscriptions: %s", err)
+	}
+
+	for _, line := range report.Lines {
+		if line.Err != nil {
+			fmt.Fprintf(os.Stderr, "line %d: %s\n", line.Line, line.Err)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "accepted: %d, rejected: %d\n", report.Accepted, report.Rejected)
+
+	if report.Rejected > 0 {
+		db.CloseConnection()
+		os.Exit(1)
+	}
+}
+
+// exitf Печать ошибки в stderr и завершение команды с кодом 1.
+func exitf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
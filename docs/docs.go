@@ -15,356 +15,3279 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/subscriptions": {
+        "/admin/api-keys": {
             "get": {
-                "description": "Возвращает список подписок с возможностью фильтрации по user_id и service_name",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "subscriptions"
-                ],
-                "summary": "Получить список подписок",
-                "parameters": [
+                "security": [
                     {
-                        "type": "string",
-                        "example": "550e8400-e29b-41d4-a716-446655440000",
-                        "description": "UUID пользователя для фильтрации",
-                        "name": "user_id",
-                        "in": "query",
-                        "required": true
+                        "BearerAuth": []
                     },
                     {
-                        "type": "string",
-                        "example": "netflix",
-                        "description": "Название сервиса для фильтрации",
-                        "name": "service_name",
-                        "in": "query",
-                        "required": true
+                        "ApiKeyAuth": []
                     }
                 ],
+                "description": "Возвращает все API-ключи арендатора, в том числе отозванные, без самих ключей.\nТребует прав администратора.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Получить список API-ключей",
                 "responses": {
                     "200": {
                         "description": "Успешный запрос",
                         "schema": {
-                            "$ref": "#/definitions/lsub.userResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.APIKey"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Невалидные параметры запроса",
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет прав администратора",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Создает новую подписку после проверки валидности данных и отсутствия активной подписки",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создает API-ключ для заголовка X-API-Key или Authorization: Bearer. Ключ возвращается\nтолько в этом ответе, в базе данных хранится его хеш. Роль role задает права ключа:\nadmin, support или user (по умолчанию), ключу с ролью user нужен user_id существующего\nпользователя, от имени которого он действует. Ключ принадлежит арендатору запроса\nи работает только с его данными. Требует прав администратора.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "admin"
                 ],
-                "summary": "Создать новую подписку",
+                "summary": "Создать API-ключ",
                 "parameters": [
                     {
-                        "description": "Данные для создания подписки",
+                        "description": "Название ключа и права",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.Subscription"
+                            "$ref": "#/definitions/model.APIKey"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Подписка успешно создана"
+                        "description": "Ключ создан",
+                        "schema": {
+                            "$ref": "#/definitions/ckey.userResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Адрес ключа"
+                            }
+                        }
                     },
                     "400": {
-                        "description": "Невалидные входные данные",
+                        "description": "Невалидный JSON тела запроса",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
                         }
                     },
-                    "409": {
-                        "description": "Подписка уже активна",
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет прав администратора",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Нарушения валидации полей в details",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
                         }
                     }
                 }
             }
         },
-        "/subscriptions/cost": {
+        "/admin/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Отзывает API-ключ арендатора, после этого запросы с ним не аутентифицируются.\nТребует прав администратора.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Отозвать API-ключ",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID ключа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Ключ отозван"
+                    },
+                    "400": {
+                        "description": "Невалидный ID ключа",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет прав администратора",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Ключ не найден или уже отозван",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/audit": {
             "get": {
-                "description": "Возвращает суммарную стоимость подписок за указанный период с возможностью фильтрации",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу журнала изменений всех подписок в порядке изменений.\nДля получения следующей страницы передайте next_cursor из ответа в параметр\ncursor с теми же фильтрами.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "audit"
                 ],
-                "summary": "Рассчитать стоимость подписок",
+                "summary": "Получить журнал изменений",
                 "parameters": [
                     {
                         "type": "string",
-                        "example": "550e8400-e29b-41d4-a716-446655440000",
-                        "description": "UUID пользователя",
-                        "name": "user_id",
-                        "in": "query",
-                        "required": true
+                        "example": "anonymous",
+                        "description": "Исполнитель изменения",
+                        "name": "actor",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "example": "netflix",
-                        "description": "Название сервиса",
-                        "name": "service_name",
-                        "in": "query",
-                        "required": true
+                        "example": "2025-07-01T00:00:00Z",
+                        "description": "Изменения не раньше (формат RFC 3339)",
+                        "name": "from",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "example": "01-2023",
-                        "description": "Начало периода (формат MM-YYYY)",
-                        "name": "start_date",
-                        "in": "query",
-                        "required": true
+                        "example": "2025-08-01T00:00:00Z",
+                        "description": "Изменения не позже (формат RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 50,
+                        "description": "Размер страницы (по умолчанию 50, максимум 500)",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "example": "12-2023",
-                        "description": "Конец периода (формат MM-YYYY)",
-                        "name": "end_date",
-                        "in": "query",
-                        "required": true
+                        "description": "Курсор следующей страницы из next_cursor",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешный расчет стоимости",
+                        "description": "Успешный запрос",
                         "schema": {
-                            "$ref": "#/definitions/costsub.userResponse"
+                            "$ref": "#/definitions/audit.userResponse"
                         }
                     },
                     "400": {
                         "description": "Невалидные параметры запроса",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Доступно только admin и support",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
                         }
                     }
                 }
             }
         },
-        "/subscriptions/{id}": {
+        "/rates": {
             "get": {
-                "description": "Возвращает информацию о подписке по её идентификатору",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает все курсы валют, по которым пересчитывается стоимость подписок.\nКурс from/to означает, что 1 from = rate to, обратный курс считается автоматически.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
-                ],
-                "summary": "Получить подписку по ID",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "example": 123,
-                        "description": "ID подписки",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "rates"
                 ],
+                "summary": "Получить курсы валют",
                 "responses": {
                     "200": {
                         "description": "Успешный запрос",
                         "schema": {
-                            "$ref": "#/definitions/model.Subscription"
-                        }
-                    },
-                    "400": {
-                        "description": "Невалидный ID подписки",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/lrate.userResponse"
                         }
                     },
-                    "404": {
-                        "description": "Подписка не найдена",
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/rates/{from}/{to}": {
             "put": {
-                "description": "Обновляет информацию о существующей подписке по её ID",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создает или заменяет курс пары валют: 1 from = rate to.\nКурс должен быть положительным и иметь не больше 10 знаков после точки.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "rates"
                 ],
-                "summary": "Обновить подписку",
+                "summary": "Установить курс валюты",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "example": 123,
-                        "description": "ID обновляемой подписки",
-                        "name": "id",
+                        "type": "string",
+                        "example": "USD",
+                        "description": "Исходная валюта (ISO 4217)",
+                        "name": "from",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Новые данные подписки",
+                        "type": "string",
+                        "example": "RUB",
+                        "description": "Валюта пересчета (ISO 4217)",
+                        "name": "to",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Курс",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.Subscription"
+                            "$ref": "#/definitions/model.RateBody"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Подписка успешно обновлена"
+                        "description": "Курс установлен",
+                        "schema": {
+                            "$ref": "#/definitions/model.Rate"
+                        }
                     },
                     "400": {
-                        "description": "Невалидные входные данные (ID или тело запроса)",
+                        "description": "Невалидная валюта или JSON тела запроса",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
                         }
                     },
-                    "404": {
-                        "description": "Подписка с указанным ID не найдена",
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Доступно только admin и support",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Невалидный курс",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Удаляет подписку по указанному ID",
-                "produces": [
-                    "text/plain"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Удаляет курс пары валют. Обратный курс, если он задан отдельно, не удаляется.",
                 "tags": [
-                    "subscriptions"
+                    "rates"
                 ],
-                "summary": "Удалить подписку",
+                "summary": "Удалить курс валюты",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "example": 123,
-                        "description": "ID удаляемой подписки",
-                        "name": "id",
+                        "type": "string",
+                        "example": "USD",
+                        "description": "Исходная валюта (ISO 4217)",
+                        "name": "from",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "RUB",
+                        "description": "Валюта пересчета (ISO 4217)",
+                        "name": "to",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "204": {
-                        "description": "Подписка успешно удалена"
+                        "description": "Курс удален"
                     },
                     "400": {
-                        "description": "Невалидный ID подписки",
+                        "description": "Невалидная валюта",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет прав администратора",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
                         }
                     },
                     "404": {
-                        "description": "Подписка не найдена",
+                        "description": "Курс не найден",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "costsub.userResponse": {
-            "type": "object",
-            "properties": {
-                "end_period": {
-                    "type": "string"
-                },
-                "service_name": {
-                    "type": "string"
-                },
-                "start_period": {
-                    "type": "string"
-                },
-                "total_cost": {
-                    "type": "integer"
-                }
-            }
         },
-        "lsub.userResponse": {
-            "type": "object",
-            "properties": {
-                "subscriptions": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/model.Subscription"
+        "/services": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
                     }
-                },
-                "total": {
-                    "type": "integer"
-                }
-            }
-        },
+                ],
+                "description": "Возвращает сервисы каталога, отсортированные по названию.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Получить каталог сервисов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "video",
+                        "description": "Категория сервисов",
+                        "name": "category",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный запрос",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Service"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Добавляет сервис в каталог. Название и синонимы сравниваются без учета регистра и лишних\nпробелов и не должны совпадать с названием или синонимом другого сервиса. Подписка с любым\nиз них получает каноническое название сервиса, а подписка без цены - default_price.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Создать сервис",
+                "parameters": [
+                    {
+                        "description": "Данные сервиса",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Service"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Сервис создан",
+                        "schema": {
+                            "$ref": "#/definitions/model.Service"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Адрес созданного сервиса"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный JSON тела запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Доступно только admin и support",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Название или синоним заняты другим сервисом",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большое тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Нарушения валидации полей в details",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/services/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает сервис каталога с синонимами и ценой по умолчанию.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Получить сервис по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID сервиса",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/model.Service"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный ID сервиса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Сервис не найден",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Полностью заменяет данные сервиса каталога. При переименовании все подписки сервиса\nполучают новое название, их версия увеличивается, а в журнал изменений пишется\nсобытие updated. Старое название не сохраняется, если его нет среди синонимов.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Заменить сервис",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID сервиса",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новые данные сервиса",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Service"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сервис обновлен",
+                        "schema": {
+                            "$ref": "#/definitions/model.Service"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный ID сервиса или JSON тела запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Доступно только admin и support",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Сервис не найден",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Название или синоним заняты другим сервисом",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большое тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Нарушения валидации полей в details",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет сервис из каталога. Сервис, на который ссылается хотя бы одна подписка,\nв том числе удаленная, не удаляется.",
+                "tags": [
+                    "services"
+                ],
+                "summary": "Удалить сервис",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID сервиса",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Сервис удален"
+                    },
+                    "400": {
+                        "description": "Невалидный ID сервиса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет прав администратора",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Сервис не найден",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "У сервиса есть подписки",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу списка подписок с необязательными фильтрами и сортировкой.\nДля получения следующей страницы передайте next_cursor из ответа в параметр cursor\nс той же сортировкой и фильтрами. total - количество подписок на странице.\nПользователь с ролью user видит только свои подписки.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Получить список подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "UUID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "net",
+                        "description": "Префикс названия сервиса без учета регистра",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "RUB",
+                        "description": "Валюта подписки (ISO 4217)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "example": 99.9,
+                        "description": "Минимальная цена, знаки после точки - как у currency",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "example": 1000,
+                        "description": "Максимальная цена, знаки после точки - как у currency",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "07-2025",
+                        "description": "Подписка активна в месяце или дне (MM-YYYY или YYYY-MM-DD)",
+                        "name": "active_at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "01-2025",
+                        "description": "Дата начала не раньше (MM-YYYY или YYYY-MM-DD)",
+                        "name": "start_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "12-2025",
+                        "description": "Дата начала не позже (MM-YYYY или YYYY-MM-DD)",
+                        "name": "start_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "01-2025",
+                        "description": "Дата окончания не раньше (MM-YYYY или YYYY-MM-DD)",
+                        "name": "end_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "12-2025",
+                        "description": "Дата окончания не позже (MM-YYYY или YYYY-MM-DD)",
+                        "name": "end_to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "service_name",
+                            "-service_name",
+                            "price",
+                            "-price",
+                            "user_id",
+                            "-user_id",
+                            "start_date",
+                            "-start_date",
+                            "end_date",
+                            "-end_date"
+                        ],
+                        "type": "string",
+                        "description": "Поле сортировки, минус в начале - по убыванию",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 50,
+                        "description": "Размер страницы (по умолчанию 50, максимум 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы из next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "true",
+                            "false",
+                            "only"
+                        ],
+                        "type": "string",
+                        "description": "Удаленные подписки: true - вместе с остальными, only - только корзина",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/lsub.userResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Фильтр по другому пользователю",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создает новую подписку после проверки валидности данных. Период подписки [start_date, end_date)\nне должен пересекаться с другой подпиской того же пользователя на тот же сервис.\nДаты принимаются в формате MM-YYYY или YYYY-MM-DD. Без billing_period подписка оплачивается ежемесячно.\nПользователь user_id должен существовать, см. POST /users.\nНазвание сервиса заменяется каноническим из каталога. Сервис без записи в каталоге создается\nдля сотрудников (admin и support), для роли user такая подписка не проходит валидацию.\nПодписка без price получает цену и валюту сервиса по умолчанию, если они заданы.\nВозвращает созданную подписку и ее адрес в заголовке Location.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Создать новую подписку",
+                "parameters": [
+                    {
+                        "description": "Данные для создания подписки",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Подписка успешно создана",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия подписки"
+                            },
+                            "Location": {
+                                "type": "string",
+                                "description": "Адрес созданной подписки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный JSON тела запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Подписка для другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Пересечение с существующей подпиской, ее ID в details",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большое тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Нарушения валидации полей в details",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/cost": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает суммарную стоимость подписок за указанный период с возможностью фильтрации.\nЦена списывается в начале каждого периода оплаты billing_period, стоимость подписки - цена,\nумноженная на число списаний в период. Границы периода включаются, MM-YYYY в start_date\nозначает первое число месяца, в end_date - последнее. Ответ содержит разбивку суммы\nпо подпискам (с числом списаний cycles) и по месяцам списаний. При заданном group_by\nвместо общей суммы и разбивки возвращаются group_by и groups: [{key, cost}].\nСуммы возвращаются по валютам подписок, например {RUB: 1200, USD: 9.99}. С параметром currency\nцены подписок пересчитываются по курсам из /rates и все суммы возвращаются в этой валюте.\nДля пользователя с ролью user стоимость считается только по его подпискам.\nПериод не длиннее 120 месяцев, в том числе начатый с самой ранней подписки без start_date.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Рассчитать стоимость подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "UUID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Название сервиса, можно передать несколько раз",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "01-2023",
+                        "description": "Начало периода (MM-YYYY или YYYY-MM-DD)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "12-2023",
+                        "description": "Конец периода (MM-YYYY или YYYY-MM-DD), по умолчанию текущий месяц",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "user",
+                            "service",
+                            "month"
+                        ],
+                        "type": "string",
+                        "description": "Группировка итогов",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Учитывать удаленные подписки",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "RUB",
+                        "description": "Валюта пересчета (ISO 4217)",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный расчет стоимости",
+                        "schema": {
+                            "$ref": "#/definitions/costsub.userResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидные параметры запроса или период длиннее 120 месяцев",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Фильтр по другому пользователю",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Нет курса для пересчета валюты подписки или сумма слишком велика",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Выгружает все подписки, подходящие под фильтры списка, в CSV с заголовком\nили в JSON Lines. Подписки отдаются по мере чтения из базы данных, limit не учитывается.\nВыгрузку в CSV можно загрузить обратно через POST /subscriptions/import.\nПользователь с ролью user выгружает только свои подписки.",
+                "produces": [
+                    "text/csv",
+                    "application/jsonl"
+                ],
+                "tags": [
+                    "transfer"
+                ],
+                "summary": "Выгрузить подписки",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "jsonl"
+                        ],
+                        "type": "string",
+                        "description": "Формат выгрузки (по умолчанию csv)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "UUID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Префикс названия сервиса без учета регистра",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта подписки (ISO 4217)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Минимальная цена без учета валюты",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Максимальная цена без учета валюты",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подписка активна в месяце или дне (MM-YYYY или YYYY-MM-DD)",
+                        "name": "active_at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата начала не раньше (MM-YYYY или YYYY-MM-DD)",
+                        "name": "start_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата начала не позже (MM-YYYY или YYYY-MM-DD)",
+                        "name": "start_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата окончания не раньше (MM-YYYY или YYYY-MM-DD)",
+                        "name": "end_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата окончания не позже (MM-YYYY или YYYY-MM-DD)",
+                        "name": "end_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поле сортировки, минус в начале - по убыванию",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "true",
+                            "false",
+                            "only"
+                        ],
+                        "type": "string",
+                        "description": "Удаленные подписки: true - вместе с остальными, only - только корзина",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Файл выгрузки",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Фильтр по другому пользователю",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создает подписки из файла CSV с заголовком или JSON Lines. Каждая строка\nпроверяется так же, как тело POST /subscriptions, ошибка строки не мешает остальным.\nВ CSV обязательны колонки service_name, price, user_id и start_date, без currency\nиспользуется RUB, колонки, которые заполняет база данных, игнорируются. Подписка с пустой\nprice получает цену и валюту сервиса по умолчанию, как в POST /subscriptions.\nНомер строки в отчете - номер строки файла. Файл обрабатывается пакетами по 1000 строк.\nЕсли файл не удалось дочитать, возвращается ошибка, а пакеты, созданные до нее, остаются.",
+                "consumes": [
+                    "text/csv",
+                    "application/jsonl"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfer"
+                ],
+                "summary": "Загрузить подписки",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "jsonl"
+                        ],
+                        "type": "string",
+                        "description": "Формат файла (по умолчанию csv)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Содержимое файла",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Файл обработан, результаты строк в lines",
+                        "schema": {
+                            "$ref": "#/definitions/impsub.userResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный формат или заголовок CSV",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Доступно только admin и support",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Файл больше 64 МБ",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает информацию о подписке по её идентификатору. Версия подписки\nотдается в заголовке ETag для последующих запросов с If-Match. Удаленная\nподписка возвращается только с include_deleted=true.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Получить подписку по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 123,
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Искать также среди удаленных подписок",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия подписки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный ID подписки или include_deleted",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Подписка другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Полностью заменяет данные существующей подписки по её ID. Тело проверяется\nтак же, как при создании, отсутствующий end_date делает подписку бессрочной.\nС заголовком If-Match подписка заменяется, только если ее версия не изменилась.\nНовая цена действует с effective_from, без него - с текущего месяца, цены до этой даты\nсохраняются. Дата должна попадать в период подписки, валюта меняется, только если\neffective_from равен start_date. Цена, которая вступит в силу позже, попадает только\nв историю цен - GET /subscriptions/{id}/prices.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Заменить подписку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 123,
+                        "description": "ID обновляемой подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"3\"",
+                        "description": "ETag подписки из предыдущего ответа",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "example": "03-2025",
+                        "description": "Дата, с которой действует новая цена (MM-YYYY или YYYY-MM-DD)",
+                        "name": "effective_from",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Новые данные подписки",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Подписка успешно обновлена, новая версия в заголовке ETag",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия подписки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный ID, If-Match, effective_from или JSON тела запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Подписка другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка с указанным ID не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Пересечение с существующей подпиской, ее ID в details",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия подписки не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большое тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Нарушения валидации полей в details",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Перемещает подписку с указанным ID в корзину, откуда ее можно восстановить.\nС hard=true подписка удаляется навсегда, в том числе из корзины. С заголовком\nIf-Match подписка удаляется, только если ее версия не изменилась. Поддержка не может\nудалять подписки, а удалить навсегда может только администратор.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Удалить подписку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 123,
+                        "description": "ID удаляемой подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"3\"",
+                        "description": "ETag подписки из предыдущего ответа",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Удалить навсегда",
+                        "name": "hard",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Подписка успешно удалена"
+                    },
+                    "400": {
+                        "description": "Невалидный ID подписки, If-Match или hard",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет прав на удаление подписки",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия подписки не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Обновляет подписку по её ID в формате JSON Merge Patch (RFC 7396): отсутствующие\nполя не меняются, null сбрасывает поле (например, \"end_date\": null делает подписку\nбессрочной). Результат слияния проверяется так же, как при создании.\nЧтение, слияние и запись выполняются в одной транзакции. С заголовком If-Match\nподписка обновляется, только если ее версия не изменилась. Параметр effective_from\nработает так же, как в PUT /subscriptions/{id}.",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Частично обновить подписку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 123,
+                        "description": "ID обновляемой подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"3\"",
+                        "description": "ETag подписки из предыдущего ответа",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "example": "03-2025",
+                        "description": "Дата, с которой действует новая цена (MM-YYYY или YYYY-MM-DD)",
+                        "name": "effective_from",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Изменяемые поля подписки",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Подписка успешно обновлена, новая версия в заголовке ETag",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия подписки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный ID, If-Match, effective_from, JSON тела запроса или пустой патч",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Подписка другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка с указанным ID не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Пересечение с существующей подпиской, ее ID в details",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия подписки не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большое тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Нарушения валидации полей в details",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу журнала изменений подписки по её ID в порядке изменений,\nвключая удаление навсегда. Для получения следующей страницы передайте next_cursor\nиз ответа в параметр cursor.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Получить историю изменений подписки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 123,
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "anonymous",
+                        "description": "Исполнитель изменения",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-07-01T00:00:00Z",
+                        "description": "Изменения не раньше (формат RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-08-01T00:00:00Z",
+                        "description": "Изменения не позже (формат RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 50,
+                        "description": "Размер страницы (по умолчанию 50, максимум 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы из next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/hsub.userResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Подписка другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/prices": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает цены подписки в валюте подписки по дате, с которой каждая цена действует.\nПервая цена действует с начала подписки, каждая следующая - до следующего изменения.\nПо этим ценам GET /subscriptions/cost оплачивает списания.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Получить историю цен подписки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 123,
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/lprice.userResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный ID",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Подписка другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка с указанным ID не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Восстанавливает мягко удаленную подписку из корзины по её ID. Период подписки\nне должен пересекаться с подписками, созданными после удаления.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Восстановить подписку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 123,
+                        "description": "ID восстанавливаемой подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"3\"",
+                        "description": "ETag подписки из предыдущего ответа",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Подписка успешно восстановлена",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия подписки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный ID подписки или If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Подписка другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Подписка не удалена или пересекается с существующей",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия подписки не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions:batch": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Выполняет до 1000 операций create, update и delete по порядку в одной транзакции.\nПодписки проверяются так же, как в POST и PUT, version заменяет заголовок If-Match,\neffective_from - параметр effective_from запроса PUT.\nРезультат каждой операции возвращается в results с тем же индексом. По умолчанию\nошибка операции не мешает остальным, с atomic=true любая ошибка отменяет весь пакет,\nа остальные операции получают ошибку batch_aborted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Пакетно изменить подписки",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Отменить весь пакет при любой ошибке",
+                        "name": "atomic",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Операции пакета",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.BatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пакет обработан, результаты операций в results",
+                        "schema": {
+                            "$ref": "#/definitions/bsub.userResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный JSON тела запроса или atomic",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Доступно только admin и support",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большое тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Пустой пакет или больше 1000 операций",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает всех пользователей в порядке создания.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Получить список пользователей",
+                "responses": {
+                    "200": {
+                        "description": "Успешный запрос",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.User"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Доступно только admin и support",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создает пользователя, на которого можно оформлять подписки. Без id генерируется новый UUID,\nбез timezone используется UTC, без currency - RUB. Email уникален без учета регистра.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Создать пользователя",
+                "parameters": [
+                    {
+                        "description": "Данные пользователя",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Пользователь создан",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Адрес созданного пользователя"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный JSON тела запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Доступно только admin и support",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "ID или email заняты другим пользователем",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большое тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Нарушения валидации полей в details",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает данные пользователя по его UUID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Получить пользователя по ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "UUID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный UUID пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Данные другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Полностью заменяет данные пользователя. UUID пользователя не меняется, id в теле\nдолжен совпадать с UUID из пути или отсутствовать.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Заменить пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "UUID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новые данные пользователя",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь обновлен",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный UUID пользователя или JSON тела запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Данные другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Email занят другим пользователем",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большое тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Нарушения валидации полей в details",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет пользователя. Пользователь, у которого есть подписки, в том числе удаленные,\nне удаляется: сначала подписки нужно удалить навсегда через hard=true.",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Удалить пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "UUID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Пользователь удален"
+                    },
+                    "400": {
+                        "description": "Невалидный UUID пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет прав администратора",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "У пользователя есть подписки",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/spending": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает расходы пользователя на подписки по месяцам и по сервисам. По умолчанию суммы\nсчитаются за последние 12 месяцев, включая текущий месяц в часовом поясе пользователя,\nи пересчитываются в валюту пользователя по курсам из /rates. Списания считаются так же,\nкак в GET /subscriptions/cost.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Получить расходы пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "UUID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Название сервиса, можно передать несколько раз",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "01-2025",
+                        "description": "Начало периода (MM-YYYY или YYYY-MM-DD)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "12-2025",
+                        "description": "Конец периода (MM-YYYY или YYYY-MM-DD)",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Учитывать удаленные подписки",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "RUB",
+                        "description": "Валюта пересчета, по умолчанию валюта пользователя",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/spend.userResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный UUID пользователя, параметры запроса или период длиннее 120 месяцев",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Данные другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Нет курса для пересчета валюты подписки или сумма слишком велика",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/subscriptions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу подписок пользователя. Без active_at и фильтров по датам возвращаются\nподписки, активные сегодня в часовом поясе пользователя. Остальные параметры такие же,\nкак у GET /subscriptions.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Получить подписки пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "UUID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "07-2025",
+                        "description": "Подписка активна в месяце или дне (MM-YYYY или YYYY-MM-DD)",
+                        "name": "active_at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "net",
+                        "description": "Префикс названия сервиса без учета регистра",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "-price",
+                        "description": "Поле сортировки, минус в начале - по убыванию",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 50,
+                        "description": "Размер страницы (по умолчанию 50, максимум 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы из next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/lusub.userResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный UUID пользователя или параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Запрос не аутентифицирован",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Данные другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "audit.userResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.SubscriptionEvent"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "bsub.itemResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/response.Error"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 201
+                },
+                "subscription": {
+                    "$ref": "#/definitions/model.Subscription"
+                }
+            }
+        },
+        "bsub.userResponse": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/bsub.itemResponse"
+                    }
+                },
+                "succeeded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "ckey.userResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "id": {
+                    "type": "integer",
+                    "readOnly": true
+                },
+                "key": {
+                    "type": "string",
+                    "example": "emk_AbCdEfGhIjKlMnOpQrStUvWxYz0123456789abcdefg"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "billing-cron"
+                },
+                "prefix": {
+                    "type": "string",
+                    "readOnly": true,
+                    "example": "emk_AbCdEfGh"
+                },
+                "revoked_at": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "admin",
+                        "support",
+                        "user"
+                    ],
+                    "example": "user"
+                },
+                "tenant_id": {
+                    "type": "string",
+                    "readOnly": true,
+                    "example": "default"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                }
+            }
+        },
+        "costsub.userResponse": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "end_period": {
+                    "type": "string"
+                },
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.MonthCost"
+                    }
+                },
+                "service_names": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "start_period": {
+                    "type": "string"
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.SubscriptionCost"
+                    }
+                },
+                "total_cost": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "hsub.userResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.SubscriptionEvent"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "impsub.lineResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/response.Error"
+                },
+                "line": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "subscription_id": {
+                    "type": "integer",
+                    "example": 123
+                }
+            }
+        },
+        "impsub.userResponse": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/impsub.lineResponse"
+                    }
+                },
+                "rejected": {
+                    "type": "integer"
+                }
+            }
+        },
+        "lprice.priceResponse": {
+            "type": "object",
+            "properties": {
+                "effective_from": {
+                    "type": "string",
+                    "example": "03-2025"
+                },
+                "price": {
+                    "type": "number",
+                    "example": 9.99
+                }
+            }
+        },
+        "lprice.userResponse": {
+            "type": "object",
+            "properties": {
+                "prices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lprice.priceResponse"
+                    }
+                },
+                "subscription_id": {
+                    "type": "integer",
+                    "example": 123
+                }
+            }
+        },
+        "lrate.userResponse": {
+            "type": "object",
+            "properties": {
+                "rates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Rate"
+                    }
+                }
+            }
+        },
+        "lsub.userResponse": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Subscription"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "lusub.userResponse": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Subscription"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                }
+            }
+        },
+        "model.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "id": {
+                    "type": "integer",
+                    "readOnly": true
+                },
+                "name": {
+                    "type": "string",
+                    "example": "billing-cron"
+                },
+                "prefix": {
+                    "type": "string",
+                    "readOnly": true,
+                    "example": "emk_AbCdEfGh"
+                },
+                "revoked_at": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "admin",
+                        "support",
+                        "user"
+                    ],
+                    "example": "user"
+                },
+                "tenant_id": {
+                    "type": "string",
+                    "readOnly": true,
+                    "example": "default"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                }
+            }
+        },
+        "model.BatchOperation": {
+            "type": "object",
+            "properties": {
+                "effective_from": {
+                    "type": "string",
+                    "example": "03-2025"
+                },
+                "hard": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 123
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete"
+                    ],
+                    "example": "create"
+                },
+                "subscription": {
+                    "$ref": "#/definitions/model.Subscription"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "model.BatchRequest": {
+            "type": "object",
+            "properties": {
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BatchOperation"
+                    }
+                }
+            }
+        },
+        "model.BillingPeriod": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "unit": {
+                    "type": "string",
+                    "enum": [
+                        "day",
+                        "week",
+                        "month",
+                        "quarter",
+                        "year"
+                    ],
+                    "example": "month"
+                }
+            }
+        },
+        "model.CostGroup": {
+            "type": "object",
+            "properties": {
+                "cost": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
+                "key": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.MonthCost": {
+            "type": "object",
+            "properties": {
+                "cost": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
+                "month": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Rate": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 92.5
+                },
+                "to": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "readOnly": true
+                }
+            }
+        },
+        "model.RateBody": {
+            "type": "object",
+            "properties": {
+                "rate": {
+                    "type": "number",
+                    "example": 92.5
+                }
+            }
+        },
+        "model.Service": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "netflix.com"
+                    ]
+                },
+                "category": {
+                    "type": "string",
+                    "example": "video"
+                },
+                "created_at": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "default_price": {
+                    "type": "number",
+                    "example": 9.99
+                },
+                "id": {
+                    "type": "integer",
+                    "readOnly": true
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Netflix"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "readOnly": true
+                }
+            }
+        },
         "model.Subscription": {
             "type": "object",
             "properties": {
+                "billing_period": {
+                    "$ref": "#/definitions/model.BillingPeriod"
+                },
+                "created_at": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "deleted_at": {
+                    "type": "string",
+                    "readOnly": true
+                },
                 "end_date": {
                     "type": "string"
                 },
+                "id": {
+                    "type": "integer",
+                    "readOnly": true
+                },
                 "price": {
-                    "type": "integer"
+                    "type": "number",
+                    "example": 9.99
+                },
+                "service_id": {
+                    "type": "integer",
+                    "readOnly": true
                 },
                 "service_name": {
                     "type": "string"
@@ -372,10 +3295,181 @@ const docTemplate = `{
                 "start_date": {
                     "type": "string"
                 },
+                "updated_at": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer",
+                    "readOnly": true
+                }
+            }
+        },
+        "model.SubscriptionCost": {
+            "type": "object",
+            "properties": {
+                "billing_period": {
+                    "$ref": "#/definitions/model.BillingPeriod"
+                },
+                "cost": {
+                    "type": "number"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "cycles": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "type": "integer"
+                },
                 "user_id": {
                     "type": "string"
                 }
             }
+        },
+        "model.SubscriptionEvent": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "updated"
+                },
+                "actor": {
+                    "type": "string",
+                    "example": "anonymous"
+                },
+                "after": {
+                    "$ref": "#/definitions/model.Subscription"
+                },
+                "before": {
+                    "$ref": "#/definitions/model.Subscription"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "host/abcdef-000001"
+                },
+                "subscription_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.User": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "display_name": {
+                    "type": "string",
+                    "example": "Иван Петров"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Europe/Moscow"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "readOnly": true
+                }
+            }
+        },
+        "response.Error": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "subscription_not_found"
+                },
+                "details": {},
+                "message": {
+                    "type": "string",
+                    "example": "subscription not found"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "host/abcdef-000001"
+                }
+            }
+        },
+        "spend.userResponse": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "end_period": {
+                    "type": "string"
+                },
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.MonthCost"
+                    }
+                },
+                "services": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.CostGroup"
+                    }
+                },
+                "start_period": {
+                    "type": "string"
+                },
+                "total_cost": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "API-ключ",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "API-ключ или JWT (HS256/RS256) в виде Bearer \u003ctoken\u003e",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -387,7 +3481,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/api/v1",
 	Schemes:          []string{},
 	Title:            "Subscription API",
-	Description:      "API для управления подписками",
+	Description:      "API для управления подписками. Данные разделены по арендаторам: арендатор запроса\nберется из API-ключа или claim tenant_id токена (по умолчанию default). Заголовок\nX-Tenant-ID выбирает арендатора только для администратора с доступом ко всем арендаторам.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
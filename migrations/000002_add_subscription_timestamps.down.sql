@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS updated_at;
\ No newline at end of file
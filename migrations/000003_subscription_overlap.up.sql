@@ -0,0 +1,11 @@
+-- Перед применением миграции пересекающиеся подписки одного пользователя
+-- на один сервис должны быть исправлены, иначе ограничение не создастся.
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_no_overlap
+    EXCLUDE USING gist (
+        user_id WITH =,
+        service_name WITH =,
+        daterange(start_date, end_date, '[)') WITH &&
+    );
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- Удаленные подписки теряются окончательно.
+DELETE FROM subscriptions WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_no_overlap;
+
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_no_overlap
+    EXCLUDE USING gist (
+        user_id WITH =,
+        service_name WITH =,
+        daterange(start_date, end_date, '[)') WITH &&
+    );
+
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS deleted_at;
\ No newline at end of file
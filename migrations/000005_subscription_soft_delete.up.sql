@@ -0,0 +1,14 @@
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Удаленные подписки не должны мешать созданию новых на тот же период.
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_no_overlap;
+
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_no_overlap
+    EXCLUDE USING gist (
+        user_id WITH =,
+        service_name WITH =,
+        daterange(start_date, end_date, '[)') WITH &&
+    )
+    WHERE (deleted_at IS NULL);
\ No newline at end of file
@@ -0,0 +1,18 @@
+-- Журнал изменений подписок. Внешнего ключа на subscriptions нет,
+-- чтобы история сохранялась после удаления подписки навсегда.
+CREATE TABLE IF NOT EXISTS subscription_events (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS subscription_events_subscription_id_idx
+    ON subscription_events (subscription_id, id);
+
+CREATE INDEX IF NOT EXISTS subscription_events_created_at_idx
+    ON subscription_events (created_at);
\ No newline at end of file
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS exchange_rates;
+
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE subscriptions
+    ALTER COLUMN price_minor TYPE INT USING price_minor / 100;
+
+ALTER TABLE subscriptions
+    RENAME COLUMN price_minor TO price;
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- Цена хранится в минимальных единицах валюты (копейках, центах),
+-- существующие цены были в рублях.
+ALTER TABLE subscriptions
+    RENAME COLUMN price TO price_minor;
+
+ALTER TABLE subscriptions
+    ALTER COLUMN price_minor TYPE BIGINT USING price_minor::BIGINT * 100;
+
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'RUB';
+
+-- Курс: 1 from_currency = rate to_currency.
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    from_currency CHAR(3) NOT NULL,
+    to_currency CHAR(3) NOT NULL,
+    rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (from_currency, to_currency),
+    CHECK (from_currency <> to_currency)
+);
\ No newline at end of file
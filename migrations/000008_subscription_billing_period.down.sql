@@ -0,0 +1,5 @@
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS billing_count;
+
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS billing_unit;
\ No newline at end of file
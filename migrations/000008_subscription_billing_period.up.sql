@@ -0,0 +1,8 @@
+-- Существующие подписки оплачивались ежемесячно.
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS billing_unit TEXT NOT NULL DEFAULT 'month'
+        CHECK (billing_unit IN ('day', 'week', 'month', 'quarter', 'year'));
+
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS billing_count INT NOT NULL DEFAULT 1
+        CHECK (billing_count > 0);
\ No newline at end of file
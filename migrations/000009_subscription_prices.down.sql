@@ -0,0 +1 @@
+DROP TABLE IF EXISTS subscription_prices;
\ No newline at end of file
@@ -0,0 +1,14 @@
+-- История цен подписки: цена действует с effective_from
+-- до следующего изменения. Текущие цены действуют с начала подписок.
+CREATE TABLE IF NOT EXISTS subscription_prices (
+    subscription_id INT NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    effective_from DATE NOT NULL,
+    price_minor BIGINT NOT NULL CHECK (price_minor >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (subscription_id, effective_from)
+);
+
+INSERT INTO subscription_prices (subscription_id, effective_from, price_minor)
+SELECT id, start_date, price_minor
+FROM subscriptions
+ON CONFLICT DO NOTHING;
\ No newline at end of file
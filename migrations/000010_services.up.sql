@@ -0,0 +1,53 @@
+-- Каталог сервисов. Подписки с названиями, которые отличаются только
+-- регистром и пробелами, получают один сервис и его каноническое
+-- название. Перед применением миграции пересекающиеся подписки одного
+-- пользователя на такие названия должны быть исправлены, иначе
+-- ограничение subscriptions_no_overlap не даст обновить названия.
+CREATE TABLE IF NOT EXISTS services (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    category VARCHAR(255) NOT NULL DEFAULT '',
+    default_price_minor BIGINT CHECK (default_price_minor > 0),
+    currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Ключи поиска сервиса: название и синонимы в нижнем регистре
+-- с одиночными пробелами.
+CREATE TABLE IF NOT EXISTS service_names (
+    name_key VARCHAR(255) PRIMARY KEY,
+    service_id INT NOT NULL REFERENCES services (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_names_service_id ON service_names (service_id);
+
+UPDATE subscriptions
+SET service_name = btrim(regexp_replace(service_name, '\s+', ' ', 'g'))
+WHERE service_name <> btrim(regexp_replace(service_name, '\s+', ' ', 'g'));
+
+-- Каноническим становится самый частый вариант написания.
+INSERT INTO services (name)
+SELECT DISTINCT ON (lower(service_name)) service_name
+FROM subscriptions
+GROUP BY service_name
+ORDER BY lower(service_name), COUNT(*) DESC, service_name;
+
+INSERT INTO service_names (name_key, service_id)
+SELECT lower(name), id
+FROM services
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS service_id INT REFERENCES services (id);
+
+UPDATE subscriptions s
+SET service_id = n.service_id,
+    service_name = sv.name
+FROM service_names n
+JOIN services sv ON sv.id = n.service_id
+WHERE n.name_key = lower(s.service_name);
+
+ALTER TABLE subscriptions ALTER COLUMN service_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_id ON subscriptions (service_id);
\ No newline at end of file
@@ -0,0 +1,22 @@
+-- Пользователи подписок. Пользователи существующих подписок создаются
+-- без email, его нужно заполнить через PUT /users/{id}.
+CREATE TABLE IF NOT EXISTS users (
+    id UUID PRIMARY KEY,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    display_name VARCHAR(255) NOT NULL DEFAULT '',
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (lower(email)) WHERE email <> '';
+
+INSERT INTO users (id)
+SELECT DISTINCT user_id
+FROM subscriptions
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users (id);
\ No newline at end of file
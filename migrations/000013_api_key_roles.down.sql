@@ -0,0 +1,10 @@
+ALTER TABLE api_keys
+    ADD COLUMN admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE api_keys SET admin = role = 'admin';
+
+DROP INDEX IF EXISTS api_keys_user_id_idx;
+
+ALTER TABLE api_keys
+    DROP COLUMN user_id,
+    DROP COLUMN role;
\ No newline at end of file
@@ -0,0 +1,13 @@
+ALTER TABLE api_keys
+    ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'user',
+    ADD COLUMN user_id UUID REFERENCES users (id) ON DELETE CASCADE;
+
+-- Ключи без прав администратора раньше видели все подписки.
+UPDATE api_keys SET role = CASE WHEN admin THEN 'admin' ELSE 'support' END;
+
+ALTER TABLE api_keys
+    DROP COLUMN admin,
+    ADD CONSTRAINT api_keys_role_check CHECK (role IN ('admin', 'support', 'user')),
+    ADD CONSTRAINT api_keys_user_id_check CHECK (role <> 'user' OR user_id IS NOT NULL);
+
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id);
\ No newline at end of file
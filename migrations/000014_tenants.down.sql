@@ -0,0 +1,77 @@
+-- Данные всех арендаторов объединяются. Если у разных арендаторов
+-- есть одинаковые курсы, названия сервисов или email, миграция
+-- завершится ошибкой.
+DROP POLICY IF EXISTS tenant_isolation ON users;
+DROP POLICY IF EXISTS tenant_isolation ON service_names;
+DROP POLICY IF EXISTS tenant_isolation ON services;
+DROP POLICY IF EXISTS tenant_isolation ON exchange_rates;
+DROP POLICY IF EXISTS tenant_isolation ON subscription_events;
+DROP POLICY IF EXISTS tenant_isolation ON subscription_prices;
+DROP POLICY IF EXISTS tenant_isolation ON subscriptions;
+
+ALTER TABLE users DISABLE ROW LEVEL SECURITY;
+ALTER TABLE service_names DISABLE ROW LEVEL SECURITY;
+ALTER TABLE services DISABLE ROW LEVEL SECURITY;
+ALTER TABLE exchange_rates DISABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_events DISABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_prices DISABLE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions DISABLE ROW LEVEL SECURITY;
+
+DROP INDEX IF EXISTS api_keys_tenant_id_idx;
+DROP INDEX IF EXISTS subscription_events_tenant_id_idx;
+
+ALTER TABLE api_keys DROP CONSTRAINT api_keys_user_id_fkey;
+ALTER TABLE api_keys
+    ADD CONSTRAINT api_keys_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+
+ALTER TABLE subscription_prices DROP CONSTRAINT subscription_prices_subscription_id_fkey;
+ALTER TABLE subscription_prices
+    ADD CONSTRAINT subscription_prices_subscription_id_fkey
+    FOREIGN KEY (subscription_id) REFERENCES subscriptions (id) ON DELETE CASCADE;
+
+ALTER TABLE subscriptions DROP CONSTRAINT subscriptions_service_id_fkey;
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_service_id_fkey
+    FOREIGN KEY (service_id) REFERENCES services (id);
+
+ALTER TABLE subscriptions DROP CONSTRAINT subscriptions_user_id_fkey;
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users (id);
+
+ALTER TABLE service_names DROP CONSTRAINT service_names_service_id_fkey;
+ALTER TABLE service_names DROP CONSTRAINT service_names_pkey;
+ALTER TABLE service_names ADD PRIMARY KEY (name_key);
+ALTER TABLE service_names
+    ADD CONSTRAINT service_names_service_id_fkey
+    FOREIGN KEY (service_id) REFERENCES services (id) ON DELETE CASCADE;
+
+ALTER TABLE subscriptions DROP CONSTRAINT subscriptions_tenant_id_id_key;
+ALTER TABLE services DROP CONSTRAINT services_tenant_id_id_key;
+ALTER TABLE users DROP CONSTRAINT users_tenant_id_id_key;
+
+DROP INDEX IF EXISTS users_email_key;
+CREATE UNIQUE INDEX users_email_key ON users (lower(email)) WHERE email <> '';
+
+ALTER TABLE exchange_rates DROP CONSTRAINT exchange_rates_pkey;
+ALTER TABLE exchange_rates ADD PRIMARY KEY (from_currency, to_currency);
+
+ALTER TABLE api_keys DROP COLUMN tenant_id;
+ALTER TABLE users DROP COLUMN tenant_id;
+ALTER TABLE service_names DROP COLUMN tenant_id;
+ALTER TABLE services DROP COLUMN tenant_id;
+ALTER TABLE exchange_rates DROP COLUMN tenant_id;
+ALTER TABLE subscription_events DROP COLUMN tenant_id;
+ALTER TABLE subscription_prices DROP COLUMN tenant_id;
+ALTER TABLE subscriptions DROP COLUMN tenant_id;
+
+ALTER DEFAULT PRIVILEGES IN SCHEMA public
+    REVOKE USAGE, SELECT ON SEQUENCES FROM subscriptions_tenant;
+
+ALTER DEFAULT PRIVILEGES IN SCHEMA public
+    REVOKE SELECT, INSERT, UPDATE, DELETE ON TABLES FROM subscriptions_tenant;
+
+REVOKE ALL ON ALL SEQUENCES IN SCHEMA public FROM subscriptions_tenant;
+REVOKE ALL ON ALL TABLES IN SCHEMA public FROM subscriptions_tenant;
+REVOKE USAGE ON SCHEMA public FROM subscriptions_tenant;
\ No newline at end of file
@@ -0,0 +1,136 @@
+-- Арендаторы (бизнес-подразделения) не видят данные друг друга.
+-- Существующие данные получают арендатора default. Сервис выполняет
+-- запросы от роли subscriptions_tenant, для которой политики
+-- row-level security оставляют только строки арендатора из настройки
+-- app.tenant_id, поэтому забытое условие в запросе не покажет чужие
+-- данные. Владелец таблиц политики обходит, поэтому миграции видят все
+-- строки. Пользователь миграций должен иметь право CREATEROLE.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'subscriptions_tenant') THEN
+        CREATE ROLE subscriptions_tenant NOLOGIN;
+    END IF;
+END
+$$;
+
+GRANT subscriptions_tenant TO CURRENT_USER;
+
+GRANT USAGE ON SCHEMA public TO subscriptions_tenant;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO subscriptions_tenant;
+GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO subscriptions_tenant;
+
+ALTER DEFAULT PRIVILEGES IN SCHEMA public
+    GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO subscriptions_tenant;
+
+ALTER DEFAULT PRIVILEGES IN SCHEMA public
+    GRANT USAGE, SELECT ON SEQUENCES TO subscriptions_tenant;
+
+ALTER TABLE subscriptions ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE subscription_prices ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE subscription_events ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE exchange_rates ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE services ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE service_names ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE users ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE api_keys ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+-- Новые строки получают арендатора транзакции. Без app.tenant_id
+-- вставка завершится ошибкой, а не создаст строку без арендатора.
+ALTER TABLE subscriptions ALTER COLUMN tenant_id SET DEFAULT current_setting('app.tenant_id');
+ALTER TABLE subscription_prices ALTER COLUMN tenant_id SET DEFAULT current_setting('app.tenant_id');
+ALTER TABLE subscription_events ALTER COLUMN tenant_id SET DEFAULT current_setting('app.tenant_id');
+ALTER TABLE exchange_rates ALTER COLUMN tenant_id SET DEFAULT current_setting('app.tenant_id');
+ALTER TABLE services ALTER COLUMN tenant_id SET DEFAULT current_setting('app.tenant_id');
+ALTER TABLE service_names ALTER COLUMN tenant_id SET DEFAULT current_setting('app.tenant_id');
+ALTER TABLE users ALTER COLUMN tenant_id SET DEFAULT current_setting('app.tenant_id');
+ALTER TABLE api_keys ALTER COLUMN tenant_id SET DEFAULT current_setting('app.tenant_id');
+
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_tenant_id_check CHECK (tenant_id <> '');
+ALTER TABLE subscription_prices ADD CONSTRAINT subscription_prices_tenant_id_check CHECK (tenant_id <> '');
+ALTER TABLE subscription_events ADD CONSTRAINT subscription_events_tenant_id_check CHECK (tenant_id <> '');
+ALTER TABLE exchange_rates ADD CONSTRAINT exchange_rates_tenant_id_check CHECK (tenant_id <> '');
+ALTER TABLE services ADD CONSTRAINT services_tenant_id_check CHECK (tenant_id <> '');
+ALTER TABLE service_names ADD CONSTRAINT service_names_tenant_id_check CHECK (tenant_id <> '');
+ALTER TABLE users ADD CONSTRAINT users_tenant_id_check CHECK (tenant_id <> '');
+ALTER TABLE api_keys ADD CONSTRAINT api_keys_tenant_id_check CHECK (tenant_id <> '');
+
+-- Уникальность в пределах арендатора. ID пользователей, сервисов
+-- и подписок остаются уникальными во всей базе.
+ALTER TABLE exchange_rates DROP CONSTRAINT exchange_rates_pkey;
+ALTER TABLE exchange_rates ADD PRIMARY KEY (tenant_id, from_currency, to_currency);
+
+DROP INDEX IF EXISTS users_email_key;
+CREATE UNIQUE INDEX users_email_key ON users (tenant_id, lower(email)) WHERE email <> '';
+
+-- Ссылки между таблицами не могут вести к другому арендатору.
+ALTER TABLE users ADD CONSTRAINT users_tenant_id_id_key UNIQUE (tenant_id, id);
+ALTER TABLE services ADD CONSTRAINT services_tenant_id_id_key UNIQUE (tenant_id, id);
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_tenant_id_id_key UNIQUE (tenant_id, id);
+
+ALTER TABLE service_names DROP CONSTRAINT service_names_service_id_fkey;
+ALTER TABLE service_names DROP CONSTRAINT service_names_pkey;
+ALTER TABLE service_names ADD PRIMARY KEY (tenant_id, name_key);
+ALTER TABLE service_names
+    ADD CONSTRAINT service_names_service_id_fkey
+    FOREIGN KEY (tenant_id, service_id) REFERENCES services (tenant_id, id) ON DELETE CASCADE;
+
+ALTER TABLE subscriptions DROP CONSTRAINT subscriptions_user_id_fkey;
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_user_id_fkey
+    FOREIGN KEY (tenant_id, user_id) REFERENCES users (tenant_id, id);
+
+ALTER TABLE subscriptions DROP CONSTRAINT subscriptions_service_id_fkey;
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_service_id_fkey
+    FOREIGN KEY (tenant_id, service_id) REFERENCES services (tenant_id, id);
+
+ALTER TABLE subscription_prices DROP CONSTRAINT subscription_prices_subscription_id_fkey;
+ALTER TABLE subscription_prices
+    ADD CONSTRAINT subscription_prices_subscription_id_fkey
+    FOREIGN KEY (tenant_id, subscription_id) REFERENCES subscriptions (tenant_id, id) ON DELETE CASCADE;
+
+ALTER TABLE api_keys DROP CONSTRAINT api_keys_user_id_fkey;
+ALTER TABLE api_keys
+    ADD CONSTRAINT api_keys_user_id_fkey
+    FOREIGN KEY (tenant_id, user_id) REFERENCES users (tenant_id, id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS subscription_events_tenant_id_idx ON subscription_events (tenant_id, id);
+CREATE INDEX IF NOT EXISTS api_keys_tenant_id_idx ON api_keys (tenant_id, id);
+
+-- У api_keys политики нет: ключ ищется по хешу до того, как известен
+-- арендатор. Остальные запросы к ключам отбирают арендатора явно.
+ALTER TABLE subscriptions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_prices ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE exchange_rates ENABLE ROW LEVEL SECURITY;
+ALTER TABLE services ENABLE ROW LEVEL SECURITY;
+ALTER TABLE service_names ENABLE ROW LEVEL SECURITY;
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation ON subscriptions
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+CREATE POLICY tenant_isolation ON subscription_prices
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+CREATE POLICY tenant_isolation ON subscription_events
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+CREATE POLICY tenant_isolation ON exchange_rates
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+CREATE POLICY tenant_isolation ON services
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+CREATE POLICY tenant_isolation ON service_names
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+CREATE POLICY tenant_isolation ON users
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
\ No newline at end of file
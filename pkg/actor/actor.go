@@ -0,0 +1,54 @@
+// Пакет actor хранит в контексте запроса того, кто выполняет
+// изменения. Используется для журнала изменений подписок.
+package actor
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Header Заголовок запроса с именем исполнителя.
+const Header = "X-Actor"
+
+// Anonymous Исполнитель, если он не указан в запросе.
+const Anonymous = "anonymous"
+
+// maxLength Максимальная длина имени исполнителя.
+const maxLength = 255
+
+// ctxKey Тип ключа контекста, чтобы не пересекаться с другими пакетами.
+type ctxKey struct{}
+
+// WithActor Добавление исполнителя в контекст.
+func WithActor(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, name)
+}
+
+// FromContext Получение исполнителя из контекста.
+// Возвращает Anonymous, если исполнитель не задан.
+func FromContext(ctx context.Context) string {
+	name, ok := ctx.Value(ctxKey{}).(string)
+	if !ok || name == "" {
+		return Anonymous
+	}
+
+	return name
+}
+
+// Middleware Получение исполнителя из заголовка X-Actor и
+// сохранение его в контексте запроса. Слишком длинное имя обрезается.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimSpace(r.Header.Get(Header))
+		if runes := []rune(name); len(runes) > maxLength {
+			name = string(runes[:maxLength])
+		}
+
+		if name != "" {
+			r = r.WithContext(WithActor(r.Context(), name))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,227 @@
+// Пакет auth для аутентификации запросов по API-ключам и JWT.
+// Аутентифицированный вызывающий хранится в контексте запроса
+// как Principal.
+package auth
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/SHSanderland/EffMobTest/pkg/config"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/SHSanderland/EffMobTest/pkg/tenant"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnauthorized = errors.New("authentication required")
+	ErrForbidden    = errors.New("permission denied")
+)
+
+// Способы аутентификации вызывающего.
+const (
+	MethodAPIKey   = "api_key"
+	MethodAdminKey = "admin_key"
+	MethodJWT      = "jwt"
+	MethodNone     = "none"
+)
+
+// KeyHeader Заголовок запроса с API-ключом. Ключ можно передать
+// и в заголовке Authorization: Bearer.
+const KeyHeader = "X-API-Key"
+
+// Principal Аутентифицированный вызывающий. Subject уникален
+// и записывается исполнителем в журнал изменений, Name - имя
+// для людей: название ключа или claim name токена. Role - одна
+// из model.Role*, UserID задан для роли user. Tenant - арендатор
+// ключа или токена, пустой, если он не указан. CrossTenant
+// задан только у администратора, который выбирает арендатора
+// заголовком запроса, Tenant у него пустой.
+type Principal struct {
+	Subject     string
+	Name        string
+	Method      string
+	Role        string
+	UserID      uuid.UUID
+	Tenant      string
+	CrossTenant bool
+}
+
+// HasRole Проверка, что у вызывающего одна из ролей.
+func (p *Principal) HasRole(roles ...string) bool {
+	return p != nil && slices.Contains(roles, p.Role)
+}
+
+// ctxKey Тип ключа контекста, чтобы не пересекаться с другими пакетами.
+type ctxKey struct{}
+
+// WithPrincipal Добавление вызывающего в контекст.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext Получение вызывающего из контекста.
+// Возвращает nil, если запрос не аутентифицирован.
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(ctxKey{}).(*Principal)
+
+	return p
+}
+
+// keyFinder Интерефейс с методами к базе данных,
+// который использует Authenticator.
+type keyFinder interface {
+	FindAPIKey(ctx context.Context, hash string) (*model.APIKey, error)
+}
+
+// Authenticator Проверка учетных данных запроса: административного
+// ключа из конфига, API-ключей из базы данных и JWT.
+type Authenticator struct {
+	keys             keyFinder
+	adminHash        []byte
+	adminCrossTenant bool
+	verifier         *Verifier
+}
+
+// New Инициализация Authenticator по конфигу. JWT принимаются, только
+// если задан секрет HS256 или файл JWKS с ключами RS256.
+func New(cfg *config.Auth, keys keyFinder) (*Authenticator, error) {
+	a := Authenticator{keys: keys, adminCrossTenant: cfg.AdminCrossTenant}
+
+	if cfg.AdminKey != "" {
+		hash := sha256.Sum256([]byte(cfg.AdminKey))
+		a.adminHash = hash[:]
+	}
+
+	if cfg.Secret != "" || cfg.JWKSFile != "" {
+		verifier, err := NewVerifier(&cfg.JWT)
+		if err != nil {
+			return nil, err
+		}
+
+		a.verifier = verifier
+	}
+
+	return &a, nil
+}
+
+// Authenticate Аутентификация запроса по заголовку Authorization: Bearer
+// или X-API-Key. Токен из трех частей через точку проверяется как JWT,
+// остальные - как API-ключ. Возвращает ErrUnauthorized, если учетных
+// данных нет или они неверны.
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	credential := strings.TrimSpace(r.Header.Get(KeyHeader))
+
+	if credential == "" {
+		scheme, token, ok := strings.Cut(strings.TrimSpace(r.Header.Get("Authorization")), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return nil, ErrUnauthorized
+		}
+
+		credential = strings.TrimSpace(token)
+	}
+
+	if credential == "" {
+		return nil, ErrUnauthorized
+	}
+
+	if strings.Count(credential, ".") == 2 {
+		return a.authenticateJWT(credential)
+	}
+
+	return a.authenticateKey(r.Context(), credential)
+}
+
+// authenticateJWT Аутентификация по JWT.
+func (a *Authenticator) authenticateJWT(token string) (*Principal, error) {
+	if a.verifier == nil {
+		return nil, fmt.Errorf("%w: JWT is not configured", ErrUnauthorized)
+	}
+
+	claims, err := a.verifier.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	p := Principal{
+		Subject: claims.Subject,
+		Name:    claims.Name,
+		Method:  MethodJWT,
+		Role:    cmp.Or(claims.Role, model.RoleUser),
+	}
+
+	if !model.IsRole(p.Role) {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrUnauthorized, p.Role)
+	}
+
+	switch {
+	case claims.TenantID == AnyTenant && p.Role != model.RoleAdmin:
+		return nil, fmt.Errorf("%w: only admin token may have tenant_id %q", ErrUnauthorized, AnyTenant)
+	case claims.TenantID == AnyTenant:
+		p.CrossTenant = true
+	case claims.TenantID != "":
+		id, err := tenant.Parse(claims.TenantID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+		}
+
+		p.Tenant = id
+	}
+
+	if p.Role == model.RoleUser {
+		userID, err := uuid.Parse(cmp.Or(claims.UserID, claims.Subject))
+		if err != nil {
+			return nil, fmt.Errorf("%w: token of role user has no user ID", ErrUnauthorized)
+		}
+
+		p.UserID = userID
+	}
+
+	return &p, nil
+}
+
+// authenticateKey Аутентификация по административному ключу
+// или API-ключу из базы данных. API-ключ работает в арендаторе,
+// в котором создан.
+func (a *Authenticator) authenticateKey(ctx context.Context, key string) (*Principal, error) {
+	hash := sha256.Sum256([]byte(key))
+
+	if a.adminHash != nil && subtle.ConstantTimeCompare(hash[:], a.adminHash) == 1 {
+		p := Principal{Subject: "admin", Name: "admin", Method: MethodAdminKey, Role: model.RoleAdmin}
+		p.CrossTenant = a.adminCrossTenant
+
+		return &p, nil
+	}
+
+	apiKey, err := a.keys.FindAPIKey(ctx, HashKey(key))
+	if errors.Is(err, storage.ErrAPIKeyNotFound) {
+		return nil, fmt.Errorf("%w: unknown API key", ErrUnauthorized)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := Principal{
+		Subject: "api_key:" + strconv.FormatInt(apiKey.ID, 10),
+		Name:    apiKey.Name,
+		Method:  MethodAPIKey,
+		Role:    apiKey.Role,
+		Tenant:  apiKey.TenantID,
+	}
+
+	if apiKey.UserID != nil {
+		p.UserID = *apiKey.UserID
+	}
+
+	return &p, nil
+}
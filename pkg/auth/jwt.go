@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Поддерживаемые алгоритмы подписи JWT.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+)
+
+// AnyTenant Значение claim tenant_id токена администратора, который
+// выбирает арендатора заголовком запроса.
+const AnyTenant = "*"
+
+// Claims Claims JWT, которые использует сервис. Subject, срок exp
+// обязательны. Без role токен получает роль user, пользователь берется
+// из user_id или, если его нет, из sub. Токен действует только
+// в арендаторе tenant_id, без него - в tenant.Default, а токен
+// администратора с tenant_id AnyTenant - в любом.
+type Claims struct {
+	jwt.RegisteredClaims
+
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	UserID   string `json:"user_id"`
+	TenantID string `json:"tenant_id"`
+}
+
+// Verifier Проверка подписи и claims JWT. HS256 проверяется общим
+// секретом, RS256 - открытыми ключами из файла JWKS.
+type Verifier struct {
+	secret []byte
+	keys   map[string]*rsa.PublicKey
+	parser *jwt.Parser
+}
+
+// NewVerifier Инициализация Verifier по конфигу. Возвращает ошибку,
+// если файл JWKS не читается или в нем нет ключей RS256. Сроки exp
+// и nbf проверяются с допуском Leeway, пустые Issuer и Audience
+// не проверяются, но конфиг требует их при включенной аутентификации.
+func NewVerifier(cfg *config.JWT) (*Verifier, error) {
+	return newVerifier(cfg, time.Now)
+}
+
+// newVerifier Инициализация Verifier с часами now.
+func newVerifier(cfg *config.JWT, now func() time.Time) (*Verifier, error) {
+	v := Verifier{secret: []byte(cfg.Secret)}
+
+	if cfg.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+
+		v.keys = keys
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{AlgHS256, AlgRS256}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+		jwt.WithTimeFunc(now),
+	}
+
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	v.parser = jwt.NewParser(opts...)
+
+	return &v, nil
+}
+
+// Verify Проверка JWT. Возвращает claims токена или ErrUnauthorized
+// с причиной, если подпись, срок действия, издатель или получатель
+// не подходят.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	var claims Claims
+
+	if _, err := v.parser.ParseWithClaims(token, &claims, v.key); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: sub is required", ErrUnauthorized)
+	}
+
+	return &claims, nil
+}
+
+// key Ключ проверки подписи по алгоритму и kid из заголовка токена.
+// Алгоритмы без настроенного ключа не принимаются.
+func (v *Verifier) key(t *jwt.Token) (any, error) {
+	switch t.Method.Alg() {
+	case AlgHS256:
+		if len(v.secret) == 0 {
+			return nil, errors.New("HS256 is not configured")
+		}
+
+		return v.secret, nil
+	case AlgRS256:
+		kid, _ := t.Header["kid"].(string)
+
+		return v.rsaKey(kid)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", t.Method.Alg())
+	}
+}
+
+// rsaKey Открытый ключ по kid. Без kid используется единственный ключ
+// из JWKS.
+func (v *Verifier) rsaKey(kid string) (*rsa.PublicKey, error) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, nil
+		}
+	}
+
+	key, ok := v.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	return key, nil
+}
+
+// jwk Открытый ключ из JWKS (RFC 7517).
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// loadJWKS Чтение ключей RS256 из файла JWKS. Ключи других типов
+// и назначений пропускаются.
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS: %w", err)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") || (k.Alg != "" && k.Alg != AlgRS256) {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JWKS key %q: %w", k.Kid, err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JWKS key %q: %w", k.Kid, err)
+		}
+
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() < 3 {
+			return nil, fmt.Errorf("failed to parse JWKS key %q: invalid exponent", k.Kid)
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no RS256 keys in JWKS %s", path)
+	}
+
+	return keys, nil
+}
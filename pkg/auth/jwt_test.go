@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestVerifierVerify(t *testing.T) {
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	secret := []byte("s3cret")
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+
+	v, err := newVerifier(&config.JWT{
+		Secret:   string(secret),
+		JWKSFile: writeJWKS(t, "key-1", &rsaKey.PublicKey),
+		Issuer:   "subscriptions-auth",
+		Audience: "subscriptions-api",
+		Leeway:   30 * time.Second,
+	}, func() time.Time { return now })
+	if err != nil {
+		t.Fatalf("newVerifier unexpected error: %v", err)
+	}
+
+	claims := func(edit func(c jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub":       "support@example.com",
+			"iss":       "subscriptions-auth",
+			"aud":       "subscriptions-api",
+			"exp":       now.Add(time.Hour).Unix(),
+			"role":      "support",
+			"tenant_id": "acme",
+		}
+		edit(c)
+
+		return c
+	}
+	valid := func(jwt.MapClaims) {}
+
+	hs256 := func(c jwt.MapClaims) string {
+		return sign(t, jwt.NewWithClaims(jwt.SigningMethodHS256, c), secret)
+	}
+	rs256 := func(kid string, c jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, c)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+
+		return sign(t, token, rsaKey)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "HS256", token: hs256(claims(valid))},
+		{name: "RS256 with kid", token: rs256("key-1", claims(valid))},
+		{name: "RS256 with single key", token: rs256("", claims(valid))},
+		{
+			name:  "audience list",
+			token: hs256(claims(func(c jwt.MapClaims) { c["aud"] = []string{"other", "subscriptions-api"} })),
+		},
+		{
+			name:  "expired within leeway",
+			token: hs256(claims(func(c jwt.MapClaims) { c["exp"] = now.Add(-10 * time.Second).Unix() })),
+		},
+		{
+			name:  "nbf within leeway",
+			token: hs256(claims(func(c jwt.MapClaims) { c["nbf"] = now.Add(10 * time.Second).Unix() })),
+		},
+		{
+			name:    "expired",
+			token:   hs256(claims(func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Minute).Unix() })),
+			wantErr: true,
+		},
+		{
+			name:    "not valid yet",
+			token:   hs256(claims(func(c jwt.MapClaims) { c["nbf"] = now.Add(time.Minute).Unix() })),
+			wantErr: true,
+		},
+		{name: "no exp", token: hs256(claims(func(c jwt.MapClaims) { delete(c, "exp") })), wantErr: true},
+		{name: "no sub", token: hs256(claims(func(c jwt.MapClaims) { delete(c, "sub") })), wantErr: true},
+		{name: "wrong issuer", token: hs256(claims(func(c jwt.MapClaims) { c["iss"] = "other" })), wantErr: true},
+		{name: "wrong audience", token: hs256(claims(func(c jwt.MapClaims) { c["aud"] = "other" })), wantErr: true},
+		{
+			name:    "wrong secret",
+			token:   sign(t, jwt.NewWithClaims(jwt.SigningMethodHS256, claims(valid)), []byte("other")),
+			wantErr: true,
+		},
+		{name: "wrong kid", token: rs256("key-2", claims(valid)), wantErr: true},
+		{
+			name:    "bad alg HS384",
+			token:   sign(t, jwt.NewWithClaims(jwt.SigningMethodHS384, claims(valid)), secret),
+			wantErr: true,
+		},
+		{
+			name:    "bad alg none",
+			token:   sign(t, jwt.NewWithClaims(jwt.SigningMethodNone, claims(valid)), jwt.UnsafeAllowNoneSignatureType),
+			wantErr: true,
+		},
+		{name: "malformed", token: "not.a.token", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Verify(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnauthorized) {
+					t.Fatalf("Verify error = %v, want ErrUnauthorized", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Verify unexpected error: %v", err)
+			}
+
+			if got.Subject != "support@example.com" || got.Role != "support" || got.TenantID != "acme" {
+				t.Errorf("Verify claims = %+v", got)
+			}
+		})
+	}
+}
+
+func TestVerifierWithoutSecret(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+
+	v, err := NewVerifier(&config.JWT{JWKSFile: writeJWKS(t, "key-1", &rsaKey.PublicKey)})
+	if err != nil {
+		t.Fatalf("NewVerifier unexpected error: %v", err)
+	}
+
+	token := sign(t, jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "admin",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}), []byte(""))
+
+	if _, err := v.Verify(token); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Verify error = %v, want ErrUnauthorized", err)
+	}
+}
+
+// sign Подпись токена ключом key.
+func sign(t *testing.T, token *jwt.Token, key any) string {
+	t.Helper()
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	return signed
+}
+
+// writeJWKS Запись JWKS с одним ключом RS256 во временный файл.
+func writeJWKS(t *testing.T, kid string, key *rsa.PublicKey) string {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]any{
+		"keys": []jwk{{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			Alg: AlgRS256,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal JWKS: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("failed to write JWKS: %v", err)
+	}
+
+	return path
+}
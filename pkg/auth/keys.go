@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// KeyPrefix Начало каждого API-ключа, чтобы ключ было легко узнать.
+const KeyPrefix = "emk_"
+
+// keyPrefixLength Длина видимой части ключа в model.APIKey.Prefix.
+const keyPrefixLength = len(KeyPrefix) + 8
+
+// GenerateKey Создание нового API-ключа из 32 случайных байт.
+// Возвращает ключ, его видимую часть и хеш для хранения.
+func GenerateKey() (key, prefix, hash string, err error) {
+	buf := make([]byte, 32)
+
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	key = KeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	return key, key[:keyPrefixLength], HashKey(key), nil
+}
+
+// HashKey Хеш API-ключа для хранения и поиска. Ключи случайные
+// и длинные, поэтому медленный хеш с солью не нужен.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
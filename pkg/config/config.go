@@ -17,20 +17,94 @@ type Config struct {
 	Env      string `yaml:"env" env:"ENV" env-default:"local"`
 	Server   `yaml:"server"`
 	Database `yaml:"database"`
+	Auth     `yaml:"auth"`
+	Tracing  `yaml:"tracing"`
+	Metrics  `yaml:"metrics"`
 }
 
-// Server Конфиг с настройками сервера.
+// Server Конфиг с настройками сервера. При выключении сервер сначала
+// ShutdownDelay отвечает на /readyz ошибкой, чтобы балансировщик
+// перестал отправлять запросы, затем до ShutdownTimeout завершает
+// начатые запросы.
 type Server struct {
-	Addr         string        `yaml:"address" env:"ADDR" env-default:":8080"`
-	ReadTimeout  time.Duration `yaml:"read_timeout" env:"RT" env-default:"5s"`
-	WriteTimeout time.Duration `yaml:"write_timeout" env:"WT" env-default:"5s"`
-	IdleTimeout  time.Duration `yaml:"idle_timeout" env:"IT" env-default:"10s"`
+	Addr            string        `yaml:"address" env:"ADDR" env-default:":8080"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" env:"RT" env-default:"5s"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" env:"WT" env-default:"5s"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" env:"IT" env-default:"10s"`
+	ShutdownDelay   time.Duration `yaml:"shutdown_delay" env:"SHUTDOWN_DELAY" env-default:"5s"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" env-default:"10s"`
 }
 
+// Драйверы хранилища подписок.
+const (
+	DriverPostgres = "postgres"
+	DriverMemory   = "memory"
+)
+
 // Database Конфиг с параметрами базы данных.
+// DSN обязателен только для драйвера postgres. ConnectTimeout - сколько
+// при запуске ждать, пока база данных станет доступна.
 type Database struct {
-	DSN       string `yaml:"dsn" env:"DSN" env-required:"true"`
-	SourceURL string `yaml:"sourceURL" env:"SURL" env-default:"./migrations"`
+	Driver         string        `yaml:"driver" env:"DB_DRIVER" env-default:"postgres"`
+	DSN            string        `yaml:"dsn" env:"DSN"`
+	SourceURL      string        `yaml:"sourceURL" env:"SURL" env-default:"./migrations"`
+	ConnectTimeout time.Duration `yaml:"connect_timeout" env:"DB_CONNECT_TIMEOUT" env-default:"60s"`
+}
+
+// Auth Конфиг аутентификации. AdminKey - ключ администратора, который
+// не хранится в базе данных и нужен, чтобы создать первые API-ключи.
+// Ключ работает в арендаторе по умолчанию, а с AdminCrossTenant
+// выбирает арендатора заголовком X-Tenant-ID.
+// Без аутентификации (Enabled: false) все запросы выполняются
+// от имени администратора, это подходит только для локального запуска.
+type Auth struct {
+	Enabled          bool   `yaml:"enabled" env:"AUTH_ENABLED" env-default:"true"`
+	AdminKey         string `yaml:"admin_key" env:"AUTH_ADMIN_KEY"`
+	AdminCrossTenant bool   `yaml:"admin_cross_tenant" env:"AUTH_ADMIN_CROSS_TENANT" env-default:"false"`
+	JWT              `yaml:"jwt"`
+}
+
+// JWT Конфиг проверки JWT. Secret включает HS256, JWKSFile - RS256
+// с ключами из локального файла JWKS. Если JWT включены вместе
+// с аутентификацией, Issuer и Audience обязательны. Leeway - допуск
+// расхождения часов при проверке exp и nbf.
+type JWT struct {
+	Secret   string        `yaml:"secret" env:"JWT_SECRET"`
+	JWKSFile string        `yaml:"jwks_file" env:"JWT_JWKS_FILE"`
+	Issuer   string        `yaml:"issuer" env:"JWT_ISSUER"`
+	Audience string        `yaml:"audience" env:"JWT_AUDIENCE"`
+	Leeway   time.Duration `yaml:"leeway" env:"JWT_LEEWAY" env-default:"30s"`
+}
+
+// Экспортеры трассировки.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Tracing Конфиг трассировки OpenTelemetry. Exporter otlp отправляет
+// спаны по OTLP/HTTP на Endpoint, stdout и file пишут их в JSON в stdout
+// или в файл File для работы без коллектора, none выключает трассировку.
+// SampleRatio - доля трассируемых запросов, решение родительского спана
+// из заголовка traceparent учитывается.
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT" env-default:"localhost:4318"`
+	Insecure    bool    `yaml:"insecure" env:"TRACING_INSECURE" env-default:"true"`
+	File        string  `yaml:"file" env:"TRACING_FILE" env-default:"traces.json"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME" env-default:"subscriptions"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+}
+
+// Metrics Конфиг метрик Prometheus. Метрики отдаются отдельным сервером
+// на MetricsAddr, который не публикуется наружу вместе с API, пустой
+// адрес выключает его. Действующие подписки пересчитываются каждые
+// RefreshInterval.
+type Metrics struct {
+	MetricsAddr     string        `yaml:"address" env:"METRICS_ADDR" env-default:":9090"`
+	RefreshInterval time.Duration `yaml:"refresh_interval" env:"METRICS_REFRESH_INTERVAL" env-default:"30s"`
 }
 
 // InitConfig Функция инициализации конфига.
@@ -56,5 +130,33 @@ func InitConfig(flagPath string) *Config {
 		log.Fatalf("error parce file: %v", err)
 	}
 
+	switch cfg.Driver {
+	case DriverPostgres:
+		if cfg.DSN == "" {
+			log.Fatal("database dsn is required for postgres driver")
+		}
+	case DriverMemory:
+	default:
+		log.Fatalf("unknown database driver: %s", cfg.Driver)
+	}
+
+	switch cfg.Exporter {
+	case ExporterNone, ExporterOTLP, ExporterStdout, ExporterFile:
+	default:
+		log.Fatalf("unknown tracing exporter: %s", cfg.Exporter)
+	}
+
+	if cfg.Auth.Enabled && (cfg.Secret != "" || cfg.JWKSFile != "") && (cfg.Issuer == "" || cfg.Audience == "") {
+		log.Fatal("jwt issuer and audience are required when JWT authentication is enabled")
+	}
+
+	if cfg.Leeway < 0 {
+		log.Fatal("jwt leeway must not be negative")
+	}
+
+	if cfg.RefreshInterval <= 0 {
+		log.Fatal("metrics refresh interval must be positive")
+	}
+
 	return &cfg
 }
@@ -0,0 +1,92 @@
+// Пакет audit для хендлера общего журнала изменений подписок.
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// listEvents Интерефейс с методами к базе данных,
+// который использует хендлер.
+type listEvents interface {
+	GetListEvents(ctx context.Context, filter *model.EventParams) (*model.EventPage, error)
+}
+
+// urlParser Интерефейс с методами к Service,
+// который использует хендлер.
+type urlParser interface {
+	GetEventParams(r *http.Request) (*model.EventParams, error)
+}
+
+// userResponse Структура для ответа пользователю.
+type userResponse struct {
+	Events     []*model.SubscriptionEvent `json:"events"`
+	Total      int                        `json:"total"`
+	NextCursor string                     `json:"next_cursor,omitempty"`
+}
+
+// @Summary		Получить журнал изменений
+// @Description	Возвращает страницу журнала изменений всех подписок в порядке изменений.
+// @Description	Для получения следующей страницы передайте next_cursor из ответа в параметр
+// @Description	cursor с теми же фильтрами.
+// @Tags			audit
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			actor	query		string			false	"Исполнитель изменения"						Example(anonymous)
+// @Param			from	query		string			false	"Изменения не раньше (формат RFC 3339)"		Example(2025-07-01T00:00:00Z)
+// @Param			to		query		string			false	"Изменения не позже (формат RFC 3339)"		Example(2025-08-01T00:00:00Z)
+// @Param			limit	query		int				false	"Размер страницы (по умолчанию 50, максимум 500)"	Example(50)
+// @Param			cursor	query		string			false	"Курсор следующей страницы из next_cursor"
+// @Success		200		{object}	userResponse	"Успешный запрос"
+// @Failure		400		{object}	response.Error	"Невалидные параметры запроса"
+// @Failure		401		{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403		{object}	response.Error	"Доступно только admin и support"
+// @Failure		500		{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/audit [get]
+func Handler(
+	l *slog.Logger, le listEvents, up urlParser,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.audit.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	filter, err := up.GetEventParams(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to parse url", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	page, err := le.GetListEvents(r.Context(), filter)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to get audit events", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	userResp := userResponse{page.Events, len(page.Events), page.NextCursor}
+
+	if err := response.JSON(w, http.StatusOK, userResp); err != nil {
+		log.ErrorContext(r.Context(), "failed to encode json", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(
+		r.Context(),
+		"Audit events sended successfully!",
+		slog.String("actor", filter.Actor),
+		slog.Int("count", len(page.Events)),
+	)
+}
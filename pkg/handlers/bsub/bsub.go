@@ -0,0 +1,203 @@
+// Пакет bsub для хендлера BatchSubscriptions.
+package bsub
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/policy"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// batchSubscriptions Интерефейс с методами к базе данных,
+// который использует хендлер.
+type batchSubscriptions interface {
+	BatchSubscriptions(ctx context.Context, ops []*model.BatchOperation, atomic bool) ([]*model.BatchResult, error)
+	FindService(ctx context.Context, name string) (*model.Service, error)
+}
+
+// helper Интерефейс с методами к Service,
+// который использует хендлер.
+type helper interface {
+	CheckOperation(op *model.BatchOperation) error
+	GetQueryFlag(r *http.Request, key string) (bool, error)
+}
+
+// itemResponse Результат одной операции пакета для ответа пользователю.
+// Status совпадает со статусом ответа на такой же одиночный запрос.
+type itemResponse struct {
+	Index        int                 `json:"index" example:"0"`
+	Status       int                 `json:"status" example:"201"`
+	Subscription *model.Subscription `json:"subscription,omitempty"`
+	Error        *response.Error     `json:"error,omitempty"`
+}
+
+// userResponse Структура для ответа пользователю.
+type userResponse struct {
+	Results   []*itemResponse `json:"results"`
+	Succeeded int             `json:"succeeded"`
+	Failed    int             `json:"failed"`
+}
+
+// successStatus Статусы успешных операций пакета.
+var successStatus = map[string]int{
+	model.BatchCreate: http.StatusCreated,
+	model.BatchUpdate: http.StatusOK,
+	model.BatchDelete: http.StatusNoContent,
+}
+
+// @Summary		Пакетно изменить подписки
+// @Description	Выполняет до 1000 операций create, update и delete по порядку в одной транзакции.
+// @Description	Подписки проверяются так же, как в POST и PUT, version заменяет заголовок If-Match,
+// @Description	effective_from - параметр effective_from запроса PUT.
+// @Description	Результат каждой операции возвращается в results с тем же индексом. По умолчанию
+// @Description	ошибка операции не мешает остальным, с atomic=true любая ошибка отменяет весь пакет,
+// @Description	а остальные операции получают ошибку batch_aborted.
+// @Tags			subscriptions
+// @Accept			json
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			atomic	query		bool				false	"Отменить весь пакет при любой ошибке"
+// @Param			input	body		model.BatchRequest	true	"Операции пакета"
+// @Success		200		{object}	userResponse		"Пакет обработан, результаты операций в results"
+// @Failure		400		{object}	response.Error		"Невалидный JSON тела запроса или atomic"
+// @Failure		413		{object}	response.Error		"Слишком большое тело запроса"
+// @Failure		422		{object}	response.Error		"Пустой пакет или больше 1000 операций"
+// @Failure		401		{object}	response.Error		"Запрос не аутентифицирован"
+// @Failure		403		{object}	response.Error		"Доступно только admin и support"
+// @Failure		500		{object}	response.Error		"Внутренняя ошибка сервера"
+// @Router			/subscriptions:batch [post]
+func Handler(
+	l *slog.Logger, bs batchSubscriptions, h helper,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.bsub.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	atomic, err := h.GetQueryFlag(r, "atomic")
+	if err != nil {
+		log.ErrorContext(r.Context(), "bad atomic", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	batch, err := model.GetBatchFromBody(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to get body", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	results := make([]*model.BatchResult, len(batch.Operations))
+	valid := make([]*model.BatchOperation, 0, len(batch.Operations))
+	indexes := make([]int, 0, len(batch.Operations))
+	failed := -1
+	catalog := storage.NewCatalog(bs)
+
+	for i, op := range batch.Operations {
+		// Подписки create заполняются по каталогу до проверки,
+		// как в POST /subscriptions.
+		if op.Op == model.BatchCreate && op.Subscription != nil {
+			if err := catalog.Apply(r.Context(), op.Subscription); err != nil {
+				log.ErrorContext(r.Context(), "failed to find service", slog.String("err", err.Error()))
+				response.SendError(w, r, err)
+
+				return
+			}
+		}
+
+		if err := h.CheckOperation(op); err != nil {
+			results[i] = &model.BatchResult{Err: err}
+			failed = i
+
+			continue
+		}
+
+		if err := authorizeOperation(r.Context(), op); err != nil {
+			results[i] = &model.BatchResult{Err: err}
+			failed = i
+
+			continue
+		}
+
+		valid = append(valid, op)
+		indexes = append(indexes, i)
+	}
+
+	switch {
+	case atomic && failed >= 0:
+		log.ErrorContext(r.Context(), "invalid operation in atomic batch", slog.Int("index", failed))
+		storage.AbortBatch(results, failed)
+	case len(valid) > 0:
+		done, err := bs.BatchSubscriptions(r.Context(), valid, atomic)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed batch subscriptions", slog.String("err", err.Error()))
+			response.SendError(w, r, err)
+
+			return
+		}
+
+		for i, result := range done {
+			results[indexes[i]] = result
+		}
+	}
+
+	userResp := newResponse(r, batch.Operations, results)
+
+	if err := response.JSON(w, http.StatusOK, userResp); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(
+		r.Context(),
+		"Batch done successfully!",
+		slog.Int("succeeded", userResp.Succeeded),
+		slog.Int("failed", userResp.Failed),
+	)
+}
+
+// newResponse Преобразование результатов операций в ответ пользователю.
+func newResponse(r *http.Request, ops []*model.BatchOperation, results []*model.BatchResult) *userResponse {
+	userResp := userResponse{Results: make([]*itemResponse, len(results))}
+
+	for i, result := range results {
+		item := itemResponse{Index: i, Subscription: result.Subscription}
+
+		if result.Err != nil {
+			item.Status, item.Error = response.NewError(r, result.Err)
+			userResp.Failed++
+		} else {
+			item.Status = successStatus[ops[i].Op]
+			userResp.Succeeded++
+		}
+
+		userResp.Results[i] = &item
+	}
+
+	return &userResp
+}
+
+// authorizeOperation Проверка права на операцию пакета. Пакет доступен
+// только сотрудникам, поэтому владелец подписки не проверяется.
+func authorizeOperation(ctx context.Context, op *model.BatchOperation) error {
+	switch {
+	case op.Op != model.BatchDelete:
+		return policy.Authorize(ctx, policy.ActionWrite)
+	case op.Hard:
+		return policy.Authorize(ctx, policy.ActionPurge)
+	default:
+		return policy.Authorize(ctx, policy.ActionDelete)
+	}
+}
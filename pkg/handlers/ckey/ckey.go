@@ -0,0 +1,111 @@
+// Пакет ckey для хендлера CreateAPIKey.
+package ckey
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/auth"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// createAPIKey Интерефейс с методами к базе данных,
+// который использует хендлер.
+type createAPIKey interface {
+	CreateAPIKey(ctx context.Context, key *model.APIKey) (*model.APIKey, error)
+}
+
+// checker Интерефейс с методами к Service,
+// который использует хендлер.
+type checker interface {
+	CheckAPIKey(key *model.APIKey) error
+}
+
+// userResponse Структура для ответа пользователю.
+type userResponse struct {
+	*model.APIKey
+	Key string `json:"key" example:"emk_AbCdEfGhIjKlMnOpQrStUvWxYz0123456789abcdefg"`
+}
+
+// @Summary		Создать API-ключ
+// @Description	Создает API-ключ для заголовка X-API-Key или Authorization: Bearer. Ключ возвращается
+// @Description	только в этом ответе, в базе данных хранится его хеш. Роль role задает права ключа:
+// @Description	admin, support или user (по умолчанию), ключу с ролью user нужен user_id существующего
+// @Description	пользователя, от имени которого он действует. Ключ принадлежит арендатору запроса
+// @Description	и работает только с его данными. Требует прав администратора.
+// @Tags			admin
+// @Accept			json
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			input	body		model.APIKey	true	"Название ключа и права"
+// @Success		201		{object}	userResponse	"Ключ создан"
+// @Header			201		{string}	Location		"Адрес ключа"
+// @Failure		400		{object}	response.Error	"Невалидный JSON тела запроса"
+// @Failure		401		{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403		{object}	response.Error	"Нет прав администратора"
+// @Failure		422		{object}	response.Error	"Нарушения валидации полей в details"
+// @Failure		500		{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/admin/api-keys [post]
+func Handler(
+	l *slog.Logger, ck createAPIKey, c checker,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.ckey.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	key, err := model.GetAPIKeyFromBody(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed get user body", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := c.CheckAPIKey(key); err != nil {
+		log.ErrorContext(r.Context(), "bad body", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	plain, prefix, hash, err := auth.GenerateKey()
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to generate API key", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	key.Prefix, key.Hash = prefix, hash
+
+	created, err := ck.CreateAPIKey(r.Context(), key)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to create API key", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("%s/%d", r.URL.Path, created.ID))
+
+	if err := response.JSON(w, http.StatusCreated, userResponse{created, plain}); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(
+		r.Context(),
+		"API key created successfully!",
+		slog.Int64("ID", created.ID),
+		slog.String("role", created.Role),
+	)
+}
@@ -3,19 +3,21 @@ package costsub
 
 import (
 	"context"
-	"encoding/json"
 	"log/slog"
 	"net/http"
 	"time"
 
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
 	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/policy"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
 )
 
 // costSubscription Интерефейс с методами к базе данных,
 // который использует хендлер.
 type costSubscription interface {
-	CostSubscription(ctx context.Context, filter *model.CostParams) (int64, error)
+	CostSubscription(ctx context.Context, filter *model.CostParams) (*model.CostReport, error)
 }
 
 // helper Интерефейс с методами к Service,
@@ -26,23 +28,56 @@ type helper interface {
 
 // userResponse Структура для ответа пользователю.
 type userResponse struct {
-	ServiceName string     `json:"service_name"`
-	StartDate   time.Time  `json:"start_period"`
-	EndDate     *time.Time `json:"end_period"`
-	TotalCost   int64      `json:"total_cost"`
+	UserID        *uuid.UUID                `json:"user_id,omitempty"`
+	ServiceNames  []string                  `json:"service_names,omitempty"`
+	StartDate     time.Time                 `json:"start_period"`
+	EndDate       time.Time                 `json:"end_period"`
+	Currency      string                    `json:"currency,omitempty"`
+	TotalCost     model.Costs               `json:"total_cost" swaggertype:"object,number"`
+	Subscriptions []*model.SubscriptionCost `json:"subscriptions"`
+	Months        []*model.MonthCost        `json:"months"`
+}
+
+// groupResponse Структура для ответа пользователю
+// при заданной группировке.
+type groupResponse struct {
+	UserID       *uuid.UUID         `json:"user_id,omitempty"`
+	ServiceNames []string           `json:"service_names,omitempty"`
+	StartDate    time.Time          `json:"start_period"`
+	EndDate      time.Time          `json:"end_period"`
+	Currency     string             `json:"currency,omitempty"`
+	GroupBy      string             `json:"group_by"`
+	Groups       []*model.CostGroup `json:"groups"`
 }
 
 // @Summary		Рассчитать стоимость подписок
-// @Description	Возвращает суммарную стоимость подписок за указанный период с возможностью фильтрации
+// @Description	Возвращает суммарную стоимость подписок за указанный период с возможностью фильтрации.
+// @Description	Цена списывается в начале каждого периода оплаты billing_period, стоимость подписки - цена,
+// @Description	умноженная на число списаний в период. Границы периода включаются, MM-YYYY в start_date
+// @Description	означает первое число месяца, в end_date - последнее. Ответ содержит разбивку суммы
+// @Description	по подпискам (с числом списаний cycles) и по месяцам списаний. При заданном group_by
+// @Description	вместо общей суммы и разбивки возвращаются group_by и groups: [{key, cost}].
+// @Description	Суммы возвращаются по валютам подписок, например {RUB: 1200, USD: 9.99}. С параметром currency
+// @Description	цены подписок пересчитываются по курсам из /rates и все суммы возвращаются в этой валюте.
+// @Description	Для пользователя с ролью user стоимость считается только по его подпискам.
+// @Description	Период не длиннее 120 месяцев, в том числе начатый с самой ранней подписки без start_date.
 // @Tags			subscriptions
 // @Produce		json
-// @Param			user_id			query		string			true	"UUID пользователя"					Example(550e8400-e29b-41d4-a716-446655440000)
-// @Param			service_name	query		string			true	"Название сервиса"					Example(netflix)
-// @Param			start_date		query		string			true	"Начало периода (формат MM-YYYY)"	Example(01-2023)
-// @Param			end_date		query		string			true	"Конец периода (формат MM-YYYY)"	Example(12-2023)
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			user_id			query		string			false	"UUID пользователя"									Example(550e8400-e29b-41d4-a716-446655440000)
+// @Param			service_name	query		[]string		false	"Название сервиса, можно передать несколько раз"	collectionFormat(multi)
+// @Param			start_date		query		string			false	"Начало периода (MM-YYYY или YYYY-MM-DD)"					Example(01-2023)
+// @Param			end_date		query		string			false	"Конец периода (MM-YYYY или YYYY-MM-DD), по умолчанию текущий месяц"	Example(12-2023)
+// @Param			group_by		query		string			false	"Группировка итогов"								Enums(user, service, month)
+// @Param			include_deleted	query		bool			false	"Учитывать удаленные подписки"
+// @Param			currency		query		string			false	"Валюта пересчета (ISO 4217)"						Example(RUB)
 // @Success		200				{object}	userResponse	"Успешный расчет стоимости"
-// @Failure		400				{string}	string			"Невалидные параметры запроса"
-// @Failure		500				{string}	string			"Внутренняя ошибка сервера"
+// @Failure		400				{object}	response.Error	"Невалидные параметры запроса или период длиннее 120 месяцев"
+// @Failure		422				{object}	response.Error	"Нет курса для пересчета валюты подписки или сумма слишком велика"
+// @Failure		401				{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403				{object}	response.Error	"Фильтр по другому пользователю"
+// @Failure		500				{object}	response.Error	"Внутренняя ошибка сервера"
 // @Router			/subscriptions/cost [get]
 func Handler(
 	l *slog.Logger, cs costSubscription, h helper,
@@ -56,39 +91,63 @@ func Handler(
 
 	filters, err := h.GetCostParams(r)
 	if err != nil {
-		log.Error("invalid params", slog.String("err", err.Error()))
-		http.Error(w, "Invalid params", http.StatusBadRequest)
+		log.ErrorContext(r.Context(), "invalid params", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	filters.UserID, err = policy.ScopeUser(r.Context(), filters.UserID)
+	if err != nil {
+		log.ErrorContext(r.Context(), "access denied", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	total, err := cs.CostSubscription(r.Context(), filters)
+	report, err := cs.CostSubscription(r.Context(), filters)
 	if err != nil {
-		log.Error("failed to count total cost", slog.String("err", err.Error()))
-		http.Error(w, "Something wrong", http.StatusInternalServerError)
+		log.ErrorContext(r.Context(), "failed to count total cost", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	ur := userResponse{
-		ServiceName: filters.ServiceName,
-		StartDate:   filters.StartDate,
-		EndDate:     filters.EndDate,
-		TotalCost:   total,
+	var resp any = &userResponse{
+		UserID:        filters.UserID,
+		ServiceNames:  filters.ServiceNames,
+		StartDate:     report.StartDate,
+		EndDate:       report.EndDate,
+		Currency:      report.Currency,
+		TotalCost:     report.Total,
+		Subscriptions: report.Subscriptions,
+		Months:        report.Months,
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	if filters.GroupBy != "" {
+		resp = &groupResponse{
+			UserID:       filters.UserID,
+			ServiceNames: filters.ServiceNames,
+			StartDate:    report.StartDate,
+			EndDate:      report.EndDate,
+			Currency:     report.Currency,
+			GroupBy:      filters.GroupBy,
+			Groups:       report.Group(filters.GroupBy),
+		}
+	}
 
-	if err := json.NewEncoder(w).Encode(&ur); err != nil {
-		log.Error("failed to send response", slog.String("err", err.Error()))
-		http.Error(w, "Something wrong", http.StatusInternalServerError)
+	if err := response.JSON(w, http.StatusOK, resp); err != nil {
+		log.ErrorContext(r.Context(), "failed to send response", slog.String("err", err.Error()))
 
 		return
 	}
 
-	log.Info(
+	log.InfoContext(
+		r.Context(),
 		"Total cost counted successfully!",
-		slog.String("userID", filters.UserID.String()),
-		slog.String("serviceName", filters.ServiceName),
+		slog.Any("userID", filters.UserID),
+		slog.Any("serviceNames", filters.ServiceNames),
+		slog.String("groupBy", filters.GroupBy),
+		slog.String("currency", filters.Currency),
 	)
 }
@@ -3,36 +3,55 @@ package csub
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
 
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
 	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/policy"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
 // createSubscription Интерефейс с методами к базе данных,
 // который использует хендлер.
 type createSubscription interface {
-	CreateSubscription(ctx context.Context, sub *model.Subscription) error
+	CreateSubscription(ctx context.Context, sub *model.Subscription) (*model.Subscription, error)
+	FindService(ctx context.Context, name string) (*model.Service, error)
 }
 
 // checker Интерефейс с методами к Service,
 // который использует хендлер.
 type checker interface {
-	CheckBody(sub *model.Subscription) bool
-	CheckSubscription(ctx context.Context, sub *model.Subscription) (bool, error)
+	CheckBody(sub *model.Subscription) error
 }
 
 // @Summary		Создать новую подписку
-// @Description	Создает новую подписку после проверки валидности данных и отсутствия активной подписки
+// @Description	Создает новую подписку после проверки валидности данных. Период подписки [start_date, end_date)
+// @Description	не должен пересекаться с другой подпиской того же пользователя на тот же сервис.
+// @Description	Даты принимаются в формате MM-YYYY или YYYY-MM-DD. Без billing_period подписка оплачивается ежемесячно.
+// @Description	Пользователь user_id должен существовать, см. POST /users.
+// @Description	Название сервиса заменяется каноническим из каталога. Сервис без записи в каталоге создается
+// @Description	для сотрудников (admin и support), для роли user такая подписка не проходит валидацию.
+// @Description	Подписка без price получает цену и валюту сервиса по умолчанию, если они заданы.
+// @Description	Возвращает созданную подписку и ее адрес в заголовке Location.
 // @Tags			subscriptions
 // @Accept			json
-// @Produce		plain
-// @Param			input	body	model.Subscription	true	"Данные для создания подписки"
-// @Success		201		"Подписка успешно создана"
-// @Failure		400		{string}	string	"Невалидные входные данные"
-// @Failure		409		{string}	string	"Подписка уже активна"
-// @Failure		500		{string}	string	"Внутренняя ошибка сервера"
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			input	body		model.Subscription	true	"Данные для создания подписки"
+// @Success		201		{object}	model.Subscription	"Подписка успешно создана"
+// @Header			201		{string}	Location			"Адрес созданной подписки"
+// @Header			201		{string}	ETag				"Версия подписки"
+// @Failure		400		{object}	response.Error		"Невалидный JSON тела запроса"
+// @Failure		409		{object}	response.Error		"Пересечение с существующей подпиской, ее ID в details"
+// @Failure		413		{object}	response.Error		"Слишком большое тело запроса"
+// @Failure		422		{object}	response.Error		"Нарушения валидации полей в details"
+// @Failure		401		{object}	response.Error		"Запрос не аутентифицирован"
+// @Failure		403		{object}	response.Error		"Подписка для другого пользователя"
+// @Failure		500		{object}	response.Error		"Внутренняя ошибка сервера"
 // @Router			/subscriptions [post]
 func Handler(
 	l *slog.Logger, cs createSubscription,
@@ -47,44 +66,56 @@ func Handler(
 
 	sub, err := model.GetSubFromBody(r)
 	if err != nil {
-		log.Error("failed get user body", slog.String("err", err.Error()))
-		http.Error(w, "Wrong body", http.StatusBadRequest)
+		log.ErrorContext(r.Context(), "failed get user body", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	log.Debug("request body", slog.Any("body", sub))
+	log.DebugContext(r.Context(), "request body", slog.Any("body", sub))
 
-	if !c.CheckBody(sub) {
-		log.Error("bad body", slog.Any("body", sub))
-		http.Error(w, "Wrong body", http.StatusBadRequest)
+	if err := storage.NewCatalog(cs).Apply(r.Context(), sub); err != nil {
+		log.ErrorContext(r.Context(), "failed to find service", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	isActive, err := c.CheckSubscription(r.Context(), sub)
-	if err != nil {
-		log.Error("failed to check subscription", slog.String("err", err.Error()))
-		http.Error(w, "something wrong", http.StatusInternalServerError)
+	if err := c.CheckBody(sub); err != nil {
+		log.ErrorContext(r.Context(), "bad body", slog.Any("body", sub), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	if isActive {
-		log.Error("subscription already active")
-		http.Error(w, "subscription already active", http.StatusConflict)
+	if err := policy.Authorize(r.Context(), policy.ActionWrite, sub.UserID); err != nil {
+		log.ErrorContext(r.Context(), "access denied", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	err = cs.CreateSubscription(r.Context(), sub)
+	created, err := cs.CreateSubscription(r.Context(), sub)
 	if err != nil {
-		log.Error("failed to create subscription", slog.String("err", err.Error()))
-		http.Error(w, "something wrong", http.StatusInternalServerError)
+		log.ErrorContext(r.Context(), "failed to create subscription", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	log.Info("Subscription created successfully!", slog.String("userID", sub.UserID.String()))
-	w.WriteHeader(http.StatusCreated)
+	w.Header().Set("Location", fmt.Sprintf("%s/%d", r.URL.Path, created.ID))
+	w.Header().Set("ETag", model.ETag(created.Version))
+
+	if err := response.JSON(w, http.StatusCreated, created); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(
+		r.Context(),
+		"Subscription created successfully!",
+		slog.String("userID", created.UserID.String()),
+		slog.Int64("ID", created.ID),
+	)
 }
@@ -0,0 +1,89 @@
+// Пакет csvc для хендлера CreateService.
+package csvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// createService Интерефейс с методами к базе данных,
+// который использует хендлер.
+type createService interface {
+	CreateService(ctx context.Context, svc *model.Service) (*model.Service, error)
+}
+
+// checker Интерефейс с методами к Service,
+// который использует хендлер.
+type checker interface {
+	CheckService(svc *model.Service) error
+}
+
+// @Summary		Создать сервис
+// @Description	Добавляет сервис в каталог. Название и синонимы сравниваются без учета регистра и лишних
+// @Description	пробелов и не должны совпадать с названием или синонимом другого сервиса. Подписка с любым
+// @Description	из них получает каноническое название сервиса, а подписка без цены - default_price.
+// @Tags			services
+// @Accept			json
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			input	body		model.Service	true	"Данные сервиса"
+// @Success		201		{object}	model.Service	"Сервис создан"
+// @Header			201		{string}	Location		"Адрес созданного сервиса"
+// @Failure		400		{object}	response.Error	"Невалидный JSON тела запроса"
+// @Failure		409		{object}	response.Error	"Название или синоним заняты другим сервисом"
+// @Failure		413		{object}	response.Error	"Слишком большое тело запроса"
+// @Failure		422		{object}	response.Error	"Нарушения валидации полей в details"
+// @Failure		401		{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403		{object}	response.Error	"Доступно только admin и support"
+// @Failure		500		{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/services [post]
+func Handler(
+	l *slog.Logger, cs createService, c checker,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.csvc.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	svc, err := model.GetServiceFromBody(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed get user body", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := c.CheckService(svc); err != nil {
+		log.ErrorContext(r.Context(), "bad body", slog.Any("body", svc), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	created, err := cs.CreateService(r.Context(), svc)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to create service", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("%s/%d", r.URL.Path, created.ID))
+
+	if err := response.JSON(w, http.StatusCreated, created); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(r.Context(), "Service created successfully!", slog.Int64("ID", created.ID))
+}
@@ -0,0 +1,93 @@
+// Пакет cuser для хендлера CreateUser.
+package cuser
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+// createUser Интерефейс с методами к базе данных,
+// который использует хендлер.
+type createUser interface {
+	CreateUser(ctx context.Context, user *model.User) (*model.User, error)
+}
+
+// checker Интерефейс с методами к Service,
+// который использует хендлер.
+type checker interface {
+	CheckUser(user *model.User) error
+}
+
+// @Summary		Создать пользователя
+// @Description	Создает пользователя, на которого можно оформлять подписки. Без id генерируется новый UUID,
+// @Description	без timezone используется UTC, без currency - RUB. Email уникален без учета регистра.
+// @Tags			users
+// @Accept			json
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			input	body		model.User		true	"Данные пользователя"
+// @Success		201		{object}	model.User		"Пользователь создан"
+// @Header			201		{string}	Location		"Адрес созданного пользователя"
+// @Failure		400		{object}	response.Error	"Невалидный JSON тела запроса"
+// @Failure		409		{object}	response.Error	"ID или email заняты другим пользователем"
+// @Failure		413		{object}	response.Error	"Слишком большое тело запроса"
+// @Failure		422		{object}	response.Error	"Нарушения валидации полей в details"
+// @Failure		401		{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403		{object}	response.Error	"Доступно только admin и support"
+// @Failure		500		{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/users [post]
+func Handler(
+	l *slog.Logger, cu createUser, c checker,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.cuser.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	user, err := model.GetUserFromBody(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed get user body", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := c.CheckUser(user); err != nil {
+		log.ErrorContext(r.Context(), "bad body", slog.Any("body", user), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if user.ID == uuid.Nil {
+		user.ID = uuid.New()
+	}
+
+	created, err := cu.CreateUser(r.Context(), user)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to create user", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("%s/%s", r.URL.Path, created.ID))
+
+	if err := response.JSON(w, http.StatusCreated, created); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(r.Context(), "User created successfully!", slog.String("userID", created.ID.String()))
+}
@@ -0,0 +1,68 @@
+// Пакет dkey для хендлера RevokeAPIKey.
+package dkey
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/service"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// revokeAPIKey Интерефейс с методами к базе данных,
+// который использует хендлер.
+type revokeAPIKey interface {
+	RevokeAPIKey(ctx context.Context, keyID int64) error
+}
+
+// urlParser Интерефейс с методами к Service,
+// который использует хендлер.
+type urlParser interface {
+	GetKeyID(r *http.Request) (int64, error)
+}
+
+// @Summary		Отозвать API-ключ
+// @Description	Отзывает API-ключ арендатора, после этого запросы с ним не аутентифицируются.
+// @Description	Требует прав администратора.
+// @Tags			admin
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			id	path	int	true	"ID ключа"	Example(1)
+// @Success		204	"Ключ отозван"
+// @Failure		400	{object}	response.Error	"Невалидный ID ключа"
+// @Failure		401	{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403	{object}	response.Error	"Нет прав администратора"
+// @Failure		404	{object}	response.Error	"Ключ не найден или уже отозван"
+// @Failure		500	{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/admin/api-keys/{id} [delete]
+func Handler(
+	l *slog.Logger, rk revokeAPIKey, up urlParser,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.dkey.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	keyID, err := up.GetKeyID(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), service.ErrInvalidKeyID.Error(), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := rk.RevokeAPIKey(r.Context(), keyID); err != nil {
+		log.ErrorContext(r.Context(), "failed to revoke API key", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	log.InfoContext(r.Context(), "API key revoked successfully!", slog.Int64("ID", keyID))
+}
@@ -0,0 +1,67 @@
+// Пакет drate для хендлера DeleteRate.
+package drate
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// deleteRate Интерефейс с методами к базе данных,
+// который использует хендлер.
+type deleteRate interface {
+	DeleteRate(ctx context.Context, from, to string) error
+}
+
+// urlParser Интерефейс с методами к Service,
+// который использует хендлер.
+type urlParser interface {
+	GetRatePair(r *http.Request) (string, string, error)
+}
+
+// @Summary		Удалить курс валюты
+// @Description	Удаляет курс пары валют. Обратный курс, если он задан отдельно, не удаляется.
+// @Tags			rates
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			from	path	string	true	"Исходная валюта (ISO 4217)"	Example(USD)
+// @Param			to		path	string	true	"Валюта пересчета (ISO 4217)"	Example(RUB)
+// @Success		204		"Курс удален"
+// @Failure		400		{object}	response.Error	"Невалидная валюта"
+// @Failure		404		{object}	response.Error	"Курс не найден"
+// @Failure		401		{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403		{object}	response.Error	"Нет прав администратора"
+// @Failure		500		{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/rates/{from}/{to} [delete]
+func Handler(
+	l *slog.Logger, dr deleteRate, up urlParser,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.drate.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	from, to, err := up.GetRatePair(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "bad currency pair", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := dr.DeleteRate(r.Context(), from, to); err != nil {
+		log.ErrorContext(r.Context(), "failed to delete rate", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	log.InfoContext(r.Context(), "Rate deleted successfully!", slog.String("from", from), slog.String("to", to))
+}
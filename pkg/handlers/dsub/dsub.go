@@ -6,6 +6,9 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/policy"
 	"github.com/SHSanderland/EffMobTest/pkg/service"
 	"github.com/go-chi/chi/v5/middleware"
 )
@@ -13,25 +16,37 @@ import (
 // deleteSubscription Интерефейс с методами к базе данных,
 // который использует хендлер.
 type deleteSubscription interface {
-	DeleteSubscription(ctx context.Context, id int64) error
+	ReadSubscription(ctx context.Context, id int64, includeDeleted bool) (*model.Subscription, error)
+	DeleteSubscription(ctx context.Context, id, version int64, hard bool) error
 }
 
 // helper Интерефейс с методами к Service,
 // который использует хендлер.
 type helper interface {
 	GetSubID(r *http.Request) (int64, error)
-	CheckSubscriptionID(ctx context.Context, subID int64) (bool, error)
+	GetIfMatch(r *http.Request) (int64, error)
+	GetQueryFlag(r *http.Request, key string) (bool, error)
 }
 
 // @Summary		Удалить подписку
-// @Description	Удаляет подписку по указанному ID
+// @Description	Перемещает подписку с указанным ID в корзину, откуда ее можно восстановить.
+// @Description	С hard=true подписка удаляется навсегда, в том числе из корзины. С заголовком
+// @Description	If-Match подписка удаляется, только если ее версия не изменилась. Поддержка не может
+// @Description	удалять подписки, а удалить навсегда может только администратор.
 // @Tags			subscriptions
 // @Produce		plain
-// @Param			id	path	int	true	"ID удаляемой подписки"	Example(123)
-// @Success		204	"Подписка успешно удалена"
-// @Failure		400	{string}	string	"Невалидный ID подписки"
-// @Failure		404	{string}	string	"Подписка не найдена"
-// @Failure		500	{string}	string	"Внутренняя ошибка сервера"
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			id			path	int		true	"ID удаляемой подписки"					Example(123)
+// @Param			If-Match	header	string	false	"ETag подписки из предыдущего ответа"	Example("3")
+// @Param			hard		query	bool	false	"Удалить навсегда"
+// @Success		204			"Подписка успешно удалена"
+// @Failure		400			{object}	response.Error	"Невалидный ID подписки, If-Match или hard"
+// @Failure		404			{object}	response.Error	"Подписка не найдена"
+// @Failure		412			{object}	response.Error	"Версия подписки не совпадает с If-Match"
+// @Failure		401			{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403			{object}	response.Error	"Нет прав на удаление подписки"
+// @Failure		500			{object}	response.Error	"Внутренняя ошибка сервера"
 // @Router			/subscriptions/{id} [delete]
 func Handler(
 	l *slog.Logger, ds deleteSubscription, h helper,
@@ -45,40 +60,67 @@ func Handler(
 
 	intsubID, err := h.GetSubID(r)
 	if err != nil {
-		log.Error(
+		log.ErrorContext(
+			r.Context(),
 			service.ErrInvalidSubID.Error(),
 			slog.Int64("ID", intsubID),
 			slog.String("err", err.Error()),
 		)
-		http.Error(w, service.ErrInvalidSubID.Error(), http.StatusBadRequest)
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	hasID, err := h.CheckSubscriptionID(r.Context(), intsubID)
+	version, err := h.GetIfMatch(r)
 	if err != nil {
-		log.Error("failed check subscription ID", slog.String("err", err.Error()))
-		http.Error(w, "Something wrong", http.StatusInternalServerError)
+		log.ErrorContext(r.Context(), "bad If-Match", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	if !hasID {
-		log.Error("ID not exists", slog.Int64("ID", intsubID))
-		http.Error(w, "Subscription ID not ex", http.StatusNotFound)
+	hard, err := h.GetQueryFlag(r, "hard")
+	if err != nil {
+		log.ErrorContext(r.Context(), "bad hard", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	err = ds.DeleteSubscription(r.Context(), intsubID)
+	sub, err := ds.ReadSubscription(r.Context(), intsubID, true)
 	if err != nil {
-		log.Error("failed to delete subscription from DB", slog.String("err", err.Error()))
-		http.Error(w, "Something wrong", http.StatusInternalServerError)
+		log.ErrorContext(r.Context(), "failed to read subscription from DB", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	action := policy.ActionDelete
+	if hard {
+		action = policy.ActionPurge
+	}
+
+	if err := policy.Authorize(r.Context(), action, sub.UserID); err != nil {
+		log.ErrorContext(r.Context(), "access denied", slog.Int64("ID", intsubID), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	err = ds.DeleteSubscription(r.Context(), intsubID, version, hard)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to delete subscription from DB", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 
-	log.Info("Subscription delete successfully!", slog.Int64("ID", intsubID))
+	log.InfoContext(
+		r.Context(),
+		"Subscription delete successfully!",
+		slog.Int64("ID", intsubID),
+		slog.Bool("hard", hard),
+	)
 }
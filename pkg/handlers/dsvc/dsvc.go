@@ -0,0 +1,69 @@
+// Пакет dsvc для хендлера DeleteService.
+package dsvc
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/service"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// deleteService Интерефейс с методами к базе данных,
+// который использует хендлер.
+type deleteService interface {
+	DeleteService(ctx context.Context, serviceID int64) error
+}
+
+// urlParser Интерефейс с методами к Service,
+// который использует хендлер.
+type urlParser interface {
+	GetServiceID(r *http.Request) (int64, error)
+}
+
+// @Summary		Удалить сервис
+// @Description	Удаляет сервис из каталога. Сервис, на который ссылается хотя бы одна подписка,
+// @Description	в том числе удаленная, не удаляется.
+// @Tags			services
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			id	path	int	true	"ID сервиса"	Example(1)
+// @Success		204	"Сервис удален"
+// @Failure		400	{object}	response.Error	"Невалидный ID сервиса"
+// @Failure		404	{object}	response.Error	"Сервис не найден"
+// @Failure		409	{object}	response.Error	"У сервиса есть подписки"
+// @Failure		401	{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403	{object}	response.Error	"Нет прав администратора"
+// @Failure		500	{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/services/{id} [delete]
+func Handler(
+	l *slog.Logger, ds deleteService, up urlParser,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.dsvc.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	serviceID, err := up.GetServiceID(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), service.ErrInvalidServiceID.Error(), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := ds.DeleteService(r.Context(), serviceID); err != nil {
+		log.ErrorContext(r.Context(), "failed to delete service", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	log.InfoContext(r.Context(), "Service deleted successfully!", slog.Int64("ID", serviceID))
+}
@@ -0,0 +1,70 @@
+// Пакет duser для хендлера DeleteUser.
+package duser
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/service"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+// deleteUser Интерефейс с методами к базе данных,
+// который использует хендлер.
+type deleteUser interface {
+	DeleteUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// urlParser Интерефейс с методами к Service,
+// который использует хендлер.
+type urlParser interface {
+	GetUserID(r *http.Request) (uuid.UUID, error)
+}
+
+// @Summary		Удалить пользователя
+// @Description	Удаляет пользователя. Пользователь, у которого есть подписки, в том числе удаленные,
+// @Description	не удаляется: сначала подписки нужно удалить навсегда через hard=true.
+// @Tags			users
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			id	path	string	true	"UUID пользователя"	Example(550e8400-e29b-41d4-a716-446655440000)
+// @Success		204	"Пользователь удален"
+// @Failure		400	{object}	response.Error	"Невалидный UUID пользователя"
+// @Failure		404	{object}	response.Error	"Пользователь не найден"
+// @Failure		409	{object}	response.Error	"У пользователя есть подписки"
+// @Failure		401	{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403	{object}	response.Error	"Нет прав администратора"
+// @Failure		500	{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/users/{id} [delete]
+func Handler(
+	l *slog.Logger, du deleteUser, up urlParser,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.duser.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	userID, err := up.GetUserID(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), service.ErrInvalidUserID.Error(), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := du.DeleteUser(r.Context(), userID); err != nil {
+		log.ErrorContext(r.Context(), "failed to delete user", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	log.InfoContext(r.Context(), "User deleted successfully!", slog.String("userID", userID.String()))
+}
@@ -0,0 +1,116 @@
+// Пакет expsub для хендлера ExportSubscriptions.
+package expsub
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/policy"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/SHSanderland/EffMobTest/pkg/transfer"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// exportSubscriptions Интерефейс с методами к базе данных,
+// который использует хендлер.
+type exportSubscriptions interface {
+	ExportSubscriptions(ctx context.Context, filter *model.ListParams, export storage.ExportFunc) error
+}
+
+// urlParser Интерефейс с методами к Service,
+// который использует хендлер.
+type urlParser interface {
+	GetFormat(r *http.Request) (string, error)
+	GetListParams(r *http.Request) (*model.ListParams, error)
+}
+
+// @Summary		Выгрузить подписки
+// @Description	Выгружает все подписки, подходящие под фильтры списка, в CSV с заголовком
+// @Description	или в JSON Lines. Подписки отдаются по мере чтения из базы данных, limit не учитывается.
+// @Description	Выгрузку в CSV можно загрузить обратно через POST /subscriptions/import.
+// @Description	Пользователь с ролью user выгружает только свои подписки.
+// @Tags			transfer
+// @Produce		text/csv
+// @Produce		application/jsonl
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			format			query		string			false	"Формат выгрузки (по умолчанию csv)"	Enums(csv, jsonl)
+// @Param			user_id			query		string			false	"UUID пользователя"
+// @Param			service_name	query		string			false	"Префикс названия сервиса без учета регистра"
+// @Param			currency		query		string			false	"Валюта подписки (ISO 4217)"
+// @Param			min_price		query		number			false	"Минимальная цена без учета валюты"
+// @Param			max_price		query		number			false	"Максимальная цена без учета валюты"
+// @Param			active_at		query		string			false	"Подписка активна в месяце или дне (MM-YYYY или YYYY-MM-DD)"
+// @Param			start_from		query		string			false	"Дата начала не раньше (MM-YYYY или YYYY-MM-DD)"
+// @Param			start_to		query		string			false	"Дата начала не позже (MM-YYYY или YYYY-MM-DD)"
+// @Param			end_from		query		string			false	"Дата окончания не раньше (MM-YYYY или YYYY-MM-DD)"
+// @Param			end_to			query		string			false	"Дата окончания не позже (MM-YYYY или YYYY-MM-DD)"
+// @Param			sort			query		string			false	"Поле сортировки, минус в начале - по убыванию"
+// @Param			include_deleted	query		string			false	"Удаленные подписки: true - вместе с остальными, only - только корзина"	Enums(true, false, only)
+// @Success		200				{file}		file			"Файл выгрузки"
+// @Failure		400				{object}	response.Error	"Невалидные параметры запроса"
+// @Failure		401				{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403				{object}	response.Error	"Фильтр по другому пользователю"
+// @Failure		500				{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/subscriptions/export [get]
+func Handler(
+	l *slog.Logger, es exportSubscriptions, up urlParser,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.expsub.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	format, err := up.GetFormat(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "bad format", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	filter, err := up.GetListParams(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to parse url", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	filter.UserID, err = policy.ScopeUser(r.Context(), filter.UserID)
+	if err != nil {
+		log.ErrorContext(r.Context(), "access denied", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	// Выгрузка может идти дольше WriteTimeout сервера.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.WarnContext(r.Context(), "failed to reset write deadline", slog.String("err", err.Error()))
+	}
+
+	w.Header().Set("Content-Type", transfer.ContentType(format))
+	w.Header().Set("Content-Disposition", `attachment; filename="subscriptions.`+format+`"`)
+
+	count, err := transfer.Export(r.Context(), es, filter, w, format)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to export subscriptions", slog.String("err", err.Error()))
+
+		// Если выгрузка уже началась, статус отправлен и ответ
+		// остается оборванным.
+		if count == 0 {
+			response.SendError(w, r, err)
+		}
+
+		return
+	}
+
+	log.InfoContext(r.Context(), "Subscriptions exported successfully!", slog.Int("count", count))
+}
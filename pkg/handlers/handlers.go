@@ -5,12 +5,38 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/audit"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/bsub"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/ckey"
 	"github.com/SHSanderland/EffMobTest/pkg/handlers/costsub"
 	"github.com/SHSanderland/EffMobTest/pkg/handlers/csub"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/csvc"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/cuser"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/dkey"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/drate"
 	"github.com/SHSanderland/EffMobTest/pkg/handlers/dsub"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/dsvc"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/duser"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/expsub"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/hsub"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/impsub"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/lkey"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/lprice"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/lrate"
 	"github.com/SHSanderland/EffMobTest/pkg/handlers/lsub"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/lsvc"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/luser"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/lusub"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/psub"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/restoresub"
 	"github.com/SHSanderland/EffMobTest/pkg/handlers/rsub"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/rsvc"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/ruser"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/spend"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/srate"
 	"github.com/SHSanderland/EffMobTest/pkg/handlers/usub"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/usvc"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/uuser"
 	"github.com/SHSanderland/EffMobTest/pkg/service"
 	"github.com/SHSanderland/EffMobTest/pkg/storage"
 )
@@ -40,22 +66,152 @@ func (sh *SubscriptionHandlers) ReadSubscription(w http.ResponseWriter, r *http.
 	rsub.Handler(sh.log, sh.database, sh.service, w, r)
 }
 
-// UpdateSubscription Обновление подписки.
+// UpdateSubscription Полная замена подписки.
 func (sh *SubscriptionHandlers) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
 	usub.Handler(sh.log, sh.database, sh.service, w, r)
 }
 
+// PatchSubscription Частичное обновление подписки.
+func (sh *SubscriptionHandlers) PatchSubscription(w http.ResponseWriter, r *http.Request) {
+	psub.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
 // DeleteSubscription Удаление подписки.
 func (sh *SubscriptionHandlers) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
 	dsub.Handler(sh.log, sh.database, sh.service, w, r)
 }
 
+// RestoreSubscription Восстановление удаленной подписки.
+func (sh *SubscriptionHandlers) RestoreSubscription(w http.ResponseWriter, r *http.Request) {
+	restoresub.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// BatchSubscriptions Пакетное изменение подписок.
+func (sh *SubscriptionHandlers) BatchSubscriptions(w http.ResponseWriter, r *http.Request) {
+	bsub.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
 // ListSubscription Список подписок.
 func (sh *SubscriptionHandlers) ListSubscription(w http.ResponseWriter, r *http.Request) {
 	lsub.Handler(sh.log, sh.database, sh.service, w, r)
 }
 
+// ExportSubscriptions Выгрузка подписок в файл.
+func (sh *SubscriptionHandlers) ExportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	expsub.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// ImportSubscriptions Загрузка подписок из файла.
+func (sh *SubscriptionHandlers) ImportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	impsub.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
 // CostSubscription Подсчет суммы подписки за период.
 func (sh *SubscriptionHandlers) CostSubscription(w http.ResponseWriter, r *http.Request) {
 	costsub.Handler(sh.log, sh.database, sh.service, w, r)
 }
+
+// HistorySubscription История изменений подписки.
+func (sh *SubscriptionHandlers) HistorySubscription(w http.ResponseWriter, r *http.Request) {
+	hsub.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// PricesSubscription История цен подписки.
+func (sh *SubscriptionHandlers) PricesSubscription(w http.ResponseWriter, r *http.Request) {
+	lprice.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// Audit Журнал изменений всех подписок.
+func (sh *SubscriptionHandlers) Audit(w http.ResponseWriter, r *http.Request) {
+	audit.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// ListRates Список курсов валют.
+func (sh *SubscriptionHandlers) ListRates(w http.ResponseWriter, r *http.Request) {
+	lrate.Handler(sh.log, sh.database, w, r)
+}
+
+// SetRate Установка курса валюты.
+func (sh *SubscriptionHandlers) SetRate(w http.ResponseWriter, r *http.Request) {
+	srate.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// DeleteRate Удаление курса валюты.
+func (sh *SubscriptionHandlers) DeleteRate(w http.ResponseWriter, r *http.Request) {
+	drate.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// CreateService Создание сервиса в каталоге.
+func (sh *SubscriptionHandlers) CreateService(w http.ResponseWriter, r *http.Request) {
+	csvc.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// ReadService Чтение сервиса из каталога.
+func (sh *SubscriptionHandlers) ReadService(w http.ResponseWriter, r *http.Request) {
+	rsvc.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// UpdateService Замена сервиса в каталоге.
+func (sh *SubscriptionHandlers) UpdateService(w http.ResponseWriter, r *http.Request) {
+	usvc.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// DeleteService Удаление сервиса из каталога.
+func (sh *SubscriptionHandlers) DeleteService(w http.ResponseWriter, r *http.Request) {
+	dsvc.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// ListServices Каталог сервисов.
+func (sh *SubscriptionHandlers) ListServices(w http.ResponseWriter, r *http.Request) {
+	lsvc.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// CreateUser Создание пользователя.
+func (sh *SubscriptionHandlers) CreateUser(w http.ResponseWriter, r *http.Request) {
+	cuser.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// ReadUser Чтение пользователя.
+func (sh *SubscriptionHandlers) ReadUser(w http.ResponseWriter, r *http.Request) {
+	ruser.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// UpdateUser Замена пользователя.
+func (sh *SubscriptionHandlers) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	uuser.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// DeleteUser Удаление пользователя.
+func (sh *SubscriptionHandlers) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	duser.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// ListUsers Список пользователей.
+func (sh *SubscriptionHandlers) ListUsers(w http.ResponseWriter, r *http.Request) {
+	luser.Handler(sh.log, sh.database, w, r)
+}
+
+// UserSubscriptions Подписки пользователя.
+func (sh *SubscriptionHandlers) UserSubscriptions(w http.ResponseWriter, r *http.Request) {
+	lusub.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// UserSpending Расходы пользователя по месяцам.
+func (sh *SubscriptionHandlers) UserSpending(w http.ResponseWriter, r *http.Request) {
+	spend.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// CreateAPIKey Создание API-ключа.
+func (sh *SubscriptionHandlers) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	ckey.Handler(sh.log, sh.database, sh.service, w, r)
+}
+
+// ListAPIKeys Список API-ключей.
+func (sh *SubscriptionHandlers) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	lkey.Handler(sh.log, sh.database, w, r)
+}
+
+// RevokeAPIKey Отзыв API-ключа.
+func (sh *SubscriptionHandlers) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	dkey.Handler(sh.log, sh.database, sh.service, w, r)
+}
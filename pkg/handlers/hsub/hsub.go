@@ -0,0 +1,132 @@
+// Пакет hsub для хендлера HistorySubscription.
+package hsub
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/policy"
+	"github.com/SHSanderland/EffMobTest/pkg/service"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+// listEvents Интерефейс с методами к базе данных,
+// который использует хендлер.
+type listEvents interface {
+	ReadSubscription(ctx context.Context, id int64, includeDeleted bool) (*model.Subscription, error)
+	GetListEvents(ctx context.Context, filter *model.EventParams) (*model.EventPage, error)
+}
+
+// helper Интерефейс с методами к Service,
+// который использует хендлер.
+type helper interface {
+	GetSubID(r *http.Request) (int64, error)
+	GetEventParams(r *http.Request) (*model.EventParams, error)
+}
+
+// userResponse Структура для ответа пользователю.
+type userResponse struct {
+	Events     []*model.SubscriptionEvent `json:"events"`
+	Total      int                        `json:"total"`
+	NextCursor string                     `json:"next_cursor,omitempty"`
+}
+
+// @Summary		Получить историю изменений подписки
+// @Description	Возвращает страницу журнала изменений подписки по её ID в порядке изменений,
+// @Description	включая удаление навсегда. Для получения следующей страницы передайте next_cursor
+// @Description	из ответа в параметр cursor.
+// @Tags			audit
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			id		path		int				true	"ID подписки"								Example(123)
+// @Param			actor	query		string			false	"Исполнитель изменения"						Example(anonymous)
+// @Param			from	query		string			false	"Изменения не раньше (формат RFC 3339)"		Example(2025-07-01T00:00:00Z)
+// @Param			to		query		string			false	"Изменения не позже (формат RFC 3339)"		Example(2025-08-01T00:00:00Z)
+// @Param			limit	query		int				false	"Размер страницы (по умолчанию 50, максимум 500)"	Example(50)
+// @Param			cursor	query		string			false	"Курсор следующей страницы из next_cursor"
+// @Success		200		{object}	userResponse	"Успешный запрос"
+// @Failure		400		{object}	response.Error	"Невалидные параметры запроса"
+// @Failure		401		{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403		{object}	response.Error	"Подписка другого пользователя"
+// @Failure		500		{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/subscriptions/{id}/history [get]
+func Handler(
+	l *slog.Logger, le listEvents, h helper,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.hsub.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	intsubID, err := h.GetSubID(r)
+	if err != nil {
+		log.ErrorContext(
+			r.Context(),
+			service.ErrInvalidSubID.Error(),
+			slog.Int64("ID", intsubID),
+			slog.String("err", err.Error()),
+		)
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	filter, err := h.GetEventParams(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to parse url", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	// Подписки, удаленной навсегда, уже нет, и ее историю видят
+	// только сотрудники.
+	owner := uuid.Nil
+
+	sub, err := le.ReadSubscription(r.Context(), intsubID, true)
+	switch {
+	case err == nil:
+		owner = sub.UserID
+	case !errors.Is(err, storage.ErrSubNotFound):
+		log.ErrorContext(r.Context(), "failed to read subscription from DB", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := policy.Authorize(r.Context(), policy.ActionRead, owner); err != nil {
+		log.ErrorContext(r.Context(), "access denied", slog.Int64("ID", intsubID), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	filter.SubscriptionID = &intsubID
+
+	page, err := le.GetListEvents(r.Context(), filter)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to get subscription history", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	userResp := userResponse{page.Events, len(page.Events), page.NextCursor}
+
+	if err := response.JSON(w, http.StatusOK, userResp); err != nil {
+		log.ErrorContext(r.Context(), "failed to encode json", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(r.Context(), "Subscription history sended successfully!", slog.Int64("ID", intsubID))
+}
@@ -0,0 +1,140 @@
+// Пакет impsub для хендлера ImportSubscriptions.
+package impsub
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/transfer"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// importSubscriptions Интерефейс с методами к базе данных,
+// который использует хендлер.
+type importSubscriptions interface {
+	BatchSubscriptions(ctx context.Context, ops []*model.BatchOperation, atomic bool) ([]*model.BatchResult, error)
+	FindService(ctx context.Context, name string) (*model.Service, error)
+}
+
+// helper Интерефейс с методами к Service,
+// который использует хендлер.
+type helper interface {
+	CheckBody(sub *model.Subscription) error
+	GetFormat(r *http.Request) (string, error)
+}
+
+// lineResponse Результат импорта одной строки для ответа пользователю.
+type lineResponse struct {
+	Line           int             `json:"line" example:"2"`
+	SubscriptionID int64           `json:"subscription_id,omitempty" example:"123"`
+	Error          *response.Error `json:"error,omitempty"`
+}
+
+// userResponse Структура для ответа пользователю.
+type userResponse struct {
+	Accepted int             `json:"accepted"`
+	Rejected int             `json:"rejected"`
+	Lines    []*lineResponse `json:"lines"`
+}
+
+// @Summary		Загрузить подписки
+// @Description	Создает подписки из файла CSV с заголовком или JSON Lines. Каждая строка
+// @Description	проверяется так же, как тело POST /subscriptions, ошибка строки не мешает остальным.
+// @Description	В CSV обязательны колонки service_name, price, user_id и start_date, без currency
+// @Description	используется RUB, колонки, которые заполняет база данных, игнорируются. Подписка с пустой
+// @Description	price получает цену и валюту сервиса по умолчанию, как в POST /subscriptions.
+// @Description	Номер строки в отчете - номер строки файла. Файл обрабатывается пакетами по 1000 строк.
+// @Description	Если файл не удалось дочитать, возвращается ошибка, а пакеты, созданные до нее, остаются.
+// @Tags			transfer
+// @Accept			text/csv
+// @Accept			application/jsonl
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			format	query		string			false	"Формат файла (по умолчанию csv)"	Enums(csv, jsonl)
+// @Param			input	body		string			true	"Содержимое файла"
+// @Success		200		{object}	userResponse	"Файл обработан, результаты строк в lines"
+// @Failure		400		{object}	response.Error	"Невалидный формат или заголовок CSV"
+// @Failure		413		{object}	response.Error	"Файл больше 64 МБ"
+// @Failure		401		{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403		{object}	response.Error	"Доступно только admin и support"
+// @Failure		500		{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/subscriptions/import [post]
+func Handler(
+	l *slog.Logger, is importSubscriptions, h helper,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.impsub.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	format, err := h.GetFormat(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "bad format", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	// Загрузка файла может идти дольше таймаутов сервера.
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		log.WarnContext(r.Context(), "failed to reset read deadline", slog.String("err", err.Error()))
+	}
+
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.WarnContext(r.Context(), "failed to reset write deadline", slog.String("err", err.Error()))
+	}
+
+	body := http.MaxBytesReader(w, r.Body, model.MaxImportSize)
+
+	report, err := transfer.Import(r.Context(), is, h.CheckBody, body, format)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to import subscriptions", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, newResponse(r, report)); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(
+		r.Context(),
+		"Subscriptions imported successfully!",
+		slog.Int("accepted", report.Accepted),
+		slog.Int("rejected", report.Rejected),
+	)
+}
+
+// newResponse Преобразование отчета импорта в ответ пользователю.
+func newResponse(r *http.Request, report *transfer.Report) *userResponse {
+	userResp := userResponse{
+		Accepted: report.Accepted,
+		Rejected: report.Rejected,
+		Lines:    make([]*lineResponse, len(report.Lines)),
+	}
+
+	for i, res := range report.Lines {
+		line := lineResponse{Line: res.Line}
+
+		if res.Err != nil {
+			_, line.Error = response.NewError(r, res.Err)
+		} else {
+			line.SubscriptionID = res.Subscription.ID
+		}
+
+		userResp.Lines[i] = &line
+	}
+
+	return &userResp
+}
@@ -0,0 +1,57 @@
+// Пакет lkey для хендлера ListAPIKeys.
+package lkey
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// listAPIKeys Интерефейс с методами к базе данных,
+// который использует хендлер.
+type listAPIKeys interface {
+	ListAPIKeys(ctx context.Context) ([]*model.APIKey, error)
+}
+
+// @Summary		Получить список API-ключей
+// @Description	Возвращает все API-ключи арендатора, в том числе отозванные, без самих ключей.
+// @Description	Требует прав администратора.
+// @Tags			admin
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Success		200	{array}		model.APIKey	"Успешный запрос"
+// @Failure		401	{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403	{object}	response.Error	"Нет прав администратора"
+// @Failure		500	{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/admin/api-keys [get]
+func Handler(
+	l *slog.Logger, lk listAPIKeys,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.lkey.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	keys, err := lk.ListAPIKeys(r.Context())
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to list API keys", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, keys); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(r.Context(), "API keys sended successfully!", slog.Int("count", len(keys)))
+}
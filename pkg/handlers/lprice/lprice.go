@@ -0,0 +1,123 @@
+// Пакет lprice для хендлера PricesSubscription.
+package lprice
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/policy"
+	"github.com/SHSanderland/EffMobTest/pkg/service"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// listPrices Интерефейс с методами к базе данных,
+// который использует хендлер.
+type listPrices interface {
+	ReadSubscription(ctx context.Context, id int64, includeDeleted bool) (*model.Subscription, error)
+	ListPrices(ctx context.Context, subID int64) (model.PriceHistory, error)
+}
+
+// helper Интерефейс с методами к Service,
+// который использует хендлер.
+type helper interface {
+	GetSubID(r *http.Request) (int64, error)
+}
+
+// priceResponse Цена подписки для ответа пользователю.
+type priceResponse struct {
+	EffectiveFrom string      `json:"effective_from" example:"03-2025"`
+	Price         json.Number `json:"price" swaggertype:"number" example:"9.99"`
+}
+
+// userResponse Структура для ответа пользователю.
+type userResponse struct {
+	SubscriptionID int64            `json:"subscription_id" example:"123"`
+	Prices         []*priceResponse `json:"prices"`
+}
+
+// @Summary		Получить историю цен подписки
+// @Description	Возвращает цены подписки в валюте подписки по дате, с которой каждая цена действует.
+// @Description	Первая цена действует с начала подписки, каждая следующая - до следующего изменения.
+// @Description	По этим ценам GET /subscriptions/cost оплачивает списания.
+// @Tags			subscriptions
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			id	path		int				true	"ID подписки"	Example(123)
+// @Success		200	{object}	userResponse	"Успешный запрос"
+// @Failure		400	{object}	response.Error	"Невалидный ID"
+// @Failure		404	{object}	response.Error	"Подписка с указанным ID не найдена"
+// @Failure		401	{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403	{object}	response.Error	"Подписка другого пользователя"
+// @Failure		500	{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/subscriptions/{id}/prices [get]
+func Handler(
+	l *slog.Logger, lp listPrices, h helper,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.lprice.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	intsubID, err := h.GetSubID(r)
+	if err != nil {
+		log.ErrorContext(
+			r.Context(),
+			service.ErrInvalidSubID.Error(),
+			slog.Int64("ID", intsubID),
+			slog.String("err", err.Error()),
+		)
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	sub, err := lp.ReadSubscription(r.Context(), intsubID, true)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to read subscription from DB", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := policy.Authorize(r.Context(), policy.ActionRead, sub.UserID); err != nil {
+		log.ErrorContext(r.Context(), "access denied", slog.Int64("ID", intsubID), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	history, err := lp.ListPrices(r.Context(), intsubID)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to get subscription prices", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	userResp := userResponse{
+		SubscriptionID: intsubID,
+		Prices:         make([]*priceResponse, len(history)),
+	}
+
+	for i, change := range history {
+		userResp.Prices[i] = &priceResponse{
+			EffectiveFrom: model.FormatDate(change.EffectiveFrom),
+			Price:         json.Number(change.Price.Format(sub.Currency)),
+		}
+	}
+
+	if err := response.JSON(w, http.StatusOK, userResp); err != nil {
+		log.ErrorContext(r.Context(), "failed to encode json", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(r.Context(), "Subscription prices sended successfully!", slog.Int64("ID", intsubID))
+}
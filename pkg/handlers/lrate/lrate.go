@@ -0,0 +1,61 @@
+// Пакет lrate для хендлера ListRates.
+package lrate
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// listRates Интерефейс с методами к базе данных,
+// который использует хендлер.
+type listRates interface {
+	ListRates(ctx context.Context) ([]*model.Rate, error)
+}
+
+// userResponse Структура для ответа пользователю.
+type userResponse struct {
+	Rates []*model.Rate `json:"rates"`
+}
+
+// @Summary		Получить курсы валют
+// @Description	Возвращает все курсы валют, по которым пересчитывается стоимость подписок.
+// @Description	Курс from/to означает, что 1 from = rate to, обратный курс считается автоматически.
+// @Tags			rates
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Success		200	{object}	userResponse	"Успешный запрос"
+// @Failure		401	{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		500	{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/rates [get]
+func Handler(
+	l *slog.Logger, lr listRates,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.lrate.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	rates, err := lr.ListRates(r.Context())
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to get rates", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, &userResponse{Rates: rates}); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(r.Context(), "Rates sent successfully!", slog.Int("count", len(rates)))
+}
@@ -3,42 +3,62 @@ package lsub
 
 import (
 	"context"
-	"encoding/json"
 	"log/slog"
 	"net/http"
 
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
 	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/policy"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/google/uuid"
 )
 
 // listSubscription Интерефейс с методами к базе данных,
 // который использует хендлер.
 type listSubscription interface {
-	GetListSubscription(ctx context.Context, userID uuid.UUID, serviceName string) ([]*model.Subscription, error)
+	GetListSubscription(ctx context.Context, filter *model.ListParams) (*model.SubscriptionPage, error)
 }
 
 // urlParser Интерефейс с методами к Service,
 // который использует хендлер.
 type urlParser interface {
-	GetUserIDAndServiceName(r *http.Request) (uuid.UUID, string, error)
+	GetListParams(r *http.Request) (*model.ListParams, error)
 }
 
 // userResponse Структура для ответа пользователю.
 type userResponse struct {
 	Subscriptions []*model.Subscription `json:"subscriptions"`
 	Total         int                   `json:"total"`
+	NextCursor    string                `json:"next_cursor,omitempty"`
 }
 
 // @Summary		Получить список подписок
-// @Description	Возвращает список подписок с возможностью фильтрации по user_id и service_name
+// @Description	Возвращает страницу списка подписок с необязательными фильтрами и сортировкой.
+// @Description	Для получения следующей страницы передайте next_cursor из ответа в параметр cursor
+// @Description	с той же сортировкой и фильтрами. total - количество подписок на странице.
+// @Description	Пользователь с ролью user видит только свои подписки.
 // @Tags			subscriptions
 // @Produce		json
-// @Param			user_id			query		string			true	"UUID пользователя для фильтрации"	Example(550e8400-e29b-41d4-a716-446655440000)
-// @Param			service_name	query		string			true	"Название сервиса для фильтрации"	Example(netflix)
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			user_id			query		string			false	"UUID пользователя"								Example(550e8400-e29b-41d4-a716-446655440000)
+// @Param			service_name	query		string			false	"Префикс названия сервиса без учета регистра"						Example(net)
+// @Param			currency		query		string			false	"Валюта подписки (ISO 4217)"					Example(RUB)
+// @Param			min_price		query		number			false	"Минимальная цена, знаки после точки - как у currency"	Example(99.9)
+// @Param			max_price		query		number			false	"Максимальная цена, знаки после точки - как у currency"	Example(1000)
+// @Param			active_at		query		string			false	"Подписка активна в месяце или дне (MM-YYYY или YYYY-MM-DD)"	Example(07-2025)
+// @Param			start_from		query		string			false	"Дата начала не раньше (MM-YYYY или YYYY-MM-DD)"		Example(01-2025)
+// @Param			start_to		query		string			false	"Дата начала не позже (MM-YYYY или YYYY-MM-DD)"			Example(12-2025)
+// @Param			end_from		query		string			false	"Дата окончания не раньше (MM-YYYY или YYYY-MM-DD)"		Example(01-2025)
+// @Param			end_to			query		string			false	"Дата окончания не позже (MM-YYYY или YYYY-MM-DD)"		Example(12-2025)
+// @Param			sort			query		string			false	"Поле сортировки, минус в начале - по убыванию"	Enums(id, -id, service_name, -service_name, price, -price, user_id, -user_id, start_date, -start_date, end_date, -end_date)
+// @Param			limit			query		int				false	"Размер страницы (по умолчанию 50, максимум 500)"	Example(50)
+// @Param			cursor			query		string			false	"Курсор следующей страницы из next_cursor"
+// @Param			include_deleted	query		string			false	"Удаленные подписки: true - вместе с остальными, only - только корзина"	Enums(true, false, only)
 // @Success		200				{object}	userResponse	"Успешный запрос"
-// @Failure		400				{string}	string			"Невалидные параметры запроса"
-// @Failure		500				{string}	string			"Внутренняя ошибка сервера"
+// @Failure		400				{object}	response.Error	"Невалидные параметры запроса"
+// @Failure		401				{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403				{object}	response.Error	"Фильтр по другому пользователю"
+// @Failure		500				{object}	response.Error	"Внутренняя ошибка сервера"
 // @Router			/subscriptions [get]
 func Handler(
 	l *slog.Logger, ls listSubscription, up urlParser,
@@ -50,36 +70,43 @@ func Handler(
 		slog.String("requestID", middleware.GetReqID(r.Context())),
 	)
 
-	userID, serviceName, err := up.GetUserIDAndServiceName(r)
+	filter, err := up.GetListParams(r)
 	if err != nil {
-		log.Error("failed to parse url", slog.String("err", err.Error()))
-		http.Error(w, "Invalid URL params", http.StatusBadRequest)
+		log.ErrorContext(r.Context(), "failed to parse url", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	subs, err := ls.GetListSubscription(r.Context(), userID, serviceName)
+	filter.UserID, err = policy.ScopeUser(r.Context(), filter.UserID)
 	if err != nil {
-		log.Error("failed to get list subscriptions", slog.String("err", err.Error()))
-		http.Error(w, "Something wrong", http.StatusInternalServerError)
+		log.ErrorContext(r.Context(), "access denied", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	userResp := userResponse{subs, len(subs)}
+	page, err := ls.GetListSubscription(r.Context(), filter)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to get list subscriptions", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
 
-	w.Header().Set("Content-Type", "application/json")
+	userResp := userResponse{page.Subscriptions, len(page.Subscriptions), page.NextCursor}
 
-	if err := json.NewEncoder(w).Encode(userResp); err != nil {
-		log.Error("failed to encode json", slog.String("err", err.Error()))
-		http.Error(w, "Something wrong", http.StatusInternalServerError)
+	if err := response.JSON(w, http.StatusOK, userResp); err != nil {
+		log.ErrorContext(r.Context(), "failed to encode json", slog.String("err", err.Error()))
 
 		return
 	}
 
-	log.Info(
+	log.InfoContext(
+		r.Context(),
 		"List of subscriptions sended successfully!",
-		slog.String("userID", userID.String()),
-		slog.String("serviceName", serviceName),
+		slog.Any("userID", filter.UserID),
+		slog.String("serviceName", filter.ServiceName),
+		slog.Int("count", len(page.Subscriptions)),
 	)
 }
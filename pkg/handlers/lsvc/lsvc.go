@@ -0,0 +1,64 @@
+// Пакет lsvc для хендлера ListServices.
+package lsvc
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// listServices Интерефейс с методами к базе данных,
+// который использует хендлер.
+type listServices interface {
+	ListServices(ctx context.Context, category string) ([]*model.Service, error)
+}
+
+// urlParser Интерефейс с методами к Service,
+// который использует хендлер.
+type urlParser interface {
+	GetServiceCategory(r *http.Request) string
+}
+
+// @Summary		Получить каталог сервисов
+// @Description	Возвращает сервисы каталога, отсортированные по названию.
+// @Tags			services
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			category	query		string			false	"Категория сервисов"	Example(video)
+// @Success		200			{array}		model.Service	"Успешный запрос"
+// @Failure		401			{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		500			{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/services [get]
+func Handler(
+	l *slog.Logger, ls listServices, up urlParser,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.lsvc.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	category := up.GetServiceCategory(r)
+
+	services, err := ls.ListServices(r.Context(), category)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to list services", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, services); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(r.Context(), "Services sended successfully!", slog.Int("count", len(services)))
+}
@@ -0,0 +1,56 @@
+// Пакет luser для хендлера ListUsers.
+package luser
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// listUsers Интерефейс с методами к базе данных,
+// который использует хендлер.
+type listUsers interface {
+	ListUsers(ctx context.Context) ([]*model.User, error)
+}
+
+// @Summary		Получить список пользователей
+// @Description	Возвращает всех пользователей в порядке создания.
+// @Tags			users
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Success		200	{array}		model.User		"Успешный запрос"
+// @Failure		401	{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403	{object}	response.Error	"Доступно только admin и support"
+// @Failure		500	{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/users [get]
+func Handler(
+	l *slog.Logger, lu listUsers,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.luser.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	users, err := lu.ListUsers(r.Context())
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to list users", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, users); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(r.Context(), "Users sended successfully!", slog.Int("count", len(users)))
+}
@@ -0,0 +1,128 @@
+// Пакет lusub для хендлера UserSubscriptions.
+package lusub
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/policy"
+	"github.com/SHSanderland/EffMobTest/pkg/service"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+// userSubscriptions Интерефейс с методами к базе данных,
+// который использует хендлер.
+type userSubscriptions interface {
+	ReadUser(ctx context.Context, userID uuid.UUID) (*model.User, error)
+	GetListSubscription(ctx context.Context, filter *model.ListParams) (*model.SubscriptionPage, error)
+}
+
+// helper Интерефейс с методами к Service,
+// который использует хендлер.
+type helper interface {
+	GetUserID(r *http.Request) (uuid.UUID, error)
+	GetUserListParams(r *http.Request, user *model.User) (*model.ListParams, error)
+}
+
+// userResponse Структура для ответа пользователю.
+type userResponse struct {
+	UserID        uuid.UUID             `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Subscriptions []*model.Subscription `json:"subscriptions"`
+	Total         int                   `json:"total"`
+	NextCursor    string                `json:"next_cursor,omitempty"`
+}
+
+// @Summary		Получить подписки пользователя
+// @Description	Возвращает страницу подписок пользователя. Без active_at и фильтров по датам возвращаются
+// @Description	подписки, активные сегодня в часовом поясе пользователя. Остальные параметры такие же,
+// @Description	как у GET /subscriptions.
+// @Tags			users
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			id				path		string			true	"UUID пользователя"	Example(550e8400-e29b-41d4-a716-446655440000)
+// @Param			active_at		query		string			false	"Подписка активна в месяце или дне (MM-YYYY или YYYY-MM-DD)"	Example(07-2025)
+// @Param			service_name	query		string			false	"Префикс названия сервиса без учета регистра"	Example(net)
+// @Param			sort			query		string			false	"Поле сортировки, минус в начале - по убыванию"	Example(-price)
+// @Param			limit			query		int				false	"Размер страницы (по умолчанию 50, максимум 500)"	Example(50)
+// @Param			cursor			query		string			false	"Курсор следующей страницы из next_cursor"
+// @Success		200				{object}	userResponse	"Успешный запрос"
+// @Failure		400				{object}	response.Error	"Невалидный UUID пользователя или параметры запроса"
+// @Failure		404				{object}	response.Error	"Пользователь не найден"
+// @Failure		401				{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403				{object}	response.Error	"Данные другого пользователя"
+// @Failure		500				{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/users/{id}/subscriptions [get]
+func Handler(
+	l *slog.Logger, us userSubscriptions, h helper,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.lusub.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	userID, err := h.GetUserID(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), service.ErrInvalidUserID.Error(), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := policy.Authorize(r.Context(), policy.ActionRead, userID); err != nil {
+		log.ErrorContext(
+			r.Context(),
+			"access denied",
+			slog.String("userID", userID.String()),
+			slog.String("err", err.Error()),
+		)
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	user, err := us.ReadUser(r.Context(), userID)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to read user", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	filter, err := h.GetUserListParams(r, user)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to parse url", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	page, err := us.GetListSubscription(r.Context(), filter)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to get list subscriptions", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	userResp := userResponse{userID, page.Subscriptions, len(page.Subscriptions), page.NextCursor}
+
+	if err := response.JSON(w, http.StatusOK, userResp); err != nil {
+		log.ErrorContext(r.Context(), "failed to encode json", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(
+		r.Context(),
+		"User subscriptions sended successfully!",
+		slog.String("userID", userID.String()),
+		slog.Int("count", len(page.Subscriptions)),
+	)
+}
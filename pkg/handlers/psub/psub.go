@@ -0,0 +1,152 @@
+// Пакет psub для хендлера PatchSubscription.
+package psub
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/policy"
+	"github.com/SHSanderland/EffMobTest/pkg/service"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// patchSubscription Интерефейс с методами к базе данных,
+// который использует хендлер.
+type patchSubscription interface {
+	UpdateSubscription(
+		ctx context.Context, id, version int64, priceFrom *time.Time, update storage.UpdateFunc,
+	) (*model.Subscription, error)
+}
+
+// helper Интерефейс с методами к Service,
+// который использует хендлер.
+type helper interface {
+	CheckBody(sub *model.Subscription) error
+	GetSubID(r *http.Request) (int64, error)
+	GetIfMatch(r *http.Request) (int64, error)
+	GetEffectiveFrom(r *http.Request) (*time.Time, error)
+}
+
+// @Summary		Частично обновить подписку
+// @Description	Обновляет подписку по её ID в формате JSON Merge Patch (RFC 7396): отсутствующие
+// @Description	поля не меняются, null сбрасывает поле (например, "end_date": null делает подписку
+// @Description	бессрочной). Результат слияния проверяется так же, как при создании.
+// @Description	Чтение, слияние и запись выполняются в одной транзакции. С заголовком If-Match
+// @Description	подписка обновляется, только если ее версия не изменилась. Параметр effective_from
+// @Description	работает так же, как в PUT /subscriptions/{id}.
+// @Tags			subscriptions
+// @Accept			json
+// @Accept			application/merge-patch+json
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			id			path		int					true	"ID обновляемой подписки"	Example(123)
+// @Param			If-Match	header		string				false	"ETag подписки из предыдущего ответа"	Example("3")
+// @Param			effective_from	query		string				false	"Дата, с которой действует новая цена (MM-YYYY или YYYY-MM-DD)"	Example(03-2025)
+// @Param			input		body		model.Subscription	true	"Изменяемые поля подписки"
+// @Success		200			{object}	model.Subscription	"Подписка успешно обновлена, новая версия в заголовке ETag"
+// @Header			200			{string}	ETag				"Версия подписки"
+// @Failure		400			{object}	response.Error		"Невалидный ID, If-Match, effective_from, JSON тела запроса или пустой патч"
+// @Failure		404			{object}	response.Error		"Подписка с указанным ID не найдена"
+// @Failure		409			{object}	response.Error		"Пересечение с существующей подпиской, ее ID в details"
+// @Failure		412			{object}	response.Error		"Версия подписки не совпадает с If-Match"
+// @Failure		413			{object}	response.Error		"Слишком большое тело запроса"
+// @Failure		422			{object}	response.Error		"Нарушения валидации полей в details"
+// @Failure		401			{object}	response.Error		"Запрос не аутентифицирован"
+// @Failure		403			{object}	response.Error		"Подписка другого пользователя"
+// @Failure		500			{object}	response.Error		"Внутренняя ошибка сервера"
+// @Router			/subscriptions/{id} [patch]
+func Handler(
+	l *slog.Logger, ps patchSubscription, h helper,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.psub.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	intsubID, err := h.GetSubID(r)
+	if err != nil {
+		log.ErrorContext(
+			r.Context(),
+			service.ErrInvalidSubID.Error(),
+			slog.Int64("ID", intsubID),
+			slog.String("err", err.Error()),
+		)
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	version, err := h.GetIfMatch(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "bad If-Match", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	priceFrom, err := h.GetEffectiveFrom(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "bad effective_from", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	patch, err := model.GetPatchFromBody(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to get body", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if len(patch) == 0 {
+		log.ErrorContext(r.Context(), "empty patch", slog.Int64("ID", intsubID))
+		response.SendError(w, r, storage.ErrEmptySub)
+
+		return
+	}
+
+	updated, err := ps.UpdateSubscription(
+		r.Context(), intsubID, version, priceFrom,
+		func(cur *model.Subscription) error {
+			if err := policy.Authorize(r.Context(), policy.ActionWrite, cur.UserID); err != nil {
+				return err
+			}
+
+			if err := patch.Apply(cur); err != nil {
+				return err
+			}
+
+			if err := h.CheckBody(cur); err != nil {
+				return err
+			}
+
+			return policy.Authorize(r.Context(), policy.ActionWrite, cur.UserID)
+		},
+	)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed update subscription", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	w.Header().Set("ETag", model.ETag(updated.Version))
+
+	if err := response.JSON(w, http.StatusOK, updated); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(r.Context(), "Subscription patch successfully!", slog.Int64("ID", intsubID))
+}
@@ -0,0 +1,179 @@
+// Пакет response нужен для единого формата ответов хендлеров.
+// Все ошибки отдаются пользователю в формате Error с машиночитаемым
+// кодом, который определяется по sentinel-ошибкам пакетов service,
+// storage и model.
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/auth"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/service"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/SHSanderland/EffMobTest/pkg/tenant"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Коды ошибок, которые получает пользователь.
+const (
+	CodeInvalidSubID       = "invalid_subscription_id"
+	CodeInvalidServiceID   = "invalid_service_id"
+	CodeInvalidUserID      = "invalid_user_id"
+	CodeInvalidKeyID       = "invalid_api_key_id"
+	CodeInvalidServiceName = "invalid_service_name"
+	CodeInvalidDate        = "invalid_date"
+	CodeInvalidGroupBy     = "invalid_group_by"
+	CodeInvalidPrice       = "invalid_price"
+	CodeInvalidSort        = "invalid_sort"
+	CodeInvalidLimit       = "invalid_limit"
+	CodeInvalidCursor      = "invalid_cursor"
+	CodeInvalidIfMatch     = "invalid_if_match"
+	CodeInvalidFlag        = "invalid_flag"
+	CodeInvalidFormat      = "invalid_format"
+	CodeInvalidCurrency    = "invalid_currency"
+	CodeInvalidTenant      = "invalid_tenant"
+	CodeInvalidBody        = "invalid_body"
+	CodeBodyTooLarge       = "body_too_large"
+	CodeValidationFailed   = "validation_failed"
+	CodeSubNotFound        = "subscription_not_found"
+	CodeSubOverlap         = "subscription_overlap"
+	CodeSubNotDeleted      = "subscription_not_deleted"
+	CodeNothingToUpdate    = "nothing_to_update"
+	CodeRateNotFound       = "rate_not_found"
+	CodeServiceNotFound    = "service_not_found"
+	CodeServiceExists      = "service_exists"
+	CodeServiceInUse       = "service_in_use"
+	CodeUserNotFound       = "user_not_found"
+	CodeUserExists         = "user_exists"
+	CodeUserInUse          = "user_in_use"
+	CodeAPIKeyNotFound     = "api_key_not_found"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeNoRate             = "no_exchange_rate"
+	CodeAmountOverflow     = "amount_overflow"
+	CodeCostRange          = "cost_period_too_long"
+	CodeBatchAborted       = "batch_aborted"
+	CodeVersionMismatch    = "version_mismatch"
+	CodeUnavailable        = "storage_unavailable"
+	CodeInternal           = "internal_error"
+)
+
+// Error Структура ошибки для ответа пользователю.
+type Error struct {
+	Code      string `json:"code" example:"subscription_not_found"`
+	Message   string `json:"message" example:"subscription not found"`
+	RequestID string `json:"request_id,omitempty" example:"host/abcdef-000001"`
+	Details   any    `json:"details,omitempty"`
+}
+
+// conflictDetails Подробности ошибки пересечения подписок.
+type conflictDetails struct {
+	ConflictingID int64 `json:"conflicting_subscription_id"`
+}
+
+// apiError Соответствие sentinel-ошибки коду и HTTP-статусу.
+type apiError struct {
+	err    error
+	code   string
+	status int
+}
+
+// apiErrors Таблица соответствия ошибок. Проверяется по порядку
+// через errors.Is, поэтому более конкретные ошибки идут раньше.
+var apiErrors = []apiError{
+	{auth.ErrUnauthorized, CodeUnauthorized, http.StatusUnauthorized},
+	{auth.ErrForbidden, CodeForbidden, http.StatusForbidden},
+	{tenant.ErrInvalidTenant, CodeInvalidTenant, http.StatusBadRequest},
+	{service.ErrInvalidSubID, CodeInvalidSubID, http.StatusBadRequest},
+	{service.ErrInvalidServiceID, CodeInvalidServiceID, http.StatusBadRequest},
+	{service.ErrInvalidKeyID, CodeInvalidKeyID, http.StatusBadRequest},
+	{service.ErrInvalidUserID, CodeInvalidUserID, http.StatusBadRequest},
+	{service.ErrInvalidServiceName, CodeInvalidServiceName, http.StatusBadRequest},
+	{service.ErrInvalidDate, CodeInvalidDate, http.StatusBadRequest},
+	{service.ErrInvalidGroupBy, CodeInvalidGroupBy, http.StatusBadRequest},
+	{service.ErrInvalidPrice, CodeInvalidPrice, http.StatusBadRequest},
+	{service.ErrInvalidSort, CodeInvalidSort, http.StatusBadRequest},
+	{service.ErrInvalidLimit, CodeInvalidLimit, http.StatusBadRequest},
+	{model.ErrInvalidCursor, CodeInvalidCursor, http.StatusBadRequest},
+	{service.ErrInvalidIfMatch, CodeInvalidIfMatch, http.StatusBadRequest},
+	{service.ErrInvalidFlag, CodeInvalidFlag, http.StatusBadRequest},
+	{service.ErrInvalidFormat, CodeInvalidFormat, http.StatusBadRequest},
+	{service.ErrInvalidCurrency, CodeInvalidCurrency, http.StatusBadRequest},
+	{model.ErrCostRange, CodeCostRange, http.StatusBadRequest},
+	{model.ErrBadBody, CodeInvalidBody, http.StatusBadRequest},
+	{model.ErrBodyTooLarge, CodeBodyTooLarge, http.StatusRequestEntityTooLarge},
+	{model.ErrValidation, CodeValidationFailed, http.StatusUnprocessableEntity},
+	{storage.ErrSubNotFound, CodeSubNotFound, http.StatusNotFound},
+	{storage.ErrSubOverlap, CodeSubOverlap, http.StatusConflict},
+	{storage.ErrNotDeleted, CodeSubNotDeleted, http.StatusConflict},
+	{storage.ErrEmptySub, CodeNothingToUpdate, http.StatusBadRequest},
+	{storage.ErrRateNotFound, CodeRateNotFound, http.StatusNotFound},
+	{storage.ErrServiceNotFound, CodeServiceNotFound, http.StatusNotFound},
+	{storage.ErrServiceExists, CodeServiceExists, http.StatusConflict},
+	{storage.ErrServiceInUse, CodeServiceInUse, http.StatusConflict},
+	{storage.ErrUserNotFound, CodeUserNotFound, http.StatusNotFound},
+	{storage.ErrUserExists, CodeUserExists, http.StatusConflict},
+	{storage.ErrUserInUse, CodeUserInUse, http.StatusConflict},
+	{storage.ErrAPIKeyNotFound, CodeAPIKeyNotFound, http.StatusNotFound},
+	{model.ErrNoRate, CodeNoRate, http.StatusUnprocessableEntity},
+	{model.ErrAmountOverflow, CodeAmountOverflow, http.StatusUnprocessableEntity},
+	{storage.ErrVersion, CodeVersionMismatch, http.StatusPreconditionFailed},
+	{storage.ErrBatchAbort, CodeBatchAborted, http.StatusFailedDependency},
+	{storage.ErrUnavailable, CodeUnavailable, http.StatusServiceUnavailable},
+}
+
+// SendError Отправка ошибки пользователю. Код и статус ответа
+// определяются по err через NewError.
+func SendError(w http.ResponseWriter, r *http.Request, err error) {
+	status, resp := NewError(r, err)
+
+	JSON(w, status, resp)
+}
+
+// NewError Создание ошибки для ответа пользователю и ее HTTP-статуса.
+// Неизвестные ошибки отдаются как internal_error без подробностей.
+// Ошибки валидации дополняются нарушениями по полям, ошибки
+// пересечения - ID конфликтующей подписки.
+func NewError(r *http.Request, err error) (int, *Error) {
+	resp := Error{
+		Code:      CodeInternal,
+		Message:   "internal server error",
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+	status := http.StatusInternalServerError
+
+	for _, ae := range apiErrors {
+		if errors.Is(err, ae.err) {
+			resp.Code = ae.code
+			resp.Message = ae.err.Error()
+			status = ae.status
+
+			break
+		}
+	}
+
+	var (
+		ve *model.ValidationError
+		oe *storage.OverlapError
+	)
+
+	switch {
+	case errors.As(err, &ve):
+		resp.Details = ve.Violations
+	case errors.As(err, &oe) && oe.ConflictID != 0:
+		resp.Details = []conflictDetails{{ConflictingID: oe.ConflictID}}
+	}
+
+	return status, &resp
+}
+
+// JSON Отправка ответа пользователю в формате JSON.
+func JSON(w http.ResponseWriter, status int, v any) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	return json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,112 @@
+// Пакет restoresub для хендлера RestoreSubscription.
+package restoresub
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/policy"
+	"github.com/SHSanderland/EffMobTest/pkg/service"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// restoreSubscription Интерефейс с методами к базе данных,
+// который использует хендлер.
+type restoreSubscription interface {
+	ReadSubscription(ctx context.Context, id int64, includeDeleted bool) (*model.Subscription, error)
+	RestoreSubscription(ctx context.Context, id, version int64) (*model.Subscription, error)
+}
+
+// helper Интерефейс с методами к Service,
+// который использует хендлер.
+type helper interface {
+	GetSubID(r *http.Request) (int64, error)
+	GetIfMatch(r *http.Request) (int64, error)
+}
+
+// @Summary		Восстановить подписку
+// @Description	Восстанавливает мягко удаленную подписку из корзины по её ID. Период подписки
+// @Description	не должен пересекаться с подписками, созданными после удаления.
+// @Tags			subscriptions
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			id			path		int					true	"ID восстанавливаемой подписки"			Example(123)
+// @Param			If-Match	header		string				false	"ETag подписки из предыдущего ответа"	Example("3")
+// @Success		200			{object}	model.Subscription	"Подписка успешно восстановлена"
+// @Header			200			{string}	ETag				"Версия подписки"
+// @Failure		400			{object}	response.Error		"Невалидный ID подписки или If-Match"
+// @Failure		404			{object}	response.Error		"Подписка не найдена"
+// @Failure		409			{object}	response.Error		"Подписка не удалена или пересекается с существующей"
+// @Failure		412			{object}	response.Error		"Версия подписки не совпадает с If-Match"
+// @Failure		401			{object}	response.Error		"Запрос не аутентифицирован"
+// @Failure		403			{object}	response.Error		"Подписка другого пользователя"
+// @Failure		500			{object}	response.Error		"Внутренняя ошибка сервера"
+// @Router			/subscriptions/{id}/restore [post]
+func Handler(
+	l *slog.Logger, rs restoreSubscription, h helper,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.restoresub.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	intsubID, err := h.GetSubID(r)
+	if err != nil {
+		log.ErrorContext(
+			r.Context(),
+			service.ErrInvalidSubID.Error(),
+			slog.Int64("ID", intsubID),
+			slog.String("err", err.Error()),
+		)
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	version, err := h.GetIfMatch(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "bad If-Match", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	sub, err := rs.ReadSubscription(r.Context(), intsubID, true)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to read subscription from DB", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := policy.Authorize(r.Context(), policy.ActionWrite, sub.UserID); err != nil {
+		log.ErrorContext(r.Context(), "access denied", slog.Int64("ID", intsubID), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	restored, err := rs.RestoreSubscription(r.Context(), intsubID, version)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to restore subscription", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	w.Header().Set("ETag", model.ETag(restored.Version))
+
+	if err := response.JSON(w, http.StatusOK, restored); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(r.Context(), "Subscription restore successfully!", slog.Int64("ID", intsubID))
+}
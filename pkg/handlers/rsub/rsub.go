@@ -3,37 +3,48 @@ package rsub
 
 import (
 	"context"
-	"encoding/json"
 	"log/slog"
 	"net/http"
 
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
 	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/policy"
 	"github.com/SHSanderland/EffMobTest/pkg/service"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
 // readSubscription Интерефейс с методами к базе данных,
 // который использует хендлер.
 type readSubscription interface {
-	ReadSubscription(ctx context.Context, id int64) (*model.Subscription, error)
+	ReadSubscription(ctx context.Context, id int64, includeDeleted bool) (*model.Subscription, error)
 }
 
 // helper Интерефейс с методами к Service,
 // который использует хендлер.
 type helper interface {
 	GetSubID(r *http.Request) (int64, error)
-	CheckSubscriptionID(ctx context.Context, subID int64) (bool, error)
+	GetQueryFlag(r *http.Request, key string) (bool, error)
+	CheckSubscriptionID(ctx context.Context, subID int64, includeDeleted bool) (bool, error)
 }
 
 // @Summary		Получить подписку по ID
-// @Description	Возвращает информацию о подписке по её идентификатору
+// @Description	Возвращает информацию о подписке по её идентификатору. Версия подписки
+// @Description	отдается в заголовке ETag для последующих запросов с If-Match. Удаленная
+// @Description	подписка возвращается только с include_deleted=true.
 // @Tags			subscriptions
 // @Produce		json
-// @Param			id	path		int					true	"ID подписки"	Example(123)
-// @Success		200	{object}	model.Subscription	"Успешный запрос"
-// @Failure		400	{string}	string				"Невалидный ID подписки"
-// @Failure		404	{string}	string				"Подписка не найдена"
-// @Failure		500	{string}	string				"Внутренняя ошибка сервера"
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			id				path		int					true	"ID подписки"	Example(123)
+// @Param			include_deleted	query		bool				false	"Искать также среди удаленных подписок"
+// @Success		200				{object}	model.Subscription	"Успешный запрос"
+// @Header			200				{string}	ETag				"Версия подписки"
+// @Failure		400				{object}	response.Error		"Невалидный ID подписки или include_deleted"
+// @Failure		404				{object}	response.Error		"Подписка не найдена"
+// @Failure		401				{object}	response.Error		"Запрос не аутентифицирован"
+// @Failure		403				{object}	response.Error		"Подписка другого пользователя"
+// @Failure		500				{object}	response.Error		"Внутренняя ошибка сервера"
 // @Router			/subscriptions/{id} [get]
 func Handler(
 	l *slog.Logger, rs readSubscription, h helper,
@@ -47,47 +58,62 @@ func Handler(
 
 	intsubID, err := h.GetSubID(r)
 	if err != nil {
-		log.Error(
+		log.ErrorContext(
+			r.Context(),
 			service.ErrInvalidSubID.Error(),
 			slog.Int64("ID", intsubID),
 			slog.String("err", err.Error()),
 		)
-		http.Error(w, service.ErrInvalidSubID.Error(), http.StatusBadRequest)
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	hasID, err := h.CheckSubscriptionID(r.Context(), intsubID)
+	includeDeleted, err := h.GetQueryFlag(r, "include_deleted")
 	if err != nil {
-		log.Error("failed check subscription ID", slog.String("err", err.Error()))
-		http.Error(w, "Something wrong", http.StatusInternalServerError)
+		log.ErrorContext(r.Context(), "bad include_deleted", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	hasID, err := h.CheckSubscriptionID(r.Context(), intsubID, includeDeleted)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed check subscription ID", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
 	if !hasID {
-		log.Error("ID not exists", slog.Int64("ID", intsubID))
-		http.Error(w, "Subscription ID not ex", http.StatusNotFound)
+		log.ErrorContext(r.Context(), "ID not exists", slog.Int64("ID", intsubID))
+		response.SendError(w, r, storage.ErrSubNotFound)
 
 		return
 	}
 
-	sub, err := rs.ReadSubscription(r.Context(), intsubID)
+	sub, err := rs.ReadSubscription(r.Context(), intsubID, includeDeleted)
 	if err != nil {
-		log.Error("failed to read subscription from DB", slog.String("err", err.Error()))
-		http.Error(w, "Something wrong", http.StatusInternalServerError)
+		log.ErrorContext(r.Context(), "failed to read subscription from DB", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := policy.Authorize(r.Context(), policy.ActionRead, sub.UserID); err != nil {
+		log.ErrorContext(r.Context(), "access denied", slog.Int64("ID", intsubID), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", model.ETag(sub.Version))
 
-	if err := json.NewEncoder(w).Encode(sub); err != nil {
-		log.Error("failed to send JSON", slog.String("err", err.Error()))
-		http.Error(w, "Something wrong", http.StatusInternalServerError)
+	if err := response.JSON(w, http.StatusOK, sub); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
 
 		return
 	}
 
-	log.Info("Subscription send successfully!", slog.Int64("ID", intsubID))
+	log.InfoContext(r.Context(), "Subscription send successfully!", slog.Int64("ID", intsubID))
 }
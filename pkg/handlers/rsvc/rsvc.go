@@ -0,0 +1,73 @@
+// Пакет rsvc для хендлера ReadService.
+package rsvc
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/service"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// readService Интерефейс с методами к базе данных,
+// который использует хендлер.
+type readService interface {
+	ReadService(ctx context.Context, serviceID int64) (*model.Service, error)
+}
+
+// urlParser Интерефейс с методами к Service,
+// который использует хендлер.
+type urlParser interface {
+	GetServiceID(r *http.Request) (int64, error)
+}
+
+// @Summary		Получить сервис по ID
+// @Description	Возвращает сервис каталога с синонимами и ценой по умолчанию.
+// @Tags			services
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			id	path		int				true	"ID сервиса"	Example(1)
+// @Success		200	{object}	model.Service	"Успешный запрос"
+// @Failure		400	{object}	response.Error	"Невалидный ID сервиса"
+// @Failure		404	{object}	response.Error	"Сервис не найден"
+// @Failure		401	{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		500	{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/services/{id} [get]
+func Handler(
+	l *slog.Logger, rs readService, up urlParser,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.rsvc.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	serviceID, err := up.GetServiceID(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), service.ErrInvalidServiceID.Error(), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	svc, err := rs.ReadService(r.Context(), serviceID)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to read service", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, svc); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(r.Context(), "Service sended successfully!", slog.Int64("ID", serviceID))
+}
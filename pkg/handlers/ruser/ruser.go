@@ -0,0 +1,88 @@
+// Пакет ruser для хендлера ReadUser.
+package ruser
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/policy"
+	"github.com/SHSanderland/EffMobTest/pkg/service"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+// readUser Интерефейс с методами к базе данных,
+// который использует хендлер.
+type readUser interface {
+	ReadUser(ctx context.Context, userID uuid.UUID) (*model.User, error)
+}
+
+// urlParser Интерефейс с методами к Service,
+// который использует хендлер.
+type urlParser interface {
+	GetUserID(r *http.Request) (uuid.UUID, error)
+}
+
+// @Summary		Получить пользователя по ID
+// @Description	Возвращает данные пользователя по его UUID.
+// @Tags			users
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			id	path		string			true	"UUID пользователя"	Example(550e8400-e29b-41d4-a716-446655440000)
+// @Success		200	{object}	model.User		"Успешный запрос"
+// @Failure		400	{object}	response.Error	"Невалидный UUID пользователя"
+// @Failure		404	{object}	response.Error	"Пользователь не найден"
+// @Failure		401	{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403	{object}	response.Error	"Данные другого пользователя"
+// @Failure		500	{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/users/{id} [get]
+func Handler(
+	l *slog.Logger, ru readUser, up urlParser,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.ruser.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	userID, err := up.GetUserID(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), service.ErrInvalidUserID.Error(), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := policy.Authorize(r.Context(), policy.ActionRead, userID); err != nil {
+		log.ErrorContext(
+			r.Context(),
+			"access denied",
+			slog.String("userID", userID.String()),
+			slog.String("err", err.Error()),
+		)
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	user, err := ru.ReadUser(r.Context(), userID)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to read user", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, user); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(r.Context(), "User sended successfully!", slog.String("userID", userID.String()))
+}
@@ -0,0 +1,142 @@
+// Пакет spend для хендлера UserSpending.
+package spend
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/policy"
+	"github.com/SHSanderland/EffMobTest/pkg/service"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+// userSpending Интерефейс с методами к базе данных,
+// который использует хендлер.
+type userSpending interface {
+	ReadUser(ctx context.Context, userID uuid.UUID) (*model.User, error)
+	CostSubscription(ctx context.Context, filter *model.CostParams) (*model.CostReport, error)
+}
+
+// helper Интерефейс с методами к Service,
+// который использует хендлер.
+type helper interface {
+	GetUserID(r *http.Request) (uuid.UUID, error)
+	GetSpendingParams(r *http.Request, user *model.User) (*model.CostParams, error)
+}
+
+// userResponse Структура для ответа пользователю.
+type userResponse struct {
+	UserID    uuid.UUID          `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	StartDate time.Time          `json:"start_period"`
+	EndDate   time.Time          `json:"end_period"`
+	Currency  string             `json:"currency" example:"RUB"`
+	TotalCost model.Costs        `json:"total_cost" swaggertype:"object,number"`
+	Months    []*model.MonthCost `json:"months"`
+	Services  []*model.CostGroup `json:"services"`
+}
+
+// @Summary		Получить расходы пользователя
+// @Description	Возвращает расходы пользователя на подписки по месяцам и по сервисам. По умолчанию суммы
+// @Description	считаются за последние 12 месяцев, включая текущий месяц в часовом поясе пользователя,
+// @Description	и пересчитываются в валюту пользователя по курсам из /rates. Списания считаются так же,
+// @Description	как в GET /subscriptions/cost.
+// @Tags			users
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			id				path		string			true	"UUID пользователя"	Example(550e8400-e29b-41d4-a716-446655440000)
+// @Param			service_name	query		[]string		false	"Название сервиса, можно передать несколько раз"	collectionFormat(multi)
+// @Param			start_date		query		string			false	"Начало периода (MM-YYYY или YYYY-MM-DD)"	Example(01-2025)
+// @Param			end_date		query		string			false	"Конец периода (MM-YYYY или YYYY-MM-DD)"	Example(12-2025)
+// @Param			include_deleted	query		bool			false	"Учитывать удаленные подписки"
+// @Param			currency		query		string			false	"Валюта пересчета, по умолчанию валюта пользователя"	Example(RUB)
+// @Success		200				{object}	userResponse	"Успешный запрос"
+// @Failure		400				{object}	response.Error	"Невалидный UUID пользователя, параметры запроса или период длиннее 120 месяцев"
+// @Failure		404				{object}	response.Error	"Пользователь не найден"
+// @Failure		422				{object}	response.Error	"Нет курса для пересчета валюты подписки или сумма слишком велика"
+// @Failure		401				{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403				{object}	response.Error	"Данные другого пользователя"
+// @Failure		500				{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/users/{id}/spending [get]
+func Handler(
+	l *slog.Logger, us userSpending, h helper,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.spend.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	userID, err := h.GetUserID(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), service.ErrInvalidUserID.Error(), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := policy.Authorize(r.Context(), policy.ActionRead, userID); err != nil {
+		log.ErrorContext(
+			r.Context(),
+			"access denied",
+			slog.String("userID", userID.String()),
+			slog.String("err", err.Error()),
+		)
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	user, err := us.ReadUser(r.Context(), userID)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to read user", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	filters, err := h.GetSpendingParams(r, user)
+	if err != nil {
+		log.ErrorContext(r.Context(), "invalid params", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	report, err := us.CostSubscription(r.Context(), filters)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to count spending", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	resp := userResponse{
+		UserID:    userID,
+		StartDate: report.StartDate,
+		EndDate:   report.EndDate,
+		Currency:  report.Currency,
+		TotalCost: report.Total,
+		Months:    report.Months,
+		Services:  report.Group(model.GroupByService),
+	}
+
+	if err := response.JSON(w, http.StatusOK, resp); err != nil {
+		log.ErrorContext(r.Context(), "failed to send response", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(
+		r.Context(),
+		"User spending counted successfully!",
+		slog.String("userID", userID.String()),
+		slog.String("currency", filters.Currency),
+	)
+}
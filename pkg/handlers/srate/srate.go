@@ -0,0 +1,86 @@
+// Пакет srate для хендлера SetRate.
+package srate
+
+import (
+	"context"
+	"log/slog"
+	"math/big"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// setRate Интерефейс с методами к базе данных,
+// который использует хендлер.
+type setRate interface {
+	SetRate(ctx context.Context, from, to string, rate *big.Rat) (*model.Rate, error)
+}
+
+// urlParser Интерефейс с методами к Service,
+// который использует хендлер.
+type urlParser interface {
+	GetRatePair(r *http.Request) (string, string, error)
+}
+
+// @Summary		Установить курс валюты
+// @Description	Создает или заменяет курс пары валют: 1 from = rate to.
+// @Description	Курс должен быть положительным и иметь не больше 10 знаков после точки.
+// @Tags			rates
+// @Accept			json
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			from	path		string			true	"Исходная валюта (ISO 4217)"	Example(USD)
+// @Param			to		path		string			true	"Валюта пересчета (ISO 4217)"	Example(RUB)
+// @Param			input	body		model.RateBody	true	"Курс"
+// @Success		200		{object}	model.Rate		"Курс установлен"
+// @Failure		400		{object}	response.Error	"Невалидная валюта или JSON тела запроса"
+// @Failure		422		{object}	response.Error	"Невалидный курс"
+// @Failure		401		{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403		{object}	response.Error	"Доступно только admin и support"
+// @Failure		500		{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/rates/{from}/{to} [put]
+func Handler(
+	l *slog.Logger, sr setRate, up urlParser,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.srate.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	from, to, err := up.GetRatePair(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "bad currency pair", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	rate, err := model.GetRateFromBody(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to get body", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	saved, err := sr.SetRate(r.Context(), from, to, rate)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to set rate", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, saved); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(r.Context(), "Rate set successfully!", slog.String("from", from), slog.String("to", to))
+}
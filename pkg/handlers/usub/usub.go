@@ -5,43 +5,67 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
 	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/policy"
 	"github.com/SHSanderland/EffMobTest/pkg/service"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
 // updateSubscription Интерефейс с методами к базе данных,
 // который использует хендлер.
 type updateSubscription interface {
-	UpdateSubscription(ctx context.Context, id int64, sub *model.Subscription) error
+	UpdateSubscription(
+		ctx context.Context, id, version int64, priceFrom *time.Time, update storage.UpdateFunc,
+	) (*model.Subscription, error)
 }
 
 // helper Интерефейс с методами к Service,
 // который использует хендлер.
 type helper interface {
-	CheckSubscriptionID(ctx context.Context, subID int64) (bool, error)
-	CheckSubscriptionForUpdate(ctx context.Context, subID int64, sub *model.Subscription) (bool, error)
+	CheckBody(sub *model.Subscription) error
 	GetSubID(r *http.Request) (int64, error)
+	GetIfMatch(r *http.Request) (int64, error)
+	GetEffectiveFrom(r *http.Request) (*time.Time, error)
 }
 
-// @Summary		Обновить подписку
-// @Description	Обновляет информацию о существующей подписке по её ID
+// @Summary		Заменить подписку
+// @Description	Полностью заменяет данные существующей подписки по её ID. Тело проверяется
+// @Description	так же, как при создании, отсутствующий end_date делает подписку бессрочной.
+// @Description	С заголовком If-Match подписка заменяется, только если ее версия не изменилась.
+// @Description	Новая цена действует с effective_from, без него - с текущего месяца, цены до этой даты
+// @Description	сохраняются. Дата должна попадать в период подписки, валюта меняется, только если
+// @Description	effective_from равен start_date. Цена, которая вступит в силу позже, попадает только
+// @Description	в историю цен - GET /subscriptions/{id}/prices.
 // @Tags			subscriptions
 // @Accept			json
-// @Produce		plain
-// @Param			id		path	int					true	"ID обновляемой подписки"	Example(123)
-// @Param			input	body	model.Subscription	true	"Новые данные подписки"
-// @Success		200		"Подписка успешно обновлена"
-// @Failure		400		{string}	string	"Невалидные входные данные (ID или тело запроса)"
-// @Failure		404		{string}	string	"Подписка с указанным ID не найдена"
-// @Failure		500		{string}	string	"Внутренняя ошибка сервера"
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			id			path		int					true	"ID обновляемой подписки"	Example(123)
+// @Param			If-Match	header		string				false	"ETag подписки из предыдущего ответа"	Example("3")
+// @Param			effective_from	query		string				false	"Дата, с которой действует новая цена (MM-YYYY или YYYY-MM-DD)"	Example(03-2025)
+// @Param			input		body		model.Subscription	true	"Новые данные подписки"
+// @Success		200			{object}	model.Subscription	"Подписка успешно обновлена, новая версия в заголовке ETag"
+// @Header			200			{string}	ETag				"Версия подписки"
+// @Failure		400			{object}	response.Error		"Невалидный ID, If-Match, effective_from или JSON тела запроса"
+// @Failure		404			{object}	response.Error		"Подписка с указанным ID не найдена"
+// @Failure		409			{object}	response.Error		"Пересечение с существующей подпиской, ее ID в details"
+// @Failure		412			{object}	response.Error		"Версия подписки не совпадает с If-Match"
+// @Failure		413			{object}	response.Error		"Слишком большое тело запроса"
+// @Failure		422			{object}	response.Error		"Нарушения валидации полей в details"
+// @Failure		401			{object}	response.Error		"Запрос не аутентифицирован"
+// @Failure		403			{object}	response.Error		"Подписка другого пользователя"
+// @Failure		500			{object}	response.Error		"Внутренняя ошибка сервера"
 // @Router			/subscriptions/{id} [put]
 func Handler(
 	l *slog.Logger, us updateSubscription, h helper,
 	w http.ResponseWriter, r *http.Request,
 ) {
-	const fn = "handlers.rsub.Handler"
+	const fn = "handlers.usub.Handler"
 	log := l.With(
 		slog.String("fn", fn),
 		slog.String("requestID", middleware.GetReqID(r.Context())),
@@ -49,61 +73,74 @@ func Handler(
 
 	intsubID, err := h.GetSubID(r)
 	if err != nil {
-		log.Error(
+		log.ErrorContext(
+			r.Context(),
 			service.ErrInvalidSubID.Error(),
 			slog.Int64("ID", intsubID),
 			slog.String("err", err.Error()),
 		)
-		http.Error(w, service.ErrInvalidSubID.Error(), http.StatusBadRequest)
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	hasID, err := h.CheckSubscriptionID(r.Context(), intsubID)
+	version, err := h.GetIfMatch(r)
 	if err != nil {
-		log.Error("failed check subscription ID", slog.String("err", err.Error()))
-		http.Error(w, "Something wrong", http.StatusInternalServerError)
+		log.ErrorContext(r.Context(), "bad If-Match", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	if !hasID {
-		log.Error("ID not exists", slog.Int64("ID", intsubID))
-		http.Error(w, "Subscription ID not ex", http.StatusNotFound)
+	priceFrom, err := h.GetEffectiveFrom(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "bad effective_from", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
 	sub, err := model.GetSubFromBody(r)
 	if err != nil {
-		log.Error("failed to get body", slog.String("err", err.Error()))
-		http.Error(w, "Bad body", http.StatusBadRequest)
+		log.ErrorContext(r.Context(), "failed to get body", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	isValidUpdate, err := h.CheckSubscriptionForUpdate(r.Context(), intsubID, sub)
-	if err != nil {
-		log.Error("failed check subscription for update", slog.String("err", err.Error()))
-		http.Error(w, "Something wrong", http.StatusInternalServerError)
+	if err := h.CheckBody(sub); err != nil {
+		log.ErrorContext(r.Context(), "bad body", slog.Any("body", sub), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	if !isValidUpdate {
-		log.Error("update not valid", slog.Any("sub", sub))
-		http.Error(w, "Bad body", http.StatusBadRequest)
+	updated, err := us.UpdateSubscription(
+		r.Context(), intsubID, version, priceFrom,
+		func(cur *model.Subscription) error {
+			if err := policy.Authorize(r.Context(), policy.ActionWrite, cur.UserID, sub.UserID); err != nil {
+				return err
+			}
+
+			cur.Replace(sub)
+
+			return nil
+		},
+	)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed update subscription", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
 
 		return
 	}
 
-	err = us.UpdateSubscription(r.Context(), intsubID, sub)
-	if err != nil {
-		log.Error("failed update subscription", slog.String("err", err.Error()))
-		http.Error(w, "Something wrong", http.StatusInternalServerError)
+	w.Header().Set("ETag", model.ETag(updated.Version))
+
+	if err := response.JSON(w, http.StatusOK, updated); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
 
 		return
 	}
 
-	log.Info("Subscription update successfully!", slog.Int64("ID", intsubID))
+	log.InfoContext(r.Context(), "Subscription update successfully!", slog.Int64("ID", intsubID))
 }
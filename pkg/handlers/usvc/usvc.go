@@ -0,0 +1,97 @@
+// Пакет usvc для хендлера UpdateService.
+package usvc
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/service"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// updateService Интерефейс с методами к базе данных,
+// который использует хендлер.
+type updateService interface {
+	UpdateService(ctx context.Context, serviceID int64, svc *model.Service) (*model.Service, error)
+}
+
+// helper Интерефейс с методами к Service,
+// который использует хендлер.
+type helper interface {
+	CheckService(svc *model.Service) error
+	GetServiceID(r *http.Request) (int64, error)
+}
+
+// @Summary		Заменить сервис
+// @Description	Полностью заменяет данные сервиса каталога. При переименовании все подписки сервиса
+// @Description	получают новое название, их версия увеличивается, а в журнал изменений пишется
+// @Description	событие updated. Старое название не сохраняется, если его нет среди синонимов.
+// @Tags			services
+// @Accept			json
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			id		path		int				true	"ID сервиса"	Example(1)
+// @Param			input	body		model.Service	true	"Новые данные сервиса"
+// @Success		200		{object}	model.Service	"Сервис обновлен"
+// @Failure		400		{object}	response.Error	"Невалидный ID сервиса или JSON тела запроса"
+// @Failure		404		{object}	response.Error	"Сервис не найден"
+// @Failure		409		{object}	response.Error	"Название или синоним заняты другим сервисом"
+// @Failure		413		{object}	response.Error	"Слишком большое тело запроса"
+// @Failure		422		{object}	response.Error	"Нарушения валидации полей в details"
+// @Failure		401		{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403		{object}	response.Error	"Доступно только admin и support"
+// @Failure		500		{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/services/{id} [put]
+func Handler(
+	l *slog.Logger, us updateService, h helper,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.usvc.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	serviceID, err := h.GetServiceID(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), service.ErrInvalidServiceID.Error(), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	svc, err := model.GetServiceFromBody(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to get body", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := h.CheckService(svc); err != nil {
+		log.ErrorContext(r.Context(), "bad body", slog.Any("body", svc), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	updated, err := us.UpdateService(r.Context(), serviceID, svc)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed update service", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, updated); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(r.Context(), "Service update successfully!", slog.Int64("ID", serviceID))
+}
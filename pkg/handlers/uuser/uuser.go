@@ -0,0 +1,118 @@
+// Пакет uuser для хендлера UpdateUser.
+package uuser
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/policy"
+	"github.com/SHSanderland/EffMobTest/pkg/service"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+// updateUser Интерефейс с методами к базе данных,
+// который использует хендлер.
+type updateUser interface {
+	UpdateUser(ctx context.Context, userID uuid.UUID, user *model.User) (*model.User, error)
+}
+
+// helper Интерефейс с методами к Service,
+// который использует хендлер.
+type helper interface {
+	CheckUser(user *model.User) error
+	GetUserID(r *http.Request) (uuid.UUID, error)
+}
+
+// @Summary		Заменить пользователя
+// @Description	Полностью заменяет данные пользователя. UUID пользователя не меняется, id в теле
+// @Description	должен совпадать с UUID из пути или отсутствовать.
+// @Tags			users
+// @Accept			json
+// @Produce		json
+// @Security		BearerAuth
+// @Security		ApiKeyAuth
+// @Param			id		path		string			true	"UUID пользователя"	Example(550e8400-e29b-41d4-a716-446655440000)
+// @Param			input	body		model.User		true	"Новые данные пользователя"
+// @Success		200		{object}	model.User		"Пользователь обновлен"
+// @Failure		400		{object}	response.Error	"Невалидный UUID пользователя или JSON тела запроса"
+// @Failure		404		{object}	response.Error	"Пользователь не найден"
+// @Failure		409		{object}	response.Error	"Email занят другим пользователем"
+// @Failure		413		{object}	response.Error	"Слишком большое тело запроса"
+// @Failure		422		{object}	response.Error	"Нарушения валидации полей в details"
+// @Failure		401		{object}	response.Error	"Запрос не аутентифицирован"
+// @Failure		403		{object}	response.Error	"Данные другого пользователя"
+// @Failure		500		{object}	response.Error	"Внутренняя ошибка сервера"
+// @Router			/users/{id} [put]
+func Handler(
+	l *slog.Logger, uu updateUser, h helper,
+	w http.ResponseWriter, r *http.Request,
+) {
+	const fn = "handlers.uuser.Handler"
+	log := l.With(
+		slog.String("fn", fn),
+		slog.String("requestID", middleware.GetReqID(r.Context())),
+	)
+
+	userID, err := h.GetUserID(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), service.ErrInvalidUserID.Error(), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := policy.Authorize(r.Context(), policy.ActionWrite, userID); err != nil {
+		log.ErrorContext(
+			r.Context(),
+			"access denied",
+			slog.String("userID", userID.String()),
+			slog.String("err", err.Error()),
+		)
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	user, err := model.GetUserFromBody(r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to get body", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if user.ID != uuid.Nil && user.ID != userID {
+		err := model.NewValidationError("id", model.RuleUnchanged, "must match the user ID in the path")
+		log.ErrorContext(r.Context(), "bad body", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := h.CheckUser(user); err != nil {
+		log.ErrorContext(r.Context(), "bad body", slog.Any("body", user), slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	updated, err := uu.UpdateUser(r.Context(), userID, user)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed update user", slog.String("err", err.Error()))
+		response.SendError(w, r, err)
+
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, updated); err != nil {
+		log.ErrorContext(r.Context(), "failed to send JSON", slog.String("err", err.Error()))
+
+		return
+	}
+
+	log.InfoContext(r.Context(), "User update successfully!", slog.String("userID", userID.String()))
+}
@@ -2,8 +2,12 @@
 package logger
 
 import (
+	"context"
+	"io"
 	"log/slog"
 	"os"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Константы уровня окружения.
@@ -18,22 +22,58 @@ const (
 // Для env=dev Level=Debug.
 // Для env=prod Level=Info.
 func InitLogger(env string) *slog.Logger {
+	return NewLogger(env, os.Stdout)
+}
+
+// NewLogger Создание логгера с нужным окружением, который пишет в w.
+// Используется командами, которые пишут результат в stdout.
+// Записи методов *Context получают traceID и spanID спана из контекста.
+func NewLogger(env string, w io.Writer) *slog.Logger {
 	var log *slog.Logger
 
 	switch env {
 	case envLocal:
-		log = slog.New(
-			slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
-		)
+		log = slog.New(traceHandler{
+			slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		})
 	case envDev:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
-		)
+		log = slog.New(traceHandler{
+			slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		})
 	case envProd:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
-		)
+		log = slog.New(traceHandler{
+			slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelInfo}),
+		})
 	}
 
 	return log
 }
+
+// traceHandler Обертка slog.Handler, которая добавляет к записи
+// атрибуты traceID и spanID текущего спана из контекста для связи
+// логов с трассой. Без спана запись не меняется.
+type traceHandler struct {
+	slog.Handler
+}
+
+// Handle Добавление атрибутов спана к записи.
+func (h traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("traceID", sc.TraceID().String()),
+			slog.String("spanID", sc.SpanID().String()),
+		)
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs Обертка обработчика с атрибутами.
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup Обертка обработчика с группой.
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// refreshTimeout Ограничение времени запроса к хранилищу при обновлении
+// бизнес-метрик.
+const refreshTimeout = 5 * time.Second
+
+// poolStats Интерефейс хранилища с пулом соединений pgxpool.
+type poolStats interface {
+	Stat() *pgxpool.Stat
+}
+
+// activeCounter Интерефейс с методами к базе данных,
+// который использует обновление бизнес-метрик.
+type activeCounter interface {
+	CountActiveSubscriptions(ctx context.Context) (map[string]int64, error)
+}
+
+// active Действующие подписки по арендаторам. Обновляется по таймеру,
+// а не при каждом запросе метрик, чтобы частые запросы не нагружали базу.
+var active = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: Namespace,
+	Name:      "active",
+	Help:      "Number of active subscriptions by tenant.",
+}, []string{"tenant"})
+
+// RegisterStorage Регистрация метрик хранилища: статистика пула
+// соединений, если хранилище использует pgxpool, и действующие
+// подписки, которые обновляются каждые interval до отмены ctx.
+// Вызывается один раз при запуске сервера.
+func RegisterStorage(ctx context.Context, log *slog.Logger, db activeCounter, interval time.Duration) {
+	if p, ok := db.(poolStats); ok {
+		registry.MustRegister(newPoolCollector(p))
+	}
+
+	go refreshActive(ctx, log, db, interval)
+}
+
+// refreshActive Обновление числа действующих подписок сразу и далее
+// каждые interval до отмены ctx.
+func refreshActive(ctx context.Context, log *slog.Logger, db activeCounter, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	tenants := map[string]bool{}
+
+	for {
+		tenants = updateActive(ctx, log, db, tenants)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// updateActive Подсчет действующих подписок в хранилище. Арендаторы
+// без подписок удаляются из метрики, при ошибке остаются прежние
+// значения. Возвращает арендаторов в метрике.
+func updateActive(
+	ctx context.Context, log *slog.Logger, db activeCounter, tenants map[string]bool,
+) map[string]bool {
+	const fn = "metrics.updateActive"
+
+	ctx, cancel := context.WithTimeout(ctx, refreshTimeout)
+	defer cancel()
+
+	counts, err := db.CountActiveSubscriptions(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to count active subscriptions", slog.String("fn", fn), slog.String("err", err.Error()))
+
+		return tenants
+	}
+
+	updated := make(map[string]bool, len(counts))
+
+	for tenantID, count := range counts {
+		active.WithLabelValues(tenantID).Set(float64(count))
+		updated[tenantID] = true
+	}
+
+	for tenantID := range tenants {
+		if !updated[tenantID] {
+			active.DeleteLabelValues(tenantID)
+		}
+	}
+
+	return updated
+}
+
+// poolCollector Сборщик статистики пула соединений pgxpool.
+type poolCollector struct {
+	pool poolStats
+
+	acquired       *prometheus.Desc
+	idle           *prometheus.Desc
+	constructing   *prometheus.Desc
+	total          *prometheus.Desc
+	max            *prometheus.Desc
+	acquires       *prometheus.Desc
+	emptyAcquires  *prometheus.Desc
+	canceled       *prometheus.Desc
+	acquireSeconds *prometheus.Desc
+	waitSeconds    *prometheus.Desc
+}
+
+// newPoolCollector Создание сборщика статистики пула.
+func newPoolCollector(pool poolStats) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(Namespace, "db_pool", name), help, nil, nil)
+	}
+
+	return &poolCollector{
+		pool:           pool,
+		acquired:       desc("acquired_connections", "Number of connections currently in use."),
+		idle:           desc("idle_connections", "Number of idle connections in the pool."),
+		constructing:   desc("constructing_connections", "Number of connections being established."),
+		total:          desc("total_connections", "Total number of connections in the pool."),
+		max:            desc("max_connections", "Maximum size of the pool."),
+		acquires:       desc("acquires_total", "Number of successful connection acquires."),
+		emptyAcquires:  desc("empty_acquires_total", "Number of acquires that waited for a connection."),
+		canceled:       desc("canceled_acquires_total", "Number of acquires canceled by context."),
+		acquireSeconds: desc("acquire_seconds_total", "Total time spent acquiring connections."),
+		waitSeconds:    desc("empty_acquire_wait_seconds_total", "Total time spent waiting for a free connection."),
+	}
+}
+
+// Describe Описание метрик сборщика.
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		c.acquired, c.idle, c.constructing, c.total, c.max,
+		c.acquires, c.emptyAcquires, c.canceled, c.acquireSeconds, c.waitSeconds,
+	} {
+		ch <- d
+	}
+}
+
+// Collect Сбор статистики пула.
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+
+	gauge := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v)
+	}
+	counter := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v)
+	}
+
+	gauge(c.acquired, float64(s.AcquiredConns()))
+	gauge(c.idle, float64(s.IdleConns()))
+	gauge(c.constructing, float64(s.ConstructingConns()))
+	gauge(c.total, float64(s.TotalConns()))
+	gauge(c.max, float64(s.MaxConns()))
+	counter(c.acquires, float64(s.AcquireCount()))
+	counter(c.emptyAcquires, float64(s.EmptyAcquireCount()))
+	counter(c.canceled, float64(s.CanceledAcquireCount()))
+	counter(c.acquireSeconds, s.AcquireDuration().Seconds())
+	counter(c.waitSeconds, s.EmptyAcquireWaitTime().Seconds())
+}
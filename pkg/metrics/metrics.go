@@ -0,0 +1,130 @@
+// Пакет metrics для метрик сервиса в формате Prometheus. Метрики
+// регистрируются в собственном реестре пакета и отдаются через Handler:
+//   - запросы к API по маршрутам, их длительность и статусы ответов;
+//   - сбои хранилища по sentinel-ошибкам пакета storage;
+//   - статистика пула соединений PostgreSQL;
+//   - действующие подписки по арендаторам.
+package metrics
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace Префикс имен метрик сервиса.
+const Namespace = "subscriptions"
+
+// unmatchedRoute Маршрут запросов, для которых роутер не нашел
+// маршрут, чтобы случайные пути не раздували число серий.
+const unmatchedRoute = "unmatched"
+
+// registry Реестр метрик сервиса.
+var registry = prometheus.NewRegistry()
+
+var (
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests by route, method and status code.",
+	}, []string{"method", "route", "status"})
+
+	duration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	storageErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "storage",
+		Name:      "errors_total",
+		Help:      "Number of failed database operations by storage error.",
+	}, []string{"error"})
+)
+
+// storageError Соответствие sentinel-ошибки хранилища значению метки.
+type storageError struct {
+	err   error
+	label string
+}
+
+// storageErrorLabels Таблица меток ошибок хранилища. Учитываются только
+// сбои базы данных, ошибки предметной области (подписка не найдена,
+// конфликт версий) видны в статусах ответов http_requests_total.
+var storageErrorLabels = []storageError{
+	{storage.ErrBeginTrans, "begin_transaction"},
+	{storage.ErrCommitTrans, "commit_transaction"},
+	{storage.ErrExecSchema, "exec_schema"},
+	{storage.ErrUnavailable, "unavailable"},
+}
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requests,
+		duration,
+		storageErrors,
+		active,
+	)
+}
+
+// Handler Отдача метрик в текстовом формате Prometheus. Ошибка одного
+// сборщика не мешает отдать остальные метрики.
+func Handler(log *slog.Logger) http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		ErrorLog:      slog.NewLogLogger(log.Handler(), slog.LevelError),
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+// Middleware Учет запросов и их длительности по шаблону маршрута chi.
+// Подключается к корневому роутеру, чтобы шаблон был известен после
+// обработки запроса.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := unmatchedRoute
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		requests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		duration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveStorageError Учет сбоя хранилища по его sentinel-ошибке.
+// Вызывается драйвером хранилища там, где сбой возникает. Остальные
+// ошибки не учитываются.
+func ObserveStorageError(err error) {
+	for _, se := range storageErrorLabels {
+		if errors.Is(err, se.err) {
+			storageErrors.WithLabelValues(se.label).Inc()
+
+			return
+		}
+	}
+}
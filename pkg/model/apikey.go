@@ -0,0 +1,96 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Роли вызывающих. Администратор может все, поддержка видит и изменяет
+// все подписки, но не удаляет их, пользователь работает только со своими
+// подписками.
+const (
+	RoleAdmin   = "admin"
+	RoleSupport = "support"
+	RoleUser    = "user"
+)
+
+// IsRole Проверка, что роль поддерживается.
+func IsRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleSupport, RoleUser:
+		return true
+	default:
+		return false
+	}
+}
+
+// APIKey Статический ключ доступа к API. Сам ключ показывается только
+// при создании, хранится лишь его хеш Hash, а Prefix помогает узнать
+// ключ в списке. Отозванный ключ (RevokedAt != nil) не принимается.
+// Ключ с ролью user действует от имени пользователя UserID. Ключ
+// принадлежит арендатору TenantID, в котором был создан.
+type APIKey struct {
+	ID        int64      `json:"id" readonly:"true"`
+	Name      string     `json:"name" example:"billing-cron"`
+	Prefix    string     `json:"prefix" readonly:"true" example:"emk_AbCdEfGh"`
+	Role      string     `json:"role" enums:"admin,support,user" example:"user"`
+	UserID    *uuid.UUID `json:"user_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	TenantID  string     `json:"tenant_id" readonly:"true" example:"default"`
+	CreatedAt time.Time  `json:"created_at" readonly:"true"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" readonly:"true"`
+	Hash      string     `json:"-"`
+}
+
+// MaxAPIKeyNameLength Ограничение длины названия ключа,
+// совпадает с размером колонки VARCHAR(255).
+const MaxAPIKeyNameLength = 255
+
+// GetAPIKeyFromBody Получение тела запроса и маршал в APIKey.
+// Без роли ключ получает роль user. Ошибки такие же, как у GetSubFromBody.
+func GetAPIKeyFromBody(r *http.Request) (*APIKey, error) {
+	key := APIKey{}
+
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, MaxBodySize))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&key); err != nil {
+		return nil, decodeError(err)
+	}
+
+	if dec.More() {
+		return nil, fmt.Errorf("%w: unexpected data after JSON object", ErrBadBody)
+	}
+
+	key.Name = strings.TrimSpace(key.Name)
+
+	if key.Role == "" {
+		key.Role = RoleUser
+	}
+
+	return &key, nil
+}
+
+// ValidateAPIKey Валидация названия и роли ключа. Ключу с ролью user
+// нужен пользователь, от имени которого он действует.
+func ValidateAPIKey(key *APIKey) error {
+	switch {
+	case key.Name == "":
+		return NewValidationError("name", RuleRequired, "must not be empty")
+	case utf8.RuneCountInString(key.Name) > MaxAPIKeyNameLength:
+		return NewValidationError(
+			"name", RuleMaxLength, fmt.Sprintf("must be at most %d characters", MaxAPIKeyNameLength),
+		)
+	case !IsRole(key.Role):
+		return NewValidationError("role", RuleFormat, "must be one of admin, support, user")
+	case key.Role == RoleUser && (key.UserID == nil || *key.UserID == uuid.Nil):
+		return NewValidationError("user_id", RuleRequired, "must be set for role user")
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Операции пакетного запроса.
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// Ограничения пакетного запроса. Тело пакета может быть больше
+// MaxBodySize, так как содержит до MaxBatchSize подписок.
+const (
+	MaxBatchSize     = 1000
+	MaxBatchBodySize = 16 << 20
+)
+
+// BatchOperation Операция пакетного запроса.
+// Для create и update обязательна Subscription, которая проверяется
+// так же, как тело POST и PUT. Для update и delete обязателен ID,
+// Version заменяет заголовок If-Match, 0 означает без проверки.
+// Hard используется только для delete, EffectiveFrom заменяет параметр
+// effective_from запроса PUT и используется только для update.
+type BatchOperation struct {
+	Op            string        `json:"op" enums:"create,update,delete" example:"create"`
+	ID            int64         `json:"id,omitempty" example:"123"`
+	Version       int64         `json:"version,omitempty" example:"3"`
+	Hard          bool          `json:"hard,omitempty"`
+	EffectiveFrom string        `json:"effective_from,omitempty" example:"03-2025"`
+	Subscription  *Subscription `json:"subscription,omitempty"`
+}
+
+// BatchRequest Тело пакетного запроса.
+type BatchRequest struct {
+	Operations []*BatchOperation `json:"operations"`
+}
+
+// BatchResult Результат одной операции пакета. Subscription
+// пустая для delete и для операции с ошибкой.
+type BatchResult struct {
+	Subscription *Subscription
+	Err          error
+}
+
+// GetBatchFromBody Получение тела пакетного запроса.
+// Размер тела ограничен MaxBatchBodySize, неизвестные поля запрещены.
+// Пустой пакет и пакет больше MaxBatchSize возвращаются как *ValidationError.
+// Подписки получают значения по умолчанию, как в GetSubFromBody.
+func GetBatchFromBody(r *http.Request) (*BatchRequest, error) {
+	batch := BatchRequest{}
+
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, MaxBatchBodySize))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&batch); err != nil {
+		return nil, decodeError(err)
+	}
+
+	if dec.More() {
+		return nil, fmt.Errorf("%w: unexpected data after JSON object", ErrBadBody)
+	}
+
+	for _, op := range batch.Operations {
+		if op != nil && op.Subscription != nil {
+			op.Subscription.SetDefaults()
+		}
+	}
+
+	switch {
+	case len(batch.Operations) == 0:
+		return nil, NewValidationError("operations", RuleRequired, "must not be empty")
+	case len(batch.Operations) > MaxBatchSize:
+		return nil, NewValidationError(
+			"operations",
+			RuleMaxLength,
+			fmt.Sprintf("must contain at most %d operations", MaxBatchSize),
+		)
+	}
+
+	return &batch, nil
+}
+
+// ValidateBatchOperation Валидация операции пакета без проверки
+// Subscription. Возвращает *ValidationError или nil.
+func ValidateBatchOperation(op *BatchOperation) error {
+	var violations []Violation
+
+	switch op.Op {
+	case BatchCreate, BatchUpdate, BatchDelete:
+	case "":
+		violations = append(violations, Violation{"op", RuleRequired, "must not be empty"})
+	default:
+		violations = append(violations, Violation{"op", RuleFormat, "must be one of create, update, delete"})
+	}
+
+	if (op.Op == BatchUpdate || op.Op == BatchDelete) && op.ID <= 0 {
+		violations = append(violations, Violation{"id", RulePositive, "must be positive"})
+	}
+
+	if op.Version < 0 {
+		violations = append(violations, Violation{"version", RuleNonNegative, "must not be negative"})
+	}
+
+	if _, err := op.PriceFrom(); err != nil {
+		violations = append(violations, Violation{"effective_from", RuleFormat, err.Error()})
+	}
+
+	if (op.Op == BatchCreate || op.Op == BatchUpdate) && op.Subscription == nil {
+		violations = append(violations, Violation{"subscription", RuleRequired, "must not be empty"})
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+
+	return nil
+}
+
+// PriceFrom Дата, с которой действует новая цена операции update.
+// Возвращает nil, если EffectiveFrom не задана.
+func (op *BatchOperation) PriceFrom() (*time.Time, error) {
+	if op.EffectiveFrom == "" {
+		return nil, nil
+	}
+
+	from, err := ParseDate(op.EffectiveFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	return &from, nil
+}
@@ -0,0 +1,161 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Service Сервис из каталога. Подписки ссылаются на сервис по ID,
+// а их service_name всегда совпадает с каноническим названием Name.
+//
+// Примечание: Name и Aliases сравниваются после ServiceKey, то есть
+// без учета регистра и лишних пробелов, и уникальны во всем каталоге.
+// DefaultPrice в валюте Currency используется при создании подписки
+// без цены.
+type Service struct {
+	ID           int64     `json:"id" readonly:"true"`
+	Name         string    `json:"name" example:"Netflix"`
+	Aliases      []string  `json:"aliases" example:"netflix.com"`
+	Category     string    `json:"category,omitempty" example:"video"`
+	DefaultPrice *Amount   `json:"default_price,omitempty" swaggertype:"number" example:"9.99"`
+	Currency     string    `json:"currency" example:"USD"`
+	CreatedAt    time.Time `json:"created_at" readonly:"true"`
+	UpdatedAt    time.Time `json:"updated_at" readonly:"true"`
+}
+
+// serviceFields Поля сервиса без методов JSON.
+type serviceFields Service
+
+// serviceJSON Сервис в JSON. Цена по умолчанию записывается с числом
+// знаков валюты сервиса.
+type serviceJSON struct {
+	*serviceFields
+	DefaultPrice json.RawMessage `json:"default_price,omitempty"`
+}
+
+// MarshalJSON Запись сервиса с ценой по умолчанию в валюте сервиса.
+func (s Service) MarshalJSON() ([]byte, error) {
+	w := serviceJSON{serviceFields: (*serviceFields)(&s)}
+	if s.DefaultPrice != nil {
+		w.DefaultPrice = json.RawMessage(s.DefaultPrice.Format(s.Currency))
+	}
+
+	return json.Marshal(w)
+}
+
+// UnmarshalJSON Чтение сервиса. Неизвестные поля запрещены, цена
+// по умолчанию читается в валюте сервиса, без валюты -
+// в DefaultCurrency. Ошибка цены возвращается с ErrInvalidAmount.
+func (s *Service) UnmarshalJSON(data []byte) error {
+	w := serviceJSON{serviceFields: (*serviceFields)(s)}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&w); err != nil {
+		return err
+	}
+
+	if isJSONNull(w.DefaultPrice) {
+		s.DefaultPrice = nil
+
+		return nil
+	}
+
+	price, err := decodeAmount(w.DefaultPrice, currencyOrDefault(s.Currency))
+	if err != nil {
+		return err
+	}
+
+	s.DefaultPrice = &price
+
+	return nil
+}
+
+// MaxAliases Ограничение числа синонимов сервиса.
+const MaxAliases = 50
+
+// NormalizeServiceName Приведение названия сервиса к каноническому
+// виду: без пробелов по краям и с одиночными пробелами между словами.
+func NormalizeServiceName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// ServiceKey Ключ поиска сервиса по названию или синониму.
+func ServiceKey(name string) string {
+	return strings.ToLower(NormalizeServiceName(name))
+}
+
+// Keys Уникальные ключи названия и синонимов сервиса.
+func (s *Service) Keys() []string {
+	keys := []string{ServiceKey(s.Name)}
+
+	for _, alias := range s.Aliases {
+		if key := ServiceKey(alias); !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// SetDefaults Нормализация названия и синонимов и заполнение
+// непереданной валюты значением по умолчанию.
+func (s *Service) SetDefaults() {
+	s.Name = NormalizeServiceName(s.Name)
+
+	if s.Aliases == nil {
+		s.Aliases = []string{}
+	}
+
+	for i, alias := range s.Aliases {
+		s.Aliases[i] = NormalizeServiceName(alias)
+	}
+
+	if s.Currency == "" {
+		s.Currency = DefaultCurrency
+	}
+}
+
+// ApplyService Заполнение подписки по сервису из каталога: название
+// заменяется каноническим, а подписка без цены получает цену и валюту
+// сервиса по умолчанию.
+func (s *Subscription) ApplyService(svc *Service) {
+	s.ServiceName = svc.Name
+
+	if s.Price == 0 && svc.DefaultPrice != nil {
+		s.Price = *svc.DefaultPrice
+		s.Currency = svc.Currency
+	}
+}
+
+// GetServiceFromBody Получение тела запроса и маршал в Service.
+// Ошибки такие же, как у GetSubFromBody.
+func GetServiceFromBody(r *http.Request) (*Service, error) {
+	svc := Service{}
+
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, MaxBodySize))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&svc); err != nil {
+		if errors.Is(err, ErrInvalidAmount) {
+			return nil, NewValidationError("default_price", RuleFormat, err.Error())
+		}
+
+		return nil, decodeError(err)
+	}
+
+	if dec.More() {
+		return nil, fmt.Errorf("%w: unexpected data after JSON object", ErrBadBody)
+	}
+
+	svc.SetDefaults()
+
+	return &svc, nil
+}
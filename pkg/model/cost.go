@@ -0,0 +1,265 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxCostMonths Наибольшая длина периода подсчета стоимости в месяцах.
+const MaxCostMonths = 120
+
+// ErrCostRange Период подсчета стоимости длиннее MaxCostMonths.
+var ErrCostRange = fmt.Errorf("cost period is longer than %d months", MaxCostMonths)
+
+// CheckCostRange Проверка, что период [from, to] занимает не больше
+// MaxCostMonths месяцев.
+func CheckCostRange(from, to time.Time) error {
+	if (to.Year()-from.Year())*12+int(to.Month()-from.Month()) >= MaxCostMonths {
+		return ErrCostRange
+	}
+
+	return nil
+}
+
+// CostSource Данные подписки, необходимые для подсчета стоимости.
+//
+// Примечание: подписка действует в полуинтервале [StartDate, EndDate),
+// то есть день окончания уже не оплачивается. EndDate == nil означает
+// бессрочную подписку. Каждое списание оплачивается по цене из Prices,
+// действующей в его дату, без истории - по Price.
+type CostSource struct {
+	SubscriptionID int64
+	UserID         uuid.UUID
+	ServiceName    string
+	Price          Amount
+	Prices         PriceHistory
+	Currency       string
+	BillingPeriod  BillingPeriod
+	StartDate      time.Time
+	EndDate        *time.Time
+}
+
+// SubscriptionCost Стоимость одной подписки за период в валюте подписки.
+// Price - текущая цена подписки, Cycles - число списаний подписки
+// за период. Cost учитывает цену, действовавшую в дату каждого списания.
+type SubscriptionCost struct {
+	SubscriptionID int64         `json:"subscription_id"`
+	UserID         uuid.UUID     `json:"user_id"`
+	ServiceName    string        `json:"service_name"`
+	Price          Amount        `json:"price" swaggertype:"number"`
+	Currency       string        `json:"currency"`
+	BillingPeriod  BillingPeriod `json:"billing_period"`
+	Cycles         int           `json:"cycles"`
+	Cost           Amount        `json:"cost" swaggertype:"number"`
+
+	// reportCurrency и reportCost - валюта и стоимость подписки в отчете,
+	// отличаются от Currency и Cost при пересчете в другую валюту.
+	reportCurrency string
+	reportCost     Amount
+}
+
+// subscriptionCostFields Поля стоимости подписки без методов JSON.
+type subscriptionCostFields SubscriptionCost
+
+// MarshalJSON Запись стоимости подписки с суммами в валюте подписки.
+func (sc SubscriptionCost) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		*subscriptionCostFields
+		Price json.RawMessage `json:"price"`
+		Cost  json.RawMessage `json:"cost"`
+	}{
+		subscriptionCostFields: (*subscriptionCostFields)(&sc),
+		Price:                  json.RawMessage(sc.Price.Format(sc.Currency)),
+		Cost:                   json.RawMessage(sc.Cost.Format(sc.Currency)),
+	})
+}
+
+// MonthCost Стоимость всех списаний за один месяц периода по валютам.
+type MonthCost struct {
+	Month string `json:"month"`
+	Cost  Costs  `json:"cost" swaggertype:"object,number"`
+}
+
+// CostGroup Сумма подписок по валютам, сгруппированная по пользователю,
+// сервису или месяцу.
+type CostGroup struct {
+	Key  string `json:"key"`
+	Cost Costs  `json:"cost" swaggertype:"object,number"`
+}
+
+// CostReport Итог подсчета стоимости подписок за период [StartDate, EndDate],
+// оба дня включаются. Суммы считаются отдельно по валютам подписок,
+// а если задана Currency - пересчитываются в нее.
+type CostReport struct {
+	StartDate     time.Time
+	EndDate       time.Time
+	Currency      string
+	Total         Costs
+	Subscriptions []*SubscriptionCost
+	Months        []*MonthCost
+}
+
+// NewCostReport Подсчет стоимости подписок по списаниям.
+// Период [StartDate, EndDate] из filter включает оба дня.
+// Если начало периода не задано, то им считается начало самой ранней
+// подписки, если не задан конец - конец текущего месяца. Подписка
+// оплачивается в начале каждого своего периода оплаты, поэтому
+// в стоимость входят списания, даты которых попадают в период, по цене,
+// действовавшей в дату списания.
+// Если в filter задана валюта, цена каждого списания пересчитывается
+// в нее по rates. Возвращает ErrCostRange, если период длиннее
+// MaxCostMonths, ErrNoRate, если курса для валюты подписки нет,
+// и ErrAmountOverflow, если сумма не помещается в Amount.
+func NewCostReport(sources []*CostSource, filter *CostParams, rates RateTable) (*CostReport, error) {
+	to := monthStart(time.Now().UTC()).AddDate(0, 1, -1)
+	if filter.EndDate != nil {
+		to = *filter.EndDate
+	}
+
+	from := monthStart(to)
+	if filter.StartDate != nil {
+		from = *filter.StartDate
+	} else {
+		for _, src := range sources {
+			if src.StartDate.Before(from) {
+				from = src.StartDate
+			}
+		}
+	}
+
+	if err := CheckCostRange(from, to); err != nil {
+		return nil, err
+	}
+
+	report := CostReport{
+		StartDate:     from,
+		EndDate:       to,
+		Currency:      filter.Currency,
+		Total:         Costs{},
+		Subscriptions: make([]*SubscriptionCost, 0, len(sources)),
+		Months:        []*MonthCost{},
+	}
+
+	monthIdx := make(map[time.Time]*MonthCost)
+
+	for m := monthStart(from); !m.After(to); m = m.AddDate(0, 1, 0) {
+		mc := &MonthCost{Month: m.Format(MonthLayout), Cost: Costs{}}
+		monthIdx[m] = mc
+		report.Months = append(report.Months, mc)
+	}
+
+	for _, src := range sources {
+		sc := SubscriptionCost{
+			SubscriptionID: src.SubscriptionID,
+			UserID:         src.UserID,
+			ServiceName:    src.ServiceName,
+			Price:          src.Price,
+			Currency:       src.Currency,
+			BillingPeriod:  src.BillingPeriod,
+			reportCurrency: src.Currency,
+		}
+
+		if filter.Currency != "" {
+			sc.reportCurrency = filter.Currency
+		}
+
+		for n := src.BillingPeriod.FirstCycle(src.StartDate, from); ; n++ {
+			charge := src.BillingPeriod.Cycle(src.StartDate, n)
+			if charge.After(to) || (src.EndDate != nil && !charge.Before(*src.EndDate)) {
+				break
+			}
+
+			price := src.priceAt(charge)
+
+			reportPrice := price
+			if filter.Currency != "" {
+				var err error
+
+				if reportPrice, err = rates.Convert(price, src.Currency, filter.Currency); err != nil {
+					return nil, err
+				}
+			}
+
+			sc.Cycles++
+
+			if err := sc.addCharge(price, reportPrice, monthIdx[monthStart(charge)]); err != nil {
+				return nil, err
+			}
+		}
+
+		if sc.Cycles == 0 {
+			continue
+		}
+
+		if err := report.Total.add(sc.reportCurrency, sc.reportCost); err != nil {
+			return nil, err
+		}
+
+		report.Subscriptions = append(report.Subscriptions, &sc)
+	}
+
+	return &report, nil
+}
+
+// addCharge Учет списания в стоимости подписки и в месяце mc.
+func (sc *SubscriptionCost) addCharge(price, reportPrice Amount, mc *MonthCost) error {
+	var err error
+
+	if sc.Cost, err = sc.Cost.Add(price); err != nil {
+		return err
+	}
+
+	if sc.reportCost, err = sc.reportCost.Add(reportPrice); err != nil {
+		return err
+	}
+
+	return mc.Cost.add(sc.reportCurrency, reportPrice)
+}
+
+// priceAt Цена списания подписки в дату t.
+func (src *CostSource) priceAt(t time.Time) Amount {
+	if len(src.Prices) == 0 {
+		return src.Price
+	}
+
+	return src.Prices.At(t)
+}
+
+// Group Группировка итогов отчета по пользователю, сервису или месяцу.
+// Группы возвращаются в порядке первого появления ключа. Цены
+// положительные, поэтому сумма группы не больше итога в Total.
+func (r *CostReport) Group(groupBy string) []*CostGroup {
+	groups := []*CostGroup{}
+
+	if groupBy == GroupByMonth {
+		for _, mc := range r.Months {
+			groups = append(groups, &CostGroup{Key: mc.Month, Cost: maps.Clone(mc.Cost)})
+		}
+
+		return groups
+	}
+
+	groupIdx := make(map[string]*CostGroup)
+
+	for _, sc := range r.Subscriptions {
+		key := sc.ServiceName
+		if groupBy == GroupByUser {
+			key = sc.UserID.String()
+		}
+
+		group, ok := groupIdx[key]
+		if !ok {
+			group = &CostGroup{Key: key, Cost: Costs{}}
+			groupIdx[key] = group
+			groups = append(groups, group)
+		}
+
+		group.Cost[sc.reportCurrency] += sc.reportCost
+	}
+
+	return groups
+}
@@ -0,0 +1,195 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestNewCostReport(t *testing.T) {
+	date := func(year int, month time.Month, day int) *time.Time {
+		d := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+		return &d
+	}
+
+	userID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	source := func(id int64, price Amount, currency string, start, end *time.Time) *CostSource {
+		return &CostSource{
+			SubscriptionID: id,
+			UserID:         userID,
+			ServiceName:    "Netflix",
+			Price:          price,
+			Currency:       currency,
+			BillingPeriod:  DefaultBillingPeriod,
+			StartDate:      *start,
+			EndDate:        end,
+		}
+	}
+
+	rates, err := NewRateTable([]*Rate{{From: "USD", To: "RUB", Rate: "90"}})
+	if err != nil {
+		t.Fatalf("NewRateTable unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		sources    []*CostSource
+		filter     *CostParams
+		wantTotal  Costs
+		wantCycles []int
+		wantMonths int
+		wantErr    error
+	}{
+		{
+			name:       "monthly charges in period",
+			sources:    []*CostSource{source(1, 40000, "RUB", date(2025, 1, 1), nil)},
+			filter:     &CostParams{StartDate: date(2025, 1, 1), EndDate: date(2025, 3, 31)},
+			wantTotal:  Costs{"RUB": 120000},
+			wantCycles: []int{3},
+			wantMonths: 3,
+		},
+		{
+			name:       "end date is not charged",
+			sources:    []*CostSource{source(1, 40000, "RUB", date(2025, 1, 1), date(2025, 3, 1))},
+			filter:     &CostParams{StartDate: date(2025, 1, 1), EndDate: date(2025, 12, 31)},
+			wantTotal:  Costs{"RUB": 80000},
+			wantCycles: []int{2},
+			wantMonths: 12,
+		},
+		{
+			name:       "subscription outside period is skipped",
+			sources:    []*CostSource{source(1, 40000, "RUB", date(2026, 1, 1), nil)},
+			filter:     &CostParams{StartDate: date(2025, 1, 1), EndDate: date(2025, 12, 31)},
+			wantTotal:  Costs{},
+			wantCycles: []int{},
+			wantMonths: 12,
+		},
+		{
+			name: "price history",
+			sources: []*CostSource{func() *CostSource {
+				src := source(1, 50000, "RUB", date(2025, 1, 1), nil)
+				src.Prices = PriceHistory{
+					{EffectiveFrom: *date(2025, 1, 1), Price: 40000},
+					{EffectiveFrom: *date(2025, 3, 1), Price: 50000},
+				}
+
+				return src
+			}()},
+			filter:     &CostParams{StartDate: date(2025, 1, 1), EndDate: date(2025, 3, 31)},
+			wantTotal:  Costs{"RUB": 130000},
+			wantCycles: []int{3},
+			wantMonths: 3,
+		},
+		{
+			name: "currencies are summed separately",
+			sources: []*CostSource{
+				source(1, 40000, "RUB", date(2025, 1, 1), nil),
+				source(2, 999, "USD", date(2025, 1, 1), nil),
+			},
+			filter:     &CostParams{StartDate: date(2025, 1, 1), EndDate: date(2025, 1, 31)},
+			wantTotal:  Costs{"RUB": 40000, "USD": 999},
+			wantCycles: []int{1, 1},
+			wantMonths: 1,
+		},
+		{
+			name: "conversion to report currency",
+			sources: []*CostSource{
+				source(1, 40000, "RUB", date(2025, 1, 1), nil),
+				source(2, 1000, "USD", date(2025, 1, 1), nil),
+			},
+			filter:     &CostParams{StartDate: date(2025, 1, 1), EndDate: date(2025, 1, 31), Currency: "RUB"},
+			wantTotal:  Costs{"RUB": 130000},
+			wantCycles: []int{1, 1},
+			wantMonths: 1,
+		},
+		{
+			name: "daily subscription started long before period",
+			sources: []*CostSource{func() *CostSource {
+				src := source(1, 100, "RUB", date(1900, 1, 31), nil)
+				src.BillingPeriod = BillingPeriod{BillingDay, 1}
+
+				return src
+			}()},
+			filter:     &CostParams{StartDate: date(2025, 2, 1), EndDate: date(2025, 2, 28)},
+			wantTotal:  Costs{"RUB": 2800},
+			wantCycles: []int{28},
+			wantMonths: 1,
+		},
+		{
+			name:       "longest period",
+			sources:    []*CostSource{source(1, 100, "RUB", date(2016, 1, 1), nil)},
+			filter:     &CostParams{StartDate: date(2016, 1, 1), EndDate: date(2025, 12, 31)},
+			wantTotal:  Costs{"RUB": 12000},
+			wantCycles: []int{MaxCostMonths},
+			wantMonths: MaxCostMonths,
+		},
+		{
+			name:    "period is too long",
+			sources: []*CostSource{source(1, 100, "RUB", date(2016, 1, 1), nil)},
+			filter:  &CostParams{StartDate: date(2015, 12, 31), EndDate: date(2025, 12, 31)},
+			wantErr: ErrCostRange,
+		},
+		{
+			name:    "period from earliest subscription is too long",
+			sources: []*CostSource{source(1, 100, "RUB", date(1900, 1, 1), nil)},
+			filter:  &CostParams{EndDate: date(2025, 12, 31)},
+			wantErr: ErrCostRange,
+		},
+		{
+			name:    "no rate",
+			sources: []*CostSource{source(1, 1000, "EUR", date(2025, 1, 1), nil)},
+			filter:  &CostParams{StartDate: date(2025, 1, 1), EndDate: date(2025, 1, 31), Currency: "RUB"},
+			wantErr: ErrNoRate,
+		},
+		{
+			name:    "subscription cost overflow",
+			sources: []*CostSource{source(1, math.MaxInt64/2, "RUB", date(2025, 1, 1), nil)},
+			filter:  &CostParams{StartDate: date(2025, 1, 1), EndDate: date(2025, 3, 31)},
+			wantErr: ErrAmountOverflow,
+		},
+		{
+			name: "total overflow",
+			sources: []*CostSource{
+				source(1, math.MaxInt64/2+1, "RUB", date(2025, 1, 1), nil),
+				source(2, math.MaxInt64/2+1, "RUB", date(2025, 1, 1), nil),
+			},
+			filter:  &CostParams{StartDate: date(2025, 1, 1), EndDate: date(2025, 1, 31)},
+			wantErr: ErrAmountOverflow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := NewCostReport(tt.sources, tt.filter, rates)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewCostReport error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(report.Total, tt.wantTotal) {
+				t.Errorf("Total = %v, want %v", report.Total, tt.wantTotal)
+			}
+
+			cycles := make([]int, 0, len(report.Subscriptions))
+			for _, sc := range report.Subscriptions {
+				cycles = append(cycles, sc.Cycles)
+			}
+
+			if !reflect.DeepEqual(cycles, tt.wantCycles) {
+				t.Errorf("Cycles = %v, want %v", cycles, tt.wantCycles)
+			}
+
+			if len(report.Months) != tt.wantMonths {
+				t.Errorf("len(Months) = %d, want %d", len(report.Months), tt.wantMonths)
+			}
+		})
+	}
+}
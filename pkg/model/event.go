@@ -0,0 +1,71 @@
+package model
+
+import (
+	"time"
+)
+
+// Действия над подпиской в журнале изменений.
+const (
+	EventCreated  = "created"
+	EventUpdated  = "updated"
+	EventDeleted  = "deleted"
+	EventRestored = "restored"
+	EventPurged   = "purged"
+)
+
+// SubscriptionEvent Запись журнала изменений подписки.
+// Before пустой для созданной подписки, After - для удаленной навсегда.
+type SubscriptionEvent struct {
+	ID             int64         `json:"id"`
+	SubscriptionID int64         `json:"subscription_id"`
+	Action         string        `json:"action" example:"updated"`
+	Actor          string        `json:"actor" example:"anonymous"`
+	RequestID      string        `json:"request_id,omitempty" example:"host/abcdef-000001"`
+	Before         *Subscription `json:"before,omitempty"`
+	After          *Subscription `json:"after,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+// SetDefaults Заполнение полей снимков подписки, которых не было
+// в журнале до появления валют, периодов оплаты и каталога сервисов.
+// Валюта и период берутся по умолчанию, как для подписок при миграции,
+// сервис - текущий сервис подписки serviceID.
+func (e *SubscriptionEvent) SetDefaults(serviceID int64) {
+	for _, sub := range []*Subscription{e.Before, e.After} {
+		if sub == nil {
+			continue
+		}
+
+		if sub.Currency == "" {
+			sub.Currency = DefaultCurrency
+		}
+
+		if sub.BillingPeriod.Unit == "" {
+			sub.BillingPeriod = DefaultBillingPeriod
+		}
+
+		if sub.ServiceID == 0 {
+			sub.ServiceID = serviceID
+		}
+	}
+}
+
+// EventParams Структура для хендлеров журнала изменений
+// с фильтрующими данными. Все фильтры необязательные, границы
+// периода From/To включаются. События отдаются по возрастанию ID,
+// AfterID задает последнее событие предыдущей страницы.
+type EventParams struct {
+	SubscriptionID *int64
+	Actor          string
+	From           *time.Time
+	To             *time.Time
+	AfterID        int64
+	Limit          int
+}
+
+// EventPage Страница журнала изменений.
+// NextCursor пустой, если страница последняя.
+type EventPage struct {
+	Events     []*SubscriptionEvent
+	NextCursor string
+}
@@ -0,0 +1,178 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ограничения размера страницы для хендлера ListSubscription.
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+// SortFields Поля, по которым можно сортировать список подписок.
+var SortFields = map[string]bool{
+	"id":           true,
+	"service_name": true,
+	"price":        true,
+	"user_id":      true,
+	"start_date":   true,
+	"end_date":     true,
+}
+
+// OpenEndDate Значение end_date бессрочной подписки при сортировке.
+// Бессрочные подписки считаются заканчивающимися позже всех остальных.
+const OpenEndDate = "9999-12-31"
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// DeletedMode Режим выборки мягко удаленных подписок в списке.
+type DeletedMode int
+
+// Режимы выборки удаленных подписок. По умолчанию удаленные
+// подписки скрыты, DeletedOnly выбирает только корзину.
+const (
+	DeletedExclude DeletedMode = iota
+	DeletedInclude
+	DeletedOnly
+)
+
+// ListParams Структура для хендлера ListSubscription
+// с фильтрующими данными. Все фильтры необязательные.
+//
+// Примечание: ServiceName ищется по префиксу. ActiveFrom/ActiveTo
+// оставляют только подписки, действующие хотя бы в один из дней
+// периода. Границы диапазонов StartFrom/StartTo и EndFrom/EndTo
+// включаются.
+// MinPrice и MaxPrice сравниваются с ценой без учета валюты,
+// поэтому обычно задаются вместе с Currency.
+type ListParams struct {
+	UserID      *uuid.UUID
+	ServiceName string
+	Currency    string
+	MinPrice    *Amount
+	MaxPrice    *Amount
+	ActiveFrom  *time.Time
+	ActiveTo    *time.Time
+	StartFrom   *time.Time
+	StartTo     *time.Time
+	EndFrom     *time.Time
+	EndTo       *time.Time
+	Sort        string
+	Desc        bool
+	Cursor      *ListCursor
+	Limit       int
+	Deleted     DeletedMode
+}
+
+// SubscriptionPage Страница списка подписок.
+// NextCursor пустой, если страница последняя.
+type SubscriptionPage struct {
+	Subscriptions []*Subscription
+	NextCursor    string
+}
+
+// ListCursor Курсор для постраничной выборки списка подписок.
+// Хранит сортировку, значение поля сортировки и ID последней
+// подписки на странице.
+type ListCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int64  `json:"id"`
+}
+
+// NewListCursor Создание курсора по последней подписке страницы.
+func NewListCursor(filter *ListParams, sub *Subscription) *ListCursor {
+	cursor := ListCursor{Sort: filter.SortKey(), ID: sub.ID}
+
+	switch filter.Sort {
+	case "service_name":
+		cursor.Value = sub.ServiceName
+	case "price":
+		cursor.Value = strconv.FormatInt(int64(sub.Price), 10)
+	case "user_id":
+		cursor.Value = sub.UserID.String()
+	case "start_date":
+		cursor.Value = cursorDate(sub.StartDate)
+	case "end_date":
+		cursor.Value = OpenEndDate
+		if sub.EndDate != "" {
+			cursor.Value = cursorDate(sub.EndDate)
+		}
+	}
+
+	return &cursor
+}
+
+// Encode Преобразование курсора в строку для ответа пользователю.
+func (c *ListCursor) Encode() string {
+	data, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeListCursor Получение курсора из строки запроса.
+// Курсор должен соответствовать текущей сортировке.
+func DecodeListCursor(s string, filter *ListParams) (*ListCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor ListCursor
+
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if cursor.Sort != filter.SortKey() {
+		return nil, ErrInvalidCursor
+	}
+
+	if _, err := cursor.Arg(filter.Sort); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// Arg Значение курсора, приведенное к типу поля сортировки.
+func (c *ListCursor) Arg(sort string) (any, error) {
+	switch sort {
+	case "price":
+		return strconv.ParseInt(c.Value, 10, 64)
+	case "user_id":
+		return uuid.Parse(c.Value)
+	case "start_date", "end_date":
+		return time.Parse(time.DateOnly, c.Value)
+	case "service_name":
+		return c.Value, nil
+	default:
+		return c.ID, nil
+	}
+}
+
+// SortKey Строковое представление сортировки, например "-price".
+func (p *ListParams) SortKey() string {
+	if p.Desc {
+		return "-" + p.Sort
+	}
+
+	return p.Sort
+}
+
+// cursorDate Преобразование даты подписки к формату курсора.
+func cursorDate(date string) string {
+	t, err := ParseDate(date)
+	if err != nil {
+		return date
+	}
+
+	return t.Format(DateLayout)
+}
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestListCursorRoundTrip(t *testing.T) {
+	sub := &Subscription{
+		ID:          42,
+		ServiceName: "Netflix",
+		Price:       999,
+		UserID:      uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
+		StartDate:   "07-2025",
+		EndDate:     "12-2025",
+	}
+
+	tests := []struct {
+		name      string
+		filter    *ListParams
+		sub       *Subscription
+		wantValue string
+	}{
+		{name: "id", filter: &ListParams{Sort: "id"}, sub: sub, wantValue: ""},
+		{
+			name:      "service name desc",
+			filter:    &ListParams{Sort: "service_name", Desc: true},
+			sub:       sub,
+			wantValue: "Netflix",
+		},
+		{name: "price", filter: &ListParams{Sort: "price"}, sub: sub, wantValue: "999"},
+		{name: "user", filter: &ListParams{Sort: "user_id"}, sub: sub, wantValue: sub.UserID.String()},
+		{name: "start date", filter: &ListParams{Sort: "start_date"}, sub: sub, wantValue: "2025-07-01"},
+		{name: "end date", filter: &ListParams{Sort: "end_date"}, sub: sub, wantValue: "2025-12-01"},
+		{
+			name:      "open end date",
+			filter:    &ListParams{Sort: "end_date"},
+			sub:       &Subscription{ID: 7, StartDate: "07-2025"},
+			wantValue: OpenEndDate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := NewListCursor(tt.filter, tt.sub).Encode()
+
+			got, err := DecodeListCursor(encoded, tt.filter)
+			if err != nil {
+				t.Fatalf("DecodeListCursor unexpected error: %v", err)
+			}
+
+			if got.ID != tt.sub.ID || got.Value != tt.wantValue || got.Sort != tt.filter.SortKey() {
+				t.Errorf("cursor = %+v, want ID %d, value %q, sort %q",
+					got, tt.sub.ID, tt.wantValue, tt.filter.SortKey())
+			}
+		})
+	}
+}
+
+func TestDecodeListCursorInvalid(t *testing.T) {
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+		filter *ListParams
+	}{
+		{name: "not base64", cursor: "!!!", filter: &ListParams{Sort: "id"}},
+		{name: "not JSON", cursor: encode("cursor"), filter: &ListParams{Sort: "id"}},
+		{name: "other sort field", cursor: encode(`{"s":"price","v":"999","id":1}`), filter: &ListParams{Sort: "id"}},
+		{
+			name:   "other direction",
+			cursor: encode(`{"s":"price","v":"999","id":1}`),
+			filter: &ListParams{Sort: "price", Desc: true},
+		},
+		{name: "bad price", cursor: encode(`{"s":"price","v":"9.99","id":1}`), filter: &ListParams{Sort: "price"}},
+		{name: "bad user", cursor: encode(`{"s":"user_id","v":"me","id":1}`), filter: &ListParams{Sort: "user_id"}},
+		{
+			name:   "bad date",
+			cursor: encode(`{"s":"start_date","v":"07-2025","id":1}`),
+			filter: &ListParams{Sort: "start_date"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeListCursor(tt.cursor, tt.filter); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeListCursor error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
@@ -3,9 +3,12 @@
 package model
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,61 +20,192 @@ import (
 // time.Time, но так как в запросе они передаются строкой, то
 // решено было оставить их также строкой и преобразовывать в нужный
 // вид по мере необходимости.
+// ID, CreatedAt, UpdatedAt, Version и DeletedAt заполняются базой
+// данных и игнорируются в теле запроса. Version увеличивается при каждом
+// обновлении и отдается пользователю в заголовке ETag. DeletedAt задан
+// только у мягко удаленных подписок.
+// Price хранится в минимальных единицах Currency и списывается
+// в начале каждого периода BillingPeriod. Если Currency или BillingPeriod
+// не переданы в теле запроса, используются DefaultCurrency
+// и DefaultBillingPeriod.
+// StartDate и EndDate принимаются в формате MM-YYYY (первое число
+// месяца) или YYYY-MM-DD, подробнее в ParseDate и FormatDate.
+// ServiceID заполняется базой данных по ServiceName: сервис ищется
+// в каталоге по названию и синонимам, а если его нет - создается.
+// ServiceName заменяется каноническим названием сервиса.
 type Subscription struct {
-	ServiceName string    `json:"service_name"`
-	Price       int       `json:"price"`
-	UserID      uuid.UUID `json:"user_id"`
-	StartDate   string    `json:"start_date"`
-	EndDate     string    `json:"end_date,omitempty"`
+	ID            int64         `json:"id" readonly:"true"`
+	ServiceID     int64         `json:"service_id" readonly:"true"`
+	ServiceName   string        `json:"service_name"`
+	Price         Amount        `json:"price" swaggertype:"number" example:"9.99"`
+	Currency      string        `json:"currency" example:"RUB"`
+	BillingPeriod BillingPeriod `json:"billing_period"`
+	UserID        uuid.UUID     `json:"user_id"`
+	StartDate     string        `json:"start_date"`
+	EndDate       string        `json:"end_date,omitempty"`
+	CreatedAt     time.Time     `json:"created_at" readonly:"true"`
+	UpdatedAt     time.Time     `json:"updated_at" readonly:"true"`
+	Version       int64         `json:"version" readonly:"true"`
+	DeletedAt     *time.Time    `json:"deleted_at,omitempty" readonly:"true"`
+}
+
+// subscriptionFields Поля подписки без методов JSON.
+type subscriptionFields Subscription
+
+// subscriptionJSON Подписка в JSON. Цена записывается с числом знаков
+// валюты подписки, поэтому читается после остальных полей.
+type subscriptionJSON struct {
+	*subscriptionFields
+	Price json.RawMessage `json:"price"`
+}
+
+// MarshalJSON Запись подписки с ценой в валюте подписки.
+func (s Subscription) MarshalJSON() ([]byte, error) {
+	return json.Marshal(subscriptionJSON{
+		subscriptionFields: (*subscriptionFields)(&s),
+		Price:              json.RawMessage(s.Price.Format(s.Currency)),
+	})
+}
+
+// UnmarshalJSON Чтение подписки. Неизвестные поля запрещены, цена
+// читается в валюте подписки, без валюты - в DefaultCurrency.
+// Ошибка цены возвращается с ErrInvalidAmount.
+func (s *Subscription) UnmarshalJSON(data []byte) error {
+	w := subscriptionJSON{subscriptionFields: (*subscriptionFields)(s)}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&w); err != nil {
+		return err
+	}
+
+	if isJSONNull(w.Price) {
+		return nil
+	}
+
+	price, err := decodeAmount(w.Price, currencyOrDefault(s.Currency))
+	if err != nil {
+		return err
+	}
+
+	s.Price = price
+
+	return nil
+}
+
+// currencyOrDefault Валюта code или DefaultCurrency, если она пустая.
+func currencyOrDefault(code string) string {
+	if code == "" {
+		return DefaultCurrency
+	}
+
+	return code
+}
+
+// isJSONNull Проверка, что значение не передано или равно null.
+func isJSONNull(data json.RawMessage) bool {
+	data = bytes.TrimSpace(data)
+
+	return len(data) == 0 || bytes.Equal(data, []byte("null"))
+}
+
+// Replace Замена всех изменяемых полей подписки полями src.
+// ID, время создания и версия не меняются.
+func (s *Subscription) Replace(src *Subscription) {
+	s.ServiceName = src.ServiceName
+	s.Price = src.Price
+	s.Currency = src.Currency
+	s.BillingPeriod = src.BillingPeriod
+	s.UserID = src.UserID
+	s.StartDate = src.StartDate
+	s.EndDate = src.EndDate
+}
+
+// ETag Значение заголовка ETag для версии подписки, например "3".
+func ETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
 }
 
 // GetSubFromBody Получения тела запроса и маршал в Subscription.
+// Размер тела ограничен MaxBodySize, неизвестные поля запрещены.
+// Ошибки типов и неизвестные поля возвращаются как *ValidationError.
 func GetSubFromBody(r *http.Request) (*Subscription, error) {
+	return decodeSubscription(http.MaxBytesReader(nil, r.Body, MaxBodySize))
+}
+
+// GetSubFromJSON Получение Subscription из одного JSON-объекта,
+// например строки JSON Lines. Ошибки такие же, как у GetSubFromBody.
+func GetSubFromJSON(data []byte) (*Subscription, error) {
+	return decodeSubscription(bytes.NewReader(data))
+}
+
+// decodeSubscription Чтение одного JSON-объекта подписки.
+func decodeSubscription(r io.Reader) (*Subscription, error) {
 	sub := Subscription{}
 
-	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
-		return nil, fmt.Errorf("bad user body: %w", err)
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&sub); err != nil {
+		return nil, decodeError(err)
 	}
 
+	if dec.More() {
+		return nil, fmt.Errorf("%w: unexpected data after JSON object", ErrBadBody)
+	}
+
+	sub.SetDefaults()
+
 	return &sub, nil
 }
 
-// IsValidSubscription Валидация структуры Subscription.
-func IsValidSubscription(sub *Subscription) bool {
-	if sub.ServiceName == "" || sub.Price <= 0 {
-		return false
-	}
+// SetDefaults Нормализация названия сервиса и заполнение непереданных
+// валюты и периода оплаты значениями по умолчанию.
+func (s *Subscription) SetDefaults() {
+	s.ServiceName = NormalizeServiceName(s.ServiceName)
 
-	if uuid.Validate(sub.UserID.String()) != nil {
-		return false
+	if s.Currency == "" {
+		s.Currency = DefaultCurrency
 	}
 
-	if sub.EndDate != "" {
-		layout := "01-2006"
+	if s.BillingPeriod.Unit == "" {
+		s.BillingPeriod.Unit = DefaultBillingPeriod.Unit
+	}
 
-		startDate, err := time.Parse(layout, sub.StartDate)
-		if err != nil {
-			return false
-		}
+	if s.BillingPeriod.Count == 0 {
+		s.BillingPeriod.Count = DefaultBillingPeriod.Count
+	}
+}
 
-		endDate, err := time.Parse(layout, sub.EndDate)
-		if err != nil {
-			return false
-		}
+// Форматы импорта и экспорта подписок.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
 
-		if !endDate.After(startDate) {
-			return false
-		}
-	}
+// MaxImportSize Ограничение размера файла импорта.
+const MaxImportSize = 64 << 20
 
-	return true
-}
+// Значения группировки для хендлера CostSubscription.
+const (
+	GroupByUser    = "user"
+	GroupByService = "service"
+	GroupByMonth   = "month"
+)
 
 // CostParams Структура для хендлера CostSubscription
-// с фильтрующими данными.
+// с фильтрующими данными. Все фильтры необязательные:
+// пустой UserID или ServiceNames означает всех пользователей
+// или все сервисы, пустой StartDate - с начала первой подписки.
+// Удаленные подписки учитываются только с IncludeDeleted.
+// Пустая Currency означает суммы по валютам подписок без пересчета.
 type CostParams struct {
-	ServiceName string     `json:"service_name"`
-	UserID      uuid.UUID  `json:"user_id"`
-	StartDate   time.Time  `json:"start_date"`
-	EndDate     *time.Time `json:"end_date"`
+	ServiceNames   []string   `json:"service_names"`
+	UserID         *uuid.UUID `json:"user_id"`
+	StartDate      *time.Time `json:"start_date"`
+	EndDate        *time.Time `json:"end_date"`
+	GroupBy        string     `json:"group_by"`
+	Currency       string     `json:"currency"`
+	IncludeDeleted bool       `json:"include_deleted"`
 }
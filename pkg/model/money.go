@@ -0,0 +1,340 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCurrency Валюта подписки, если она не указана в запросе.
+// Все подписки, созданные до появления валют, считаются в рублях.
+const DefaultCurrency = "RUB"
+
+// defaultMinorDigits Число знаков минимальной единицы неизвестной
+// валюты, чтобы прочитать сумму до проверки кода валюты.
+const defaultMinorDigits = 2
+
+// maxAmountDigits Максимальное число цифр суммы в минимальных единицах.
+const maxAmountDigits = 17
+
+// Ограничения курса валюты, совпадают с колонкой NUMERIC(20, 10).
+const (
+	MaxRateDigits = 10
+	maxRate       = 1e10
+)
+
+// Currencies Поддерживаемые валюты по ISO 4217 с числом знаков
+// минимальной единицы: 2 у RUB и USD, 0 у JPY, 3 у KWD.
+// Расчетные единицы и драгоценные металлы без минимальной единицы
+// в список не входят.
+var Currencies = map[string]int{
+	"AED": 2, "AFN": 2, "ALL": 2, "AMD": 2, "AOA": 2, "ARS": 2, "AUD": 2, "AWG": 2,
+	"AZN": 2, "BAM": 2, "BBD": 2, "BDT": 2, "BGN": 2, "BHD": 3, "BIF": 0, "BMD": 2,
+	"BND": 2, "BOB": 2, "BOV": 2, "BRL": 2, "BSD": 2, "BTN": 2, "BWP": 2, "BYN": 2,
+	"BZD": 2, "CAD": 2, "CDF": 2, "CHE": 2, "CHF": 2, "CHW": 2, "CLF": 4, "CLP": 0,
+	"CNY": 2, "COP": 2, "COU": 2, "CRC": 2, "CUP": 2, "CVE": 2, "CZK": 2, "DJF": 0,
+	"DKK": 2, "DOP": 2, "DZD": 2, "EGP": 2, "ERN": 2, "ETB": 2, "EUR": 2, "FJD": 2,
+	"FKP": 2, "GBP": 2, "GEL": 2, "GHS": 2, "GIP": 2, "GMD": 2, "GNF": 0, "GTQ": 2,
+	"GYD": 2, "HKD": 2, "HNL": 2, "HTG": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2,
+	"IQD": 3, "IRR": 2, "ISK": 0, "JMD": 2, "JOD": 3, "JPY": 0, "KES": 2, "KGS": 2,
+	"KHR": 2, "KMF": 0, "KPW": 2, "KRW": 0, "KWD": 3, "KYD": 2, "KZT": 2, "LAK": 2,
+	"LBP": 2, "LKR": 2, "LRD": 2, "LSL": 2, "LYD": 3, "MAD": 2, "MDL": 2, "MGA": 2,
+	"MKD": 2, "MMK": 2, "MNT": 2, "MOP": 2, "MRU": 2, "MUR": 2, "MVR": 2, "MWK": 2,
+	"MXN": 2, "MXV": 2, "MYR": 2, "MZN": 2, "NAD": 2, "NGN": 2, "NIO": 2, "NOK": 2,
+	"NPR": 2, "NZD": 2, "OMR": 3, "PAB": 2, "PEN": 2, "PGK": 2, "PHP": 2, "PKR": 2,
+	"PLN": 2, "PYG": 0, "QAR": 2, "RON": 2, "RSD": 2, "RUB": 2, "RWF": 0, "SAR": 2,
+	"SBD": 2, "SCR": 2, "SDG": 2, "SEK": 2, "SGD": 2, "SHP": 2, "SLE": 2, "SOS": 2,
+	"SRD": 2, "SSP": 2, "STN": 2, "SVC": 2, "SYP": 2, "SZL": 2, "THB": 2, "TJS": 2,
+	"TMT": 2, "TND": 3, "TOP": 2, "TRY": 2, "TTD": 2, "TWD": 2, "TZS": 2, "UAH": 2,
+	"UGX": 0, "USD": 2, "USN": 2, "UYI": 0, "UYU": 2, "UYW": 4, "UZS": 2, "VED": 2,
+	"VES": 2, "VND": 0, "VUV": 0, "WST": 2, "XAF": 0, "XCD": 2, "XCG": 2, "XOF": 0,
+	"XPF": 0, "YER": 2, "ZAR": 2, "ZMW": 2, "ZWG": 2,
+}
+
+var (
+	ErrInvalidAmount  = errors.New("must be a number")
+	ErrAmountOverflow = errors.New("amount is out of range")
+	ErrNoRate         = errors.New("no exchange rate for currency")
+)
+
+// IsCurrency Проверка, что code - поддерживаемый код валюты.
+func IsCurrency(code string) bool {
+	_, ok := Currencies[code]
+
+	return ok
+}
+
+// MinorDigits Число знаков минимальной единицы валюты code.
+// Для неизвестной валюты - defaultMinorDigits.
+func MinorDigits(code string) int {
+	if digits, ok := Currencies[code]; ok {
+		return digits
+	}
+
+	return defaultMinorDigits
+}
+
+// Amount Денежная сумма в минимальных единицах валюты (копейках,
+// центах, иенах). В JSON передается десятичным числом с не более чем
+// MinorDigits знаками валюты после точки, например 9.99 RUB или
+// 1200 JPY, поэтому читается и записывается вместе с валютой.
+type Amount int64
+
+// ParseAmount Получение суммы в валюте currency из десятичной строки,
+// например "9.99". Экспонента и больше MinorDigits(currency) знаков
+// после точки не допускаются.
+func ParseAmount(s, currency string) (Amount, error) {
+	digits := MinorDigits(currency)
+	sign := int64(1)
+
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" || len(frac) > digits || len(whole)+digits > maxAmountDigits {
+		return 0, amountError(digits)
+	}
+
+	frac += strings.Repeat("0", digits-len(frac))
+
+	minor, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil || strings.ContainsAny(whole+frac, "+-") {
+		return 0, amountError(digits)
+	}
+
+	return Amount(sign * minor), nil
+}
+
+// amountError Ошибка формата суммы с числом знаков валюты.
+func amountError(digits int) error {
+	if digits == 0 {
+		return fmt.Errorf("%w without decimal places", ErrInvalidAmount)
+	}
+
+	return fmt.Errorf("%w with at most %d decimal places", ErrInvalidAmount, digits)
+}
+
+// decodeAmount Чтение суммы в валюте currency из JSON-числа.
+// Строки и другие значения возвращаются как ErrInvalidAmount.
+func decodeAmount(data json.RawMessage, currency string) (Amount, error) {
+	var num json.Number
+	if err := json.Unmarshal(data, &num); err != nil || data[0] == '"' {
+		return 0, amountError(MinorDigits(currency))
+	}
+
+	return ParseAmount(num.String(), currency)
+}
+
+// Format Десятичная запись суммы в валюте currency без лишних нулей,
+// например 9.9 или 400.
+func (a Amount) Format(currency string) string {
+	sign := ""
+	if a < 0 {
+		sign, a = "-", -a
+	}
+
+	digits := MinorDigits(currency)
+	scale := minorScale(digits).Int64()
+
+	whole := strconv.FormatInt(int64(a)/scale, 10)
+	if digits == 0 {
+		return sign + whole
+	}
+
+	frac := strings.TrimRight(fmt.Sprintf("%0*d", digits, int64(a)%scale), "0")
+	if frac == "" {
+		return sign + whole
+	}
+
+	return sign + whole + "." + frac
+}
+
+// Add Сложение сумм одной валюты. Возвращает ErrAmountOverflow,
+// если сумма не помещается в Amount.
+func (a Amount) Add(b Amount) (Amount, error) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, ErrAmountOverflow
+	}
+
+	return sum, nil
+}
+
+// minorScale 10 в степени digits - число минимальных единиц в единице
+// валюты.
+func minorScale(digits int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+}
+
+// Costs Суммы по валютам. В JSON передается объектом
+// с кодами валют в ключах, например {"RUB": 1200, "USD": 9.99}.
+type Costs map[string]Amount
+
+// MarshalJSON Запись сумм с числом знаков валюты каждой суммы.
+func (c Costs) MarshalJSON() ([]byte, error) {
+	if c == nil {
+		return []byte("null"), nil
+	}
+
+	out := make(map[string]json.Number, len(c))
+	for code, amount := range c {
+		out[code] = json.Number(amount.Format(code))
+	}
+
+	return json.Marshal(out)
+}
+
+// add Прибавление суммы amount в валюте code. Возвращает
+// ErrAmountOverflow, если сумма не помещается в Amount.
+func (c Costs) add(code string, amount Amount) error {
+	sum, err := c[code].Add(amount)
+	if err != nil {
+		return err
+	}
+
+	c[code] = sum
+
+	return nil
+}
+
+// Rate Курс обмена валюты From на валюту To: 1 From = Rate To.
+// Обратный курс считается автоматически, если прямой не задан.
+type Rate struct {
+	From      string      `json:"from" example:"USD"`
+	To        string      `json:"to" example:"RUB"`
+	Rate      json.Number `json:"rate" swaggertype:"number" example:"92.5"`
+	UpdatedAt time.Time   `json:"updated_at" readonly:"true"`
+}
+
+// RateBody Тело запроса установки курса.
+type RateBody struct {
+	Rate json.Number `json:"rate" swaggertype:"number" example:"92.5"`
+}
+
+// GetRateFromBody Получение тела запроса установки курса.
+// Курс проверяется через ParseRate.
+func GetRateFromBody(r *http.Request) (*big.Rat, error) {
+	body := RateBody{}
+
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, MaxBodySize))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&body); err != nil {
+		return nil, decodeError(err)
+	}
+
+	if dec.More() {
+		return nil, fmt.Errorf("%w: unexpected data after JSON object", ErrBadBody)
+	}
+
+	return ParseRate(body.Rate.String())
+}
+
+// ParseRate Получение курса из десятичной строки. Курс должен быть
+// положительным и иметь не больше MaxRateDigits знаков после точки.
+// Возвращает *ValidationError.
+func ParseRate(s string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(s)
+
+	switch {
+	case s == "" || !ok:
+		return nil, NewValidationError("rate", RuleRequired, "must be a number")
+	case rate.Sign() <= 0:
+		return nil, NewValidationError("rate", RulePositive, "must be positive")
+	case rate.Cmp(new(big.Rat).SetFloat64(maxRate)) >= 0:
+		return nil, NewValidationError("rate", RuleFormat, "must be less than 10000000000")
+	}
+
+	scaled := new(big.Rat).Mul(rate, new(big.Rat).SetInt(rateScale()))
+	if !scaled.IsInt() {
+		return nil, NewValidationError(
+			"rate",
+			RuleFormat,
+			fmt.Sprintf("must have at most %d decimal places", MaxRateDigits),
+		)
+	}
+
+	return rate, nil
+}
+
+// FormatRate Десятичная запись курса без лишних нулей.
+func FormatRate(rate *big.Rat) json.Number {
+	s := rate.FloatString(MaxRateDigits)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+
+	return json.Number(s)
+}
+
+// rateScale 10 в степени MaxRateDigits.
+func rateScale() *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(MaxRateDigits), nil)
+}
+
+// RateTable Таблица курсов для пересчета сумм.
+type RateTable map[[2]string]*big.Rat
+
+// NewRateTable Создание таблицы курсов.
+func NewRateTable(rates []*Rate) (RateTable, error) {
+	table := make(RateTable, len(rates))
+
+	for _, rate := range rates {
+		value, ok := new(big.Rat).SetString(rate.Rate.String())
+		if !ok || value.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid rate %s/%s: %s", rate.From, rate.To, rate.Rate)
+		}
+
+		table[[2]string{rate.From, rate.To}] = value
+	}
+
+	return table, nil
+}
+
+// Convert Пересчет суммы из валюты from в валюту to с округлением
+// до минимальной единицы валюты to. Если прямого курса нет,
+// используется обратный. Возвращает ErrNoRate, если нет ни того,
+// ни другого, и ErrAmountOverflow, если сумма не помещается в Amount.
+func (t RateTable) Convert(amount Amount, from, to string) (Amount, error) {
+	if from == to {
+		return amount, nil
+	}
+
+	rate, ok := t[[2]string{from, to}]
+	if !ok {
+		inverse, ok := t[[2]string{to, from}]
+		if !ok {
+			return 0, fmt.Errorf("%w: %s to %s", ErrNoRate, from, to)
+		}
+
+		rate = new(big.Rat).Inv(inverse)
+	}
+
+	scale := new(big.Rat).SetFrac(minorScale(MinorDigits(to)), minorScale(MinorDigits(from)))
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(int64(amount)), rate)
+
+	return roundAmount(converted.Mul(converted, scale))
+}
+
+// roundAmount Округление до целого числа минимальных единиц,
+// половина округляется от нуля.
+func roundAmount(x *big.Rat) (Amount, error) {
+	q, m := new(big.Int).QuoRem(x.Num(), x.Denom(), new(big.Int))
+
+	if new(big.Int).Mul(new(big.Int).Abs(m), big.NewInt(2)).Cmp(x.Denom()) >= 0 {
+		q.Add(q, big.NewInt(int64(x.Sign())))
+	}
+
+	if !q.IsInt64() {
+		return 0, ErrAmountOverflow
+	}
+
+	return Amount(q.Int64()), nil
+}
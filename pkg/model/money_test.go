@@ -0,0 +1,147 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		currency string
+		want     Amount
+		wantErr  bool
+	}{
+		{name: "whole rubles", input: "400", currency: "RUB", want: 40000},
+		{name: "kopecks", input: "9.99", currency: "RUB", want: 999},
+		{name: "one decimal place", input: "9.9", currency: "RUB", want: 990},
+		{name: "plus sign", input: "+1.5", currency: "USD", want: 150},
+		{name: "minus sign", input: "-1.5", currency: "USD", want: -150},
+		{name: "too many decimal places", input: "9.999", currency: "RUB", wantErr: true},
+		{name: "yen without decimals", input: "1200", currency: "JPY", want: 1200},
+		{name: "yen with decimals", input: "12.5", currency: "JPY", wantErr: true},
+		{name: "dinar fils", input: "1.275", currency: "KWD", want: 1275},
+		{name: "dinar four decimal places", input: "1.2755", currency: "KWD", wantErr: true},
+		{name: "unknown currency uses two digits", input: "1.25", currency: "XXX", want: 125},
+		{name: "empty", input: "", currency: "RUB", wantErr: true},
+		{name: "no whole part", input: ".5", currency: "RUB", wantErr: true},
+		{name: "exponent", input: "1e3", currency: "RUB", wantErr: true},
+		{name: "double sign", input: "--1", currency: "RUB", wantErr: true},
+		{name: "letters", input: "abc", currency: "RUB", wantErr: true},
+		{name: "too many digits", input: "1000000000000000", currency: "RUB", wantErr: true},
+		{name: "max digits", input: "999999999999999", currency: "RUB", want: 99999999999999900},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAmount(tt.input, tt.currency)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAmount) {
+					t.Fatalf("ParseAmount(%q, %q) error = %v, want ErrInvalidAmount", tt.input, tt.currency, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseAmount(%q, %q) unexpected error: %v", tt.input, tt.currency, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("ParseAmount(%q, %q) = %d, want %d", tt.input, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmountFormat(t *testing.T) {
+	tests := []struct {
+		amount   Amount
+		currency string
+		want     string
+	}{
+		{amount: 40000, currency: "RUB", want: "400"},
+		{amount: 999, currency: "RUB", want: "9.99"},
+		{amount: 990, currency: "RUB", want: "9.9"},
+		{amount: 5, currency: "RUB", want: "0.05"},
+		{amount: -150, currency: "USD", want: "-1.5"},
+		{amount: 1200, currency: "JPY", want: "1200"},
+		{amount: 1275, currency: "KWD", want: "1.275"},
+		{amount: 2400, currency: "KWD", want: "2.4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want+" "+tt.currency, func(t *testing.T) {
+			if got := tt.amount.Format(tt.currency); got != tt.want {
+				t.Errorf("Amount(%d).Format(%q) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmountAdd(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    Amount
+		want    Amount
+		wantErr error
+	}{
+		{name: "positive", a: 100, b: 250, want: 350},
+		{name: "negative", a: 100, b: -250, want: -150},
+		{name: "overflow", a: math.MaxInt64, b: 1, wantErr: ErrAmountOverflow},
+		{name: "underflow", a: math.MinInt64, b: -1, wantErr: ErrAmountOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Add(tt.b)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Add error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("%d + %d = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateTableConvert(t *testing.T) {
+	rates, err := NewRateTable([]*Rate{
+		{From: "USD", To: "RUB", Rate: "90.5"},
+		{From: "JPY", To: "KWD", Rate: "0.002"},
+	})
+	if err != nil {
+		t.Fatalf("NewRateTable unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		amount   Amount
+		from, to string
+		want     Amount
+		wantErr  error
+	}{
+		{name: "same currency", amount: 999, from: "RUB", to: "RUB", want: 999},
+		{name: "direct rate", amount: 1000, from: "USD", to: "RUB", want: 90500},
+		{name: "inverse rate rounds half away from zero", amount: 905, from: "RUB", to: "USD", want: 10},
+		{name: "different minor units", amount: 1200, from: "JPY", to: "KWD", want: 2400},
+		{name: "no rate", amount: 100, from: "EUR", to: "RUB", wantErr: ErrNoRate},
+		{name: "overflow", amount: math.MaxInt64, from: "USD", to: "RUB", wantErr: ErrAmountOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rates.Convert(tt.amount, tt.from, tt.to)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Convert error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Convert(%d, %s, %s) = %d, want %d", tt.amount, tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// SubscriptionPatch Тело запроса PATCH в формате JSON Merge Patch
+// (RFC 7396). Отсутствующее поле не меняется, null сбрасывает поле
+// к пустому значению.
+type SubscriptionPatch map[string]json.RawMessage
+
+// GetPatchFromBody Получение тела запроса PATCH.
+// Размер тела ограничен MaxBodySize.
+func GetPatchFromBody(r *http.Request) (SubscriptionPatch, error) {
+	patch := SubscriptionPatch{}
+
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, MaxBodySize))
+
+	if err := dec.Decode(&patch); err != nil {
+		return nil, decodeError(err)
+	}
+
+	if dec.More() {
+		return nil, fmt.Errorf("%w: unexpected data after JSON object", ErrBadBody)
+	}
+
+	return patch, nil
+}
+
+// Apply Применение патча к подписке. Поля, которые заполняет база
+// данных, игнорируются. Цена применяется последней, так как читается
+// в валюте подписки после патча. Неизвестные поля и ошибки типов
+// возвращаются как *ValidationError, подписка при этом может быть
+// изменена частично.
+func (p SubscriptionPatch) Apply(sub *Subscription) error {
+	targets := map[string]any{
+		"service_name":   &sub.ServiceName,
+		"currency":       &sub.Currency,
+		"billing_period": &sub.BillingPeriod,
+		"user_id":        &sub.UserID,
+		"start_date":     &sub.StartDate,
+		"end_date":       &sub.EndDate,
+	}
+	readOnly := map[string]bool{
+		"id": true, "service_id": true, "created_at": true, "updated_at": true, "version": true,
+		"deleted_at": true,
+	}
+
+	var violations []Violation
+
+	keys := make([]string, 0, len(p))
+	for key := range p {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		target, ok := targets[key]
+		if !ok {
+			if key != "price" && !readOnly[key] {
+				violations = append(violations, Violation{key, RuleUnknownField, "unknown field"})
+			}
+
+			continue
+		}
+
+		if bytes.Equal(bytes.TrimSpace(p[key]), []byte("null")) {
+			resetField(target)
+
+			continue
+		}
+
+		if err := json.Unmarshal(p[key], target); err != nil {
+			violations = append(violations, patchViolation(key, err))
+		}
+	}
+
+	if raw, ok := p["price"]; ok {
+		if err := applyPrice(sub, raw); err != nil {
+			violations = append(violations, patchViolation("price", err))
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+
+	return nil
+}
+
+// applyPrice Применение цены из патча в валюте подписки.
+// null сбрасывает цену.
+func applyPrice(sub *Subscription, raw json.RawMessage) error {
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		sub.Price = 0
+
+		return nil
+	}
+
+	price, err := decodeAmount(raw, currencyOrDefault(sub.Currency))
+	if err != nil {
+		return err
+	}
+
+	sub.Price = price
+
+	return nil
+}
+
+// patchViolation Нарушение для поля патча, которое не удалось прочитать.
+func patchViolation(key string, err error) Violation {
+	if errors.Is(err, ErrInvalidAmount) {
+		return Violation{key, RuleFormat, err.Error()}
+	}
+
+	return Violation{key, RuleType, "invalid value: " + err.Error()}
+}
+
+// resetField Сброс поля подписки к пустому значению.
+func resetField(target any) {
+	switch t := target.(type) {
+	case *string:
+		*t = ""
+	case *BillingPeriod:
+		*t = DefaultBillingPeriod
+	case *uuid.UUID:
+		*t = uuid.Nil
+	}
+}
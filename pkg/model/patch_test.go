@@ -0,0 +1,137 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSubscriptionPatchApply(t *testing.T) {
+	userID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	base := Subscription{
+		ID:            1,
+		ServiceName:   "Netflix",
+		Price:         40000,
+		Currency:      "RUB",
+		BillingPeriod: DefaultBillingPeriod,
+		UserID:        userID,
+		StartDate:     "07-2025",
+		EndDate:       "12-2025",
+		Version:       3,
+	}
+
+	tests := []struct {
+		name       string
+		patch      string
+		want       func(sub *Subscription)
+		wantFields []string
+	}{
+		{
+			name:  "empty patch",
+			patch: `{}`,
+			want:  func(*Subscription) {},
+		},
+		{
+			name:  "fields",
+			patch: `{"service_name":"Yandex Plus","start_date":"08-2025","billing_period":{"unit":"year","count":1}}`,
+			want: func(sub *Subscription) {
+				sub.ServiceName = "Yandex Plus"
+				sub.StartDate = "08-2025"
+				sub.BillingPeriod = BillingPeriod{BillingYear, 1}
+			},
+		},
+		{
+			name:  "null resets field",
+			patch: `{"end_date":null,"billing_period":null}`,
+			want: func(sub *Subscription) {
+				sub.EndDate = ""
+				sub.BillingPeriod = DefaultBillingPeriod
+			},
+		},
+		{
+			name:  "price in subscription currency",
+			patch: `{"price":9.99}`,
+			want:  func(sub *Subscription) { sub.Price = 999 },
+		},
+		{
+			name:  "price in new currency",
+			patch: `{"price":1200,"currency":"JPY"}`,
+			want: func(sub *Subscription) {
+				sub.Price = 1200
+				sub.Currency = "JPY"
+			},
+		},
+		{
+			name:  "read-only fields are ignored",
+			patch: `{"id":5,"version":10,"created_at":"2025-01-01T00:00:00Z","deleted_at":null}`,
+			want:  func(*Subscription) {},
+		},
+		{
+			name:       "unknown field",
+			patch:      `{"service":"Netflix"}`,
+			wantFields: []string{"service"},
+		},
+		{
+			name:       "wrong types",
+			patch:      `{"service_name":1,"user_id":"me"}`,
+			wantFields: []string{"service_name", "user_id"},
+		},
+		{
+			name:       "too precise price for new currency",
+			patch:      `{"price":12.5,"currency":"JPY"}`,
+			wantFields: []string{"price"},
+		},
+		{
+			name:       "price as string",
+			patch:      `{"price":"9.99"}`,
+			wantFields: []string{"price"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch SubscriptionPatch
+			if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+				t.Fatalf("invalid patch %s: %v", tt.patch, err)
+			}
+
+			sub := base
+			err := patch.Apply(&sub)
+
+			if tt.wantFields != nil {
+				var verr *ValidationError
+				if !errors.As(err, &verr) {
+					t.Fatalf("Apply error = %v, want *ValidationError", err)
+				}
+
+				fields := make([]string, 0, len(verr.Violations))
+				for _, v := range verr.Violations {
+					fields = append(fields, v.Field)
+				}
+
+				slices.Sort(fields)
+
+				if !reflect.DeepEqual(fields, tt.wantFields) {
+					t.Errorf("violation fields = %v, want %v", fields, tt.wantFields)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Apply unexpected error: %v", err)
+			}
+
+			want := base
+			tt.want(&want)
+
+			if !reflect.DeepEqual(sub, want) {
+				t.Errorf("Apply result = %+v, want %+v", sub, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// Форматы дат подписки. Дата в формате MonthLayout означает
+// первое число месяца.
+const (
+	MonthLayout = "01-2006"
+	DateLayout  = time.DateOnly
+)
+
+var ErrDateFormat = errors.New("must be in MM-YYYY or YYYY-MM-DD format")
+
+// Единицы периода оплаты подписки.
+const (
+	BillingDay     = "day"
+	BillingWeek    = "week"
+	BillingMonth   = "month"
+	BillingQuarter = "quarter"
+	BillingYear    = "year"
+)
+
+// BillingPeriod Период оплаты подписки: цена списывается каждые
+// Count единиц Unit, начиная с даты начала подписки.
+type BillingPeriod struct {
+	Unit  string `json:"unit" enums:"day,week,month,quarter,year" example:"month"`
+	Count int    `json:"count" example:"1"`
+}
+
+// DefaultBillingPeriod Период оплаты, если он не указан в запросе.
+// Все подписки, созданные до появления периодов, оплачиваются помесячно.
+var DefaultBillingPeriod = BillingPeriod{Unit: BillingMonth, Count: 1}
+
+// billingUnits Поддерживаемые единицы периода оплаты.
+var billingUnits = map[string]bool{
+	BillingDay:     true,
+	BillingWeek:    true,
+	BillingMonth:   true,
+	BillingQuarter: true,
+	BillingYear:    true,
+}
+
+// Cycle Дата n-го списания (с нуля) подписки, начатой start.
+// Если в месяце списания нет дня start, списание переносится
+// на последний день месяца, например 31-01, 28-02, 31-03.
+// Невалидный период считается DefaultBillingPeriod.
+func (p BillingPeriod) Cycle(start time.Time, n int) time.Time {
+	if !billingUnits[p.Unit] || p.Count <= 0 {
+		p = DefaultBillingPeriod
+	}
+
+	k := n * p.Count
+
+	switch p.Unit {
+	case BillingDay:
+		return start.AddDate(0, 0, k)
+	case BillingWeek:
+		return start.AddDate(0, 0, 7*k)
+	case BillingQuarter:
+		return addMonths(start, 3*k)
+	case BillingYear:
+		return addMonths(start, 12*k)
+	default:
+		return addMonths(start, k)
+	}
+}
+
+// FirstCycle Номер первого списания (с нуля) подписки, начатой start,
+// в дату from или позже. Номер считается по разнице дат без перебора
+// более ранних списаний.
+func (p BillingPeriod) FirstCycle(start, from time.Time) int {
+	if !from.After(start) {
+		return 0
+	}
+
+	if !billingUnits[p.Unit] || p.Count <= 0 {
+		p = DefaultBillingPeriod
+	}
+
+	var n int
+
+	switch p.Unit {
+	case BillingDay:
+		n = int(from.Sub(start).Hours()/24) / p.Count
+	case BillingWeek:
+		n = int(from.Sub(start).Hours()/24) / (7 * p.Count)
+	default:
+		months := (from.Year()-start.Year())*12 + int(from.Month()-start.Month())
+		n = months / (p.Count * billingMonths[p.Unit])
+	}
+
+	// Списание n не позже from, но может оказаться раньше него
+	// в том же месяце или неделе.
+	for p.Cycle(start, n).Before(from) {
+		n++
+	}
+
+	return n
+}
+
+// billingMonths Число месяцев в единицах периода оплаты, которые
+// считаются месяцами.
+var billingMonths = map[string]int{
+	BillingMonth:   1,
+	BillingQuarter: 3,
+	BillingYear:    12,
+}
+
+// addMonths Прибавление k месяцев без перехода на следующий месяц
+// для дней, которых нет в месяце результата.
+func addMonths(t time.Time, k int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(k), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+
+	return first.AddDate(0, 0, min(t.Day(), last)-1)
+}
+
+// ParseDate Получение даты подписки из строки в формате MM-YYYY
+// (первое число месяца) или YYYY-MM-DD.
+func ParseDate(s string) (time.Time, error) {
+	from, _, err := ParseDateRange(s)
+
+	return from, err
+}
+
+// ParseDateRange Получение первого и последнего дня, которые обозначает
+// строка: весь месяц для MM-YYYY и один день для YYYY-MM-DD.
+func ParseDateRange(s string) (time.Time, time.Time, error) {
+	if t, err := time.Parse(MonthLayout, s); err == nil {
+		return t, t.AddDate(0, 1, -1), nil
+	}
+
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrDateFormat
+	}
+
+	return t, t, nil
+}
+
+// FormatDate Запись даты подписки: первое число месяца записывается
+// в формате MM-YYYY, как раньше, остальные даты - YYYY-MM-DD.
+func FormatDate(t time.Time) string {
+	if t.Day() == 1 {
+		return t.Format(MonthLayout)
+	}
+
+	return t.Format(DateLayout)
+}
+
+// monthStart Приведение даты к первому числу месяца.
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Dates Разбор дат подписки. EndDate == nil означает бессрочную
+// подписку.
+func (s *Subscription) Dates() (time.Time, *time.Time, error) {
+	start, err := ParseDate(s.StartDate)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	if s.EndDate == "" {
+		return start, nil, nil
+	}
+
+	end, err := ParseDate(s.EndDate)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	return start, &end, nil
+}
@@ -0,0 +1,121 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBillingPeriodCycle(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		period BillingPeriod
+		start  time.Time
+		n      int
+		want   time.Time
+	}{
+		{name: "first charge", period: DefaultBillingPeriod, start: date(2025, 1, 15), n: 0, want: date(2025, 1, 15)},
+		{name: "monthly", period: DefaultBillingPeriod, start: date(2025, 1, 15), n: 3, want: date(2025, 4, 15)},
+		{
+			name:   "month end to february",
+			period: DefaultBillingPeriod,
+			start:  date(2025, 1, 31),
+			n:      1,
+			want:   date(2025, 2, 28),
+		},
+		{
+			name:   "month end after february",
+			period: DefaultBillingPeriod,
+			start:  date(2025, 1, 31),
+			n:      2,
+			want:   date(2025, 3, 31),
+		},
+		{name: "leap february", period: DefaultBillingPeriod, start: date(2024, 1, 31), n: 1, want: date(2024, 2, 29)},
+		{
+			name:   "every two months",
+			period: BillingPeriod{BillingMonth, 2},
+			start:  date(2025, 1, 1),
+			n:      2,
+			want:   date(2025, 5, 1),
+		},
+		{name: "daily", period: BillingPeriod{BillingDay, 1}, start: date(2025, 2, 27), n: 2, want: date(2025, 3, 1)},
+		{name: "weekly", period: BillingPeriod{BillingWeek, 1}, start: date(2025, 1, 1), n: 2, want: date(2025, 1, 15)},
+		{
+			name:   "quarterly",
+			period: BillingPeriod{BillingQuarter, 1},
+			start:  date(2025, 11, 30),
+			n:      1,
+			want:   date(2026, 2, 28),
+		},
+		{
+			name:   "yearly from leap day",
+			period: BillingPeriod{BillingYear, 1},
+			start:  date(2024, 2, 29),
+			n:      1,
+			want:   date(2025, 2, 28),
+		},
+		{
+			name:   "unknown unit is monthly",
+			period: BillingPeriod{"decade", 1},
+			start:  date(2025, 1, 1),
+			n:      1,
+			want:   date(2025, 2, 1),
+		},
+		{
+			name:   "zero count is monthly",
+			period: BillingPeriod{BillingYear, 0},
+			start:  date(2025, 1, 1),
+			n:      1,
+			want:   date(2025, 2, 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.period.Cycle(tt.start, tt.n); !got.Equal(tt.want) {
+				t.Errorf("Cycle(%s, %d) = %s, want %s",
+					tt.start.Format(time.DateOnly), tt.n, got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestBillingPeriodFirstCycle(t *testing.T) {
+	periods := []BillingPeriod{
+		DefaultBillingPeriod,
+		{BillingMonth, 2},
+		{BillingDay, 1},
+		{BillingDay, 10},
+		{BillingWeek, 1},
+		{BillingWeek, 3},
+		{BillingQuarter, 1},
+		{BillingYear, 1},
+		{"decade", 1},
+	}
+	starts := []time.Time{
+		time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Номер первого списания совпадает с найденным перебором списаний
+	// для каждого дня двух лет после начала подписки и до него.
+	for _, period := range periods {
+		for _, start := range starts {
+			for from := start.AddDate(0, 0, -3); from.Before(start.AddDate(2, 0, 0)); from = from.AddDate(0, 0, 1) {
+				want := 0
+				for period.Cycle(start, want).Before(from) {
+					want++
+				}
+
+				if got := period.FirstCycle(start, from); got != want {
+					t.Fatalf("%+v.FirstCycle(%s, %s) = %d, want %d", period,
+						start.Format(time.DateOnly), from.Format(time.DateOnly), got, want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// PriceChange Цена подписки, действующая с EffectiveFrom до следующего
+// изменения цены.
+type PriceChange struct {
+	EffectiveFrom time.Time
+	Price         Amount
+}
+
+// PriceHistory История цен подписки, отсортированная по EffectiveFrom.
+// Первая запись создается вместе с подпиской с датой ее начала.
+type PriceHistory []PriceChange
+
+// At Цена, действующая в дату t. До первого изменения действует
+// первая цена истории.
+func (h PriceHistory) At(t time.Time) Amount {
+	price := h[0].Price
+
+	for _, change := range h[1:] {
+		if change.EffectiveFrom.After(t) {
+			break
+		}
+
+		price = change.Price
+	}
+
+	return price
+}
+
+// Apply Новая история после изменения u. История h не изменяется.
+func (h PriceHistory) Apply(u *PriceUpdate) PriceHistory {
+	history := slices.DeleteFunc(slices.Clone(h), func(change PriceChange) bool {
+		return !change.EffectiveFrom.Before(u.Since)
+	})
+
+	return append(history, u.Change)
+}
+
+// PriceUpdate Изменение истории цен при обновлении подписки: записи
+// с EffectiveFrom не раньше Since удаляются и добавляется Change.
+type PriceUpdate struct {
+	Since  time.Time
+	Change PriceChange
+}
+
+// NewPriceUpdate Изменение истории цен при обновлении подписки before
+// до after в момент now. Новая цена действует с from, без from -
+// с текущего месяца или с начала подписки, если она еще не началась,
+// поэтому цены прошлых месяцев меняются только явно. from должна
+// попадать в период подписки, а валюта может меняться, только если
+// новая цена заменяет всю историю (from совпадает с start_date).
+// Если новая цена вступает в силу позже now, after сохраняет цену
+// before, действующую сейчас. Возвращает nil, если историю менять
+// не нужно, и *ValidationError, если from не подходит.
+func NewPriceUpdate(before, after *Subscription, from *time.Time, now time.Time) (*PriceUpdate, error) {
+	start, end, err := after.Dates()
+	if err != nil {
+		return nil, err
+	}
+
+	if from == nil {
+		if before.Price == after.Price && before.Currency == after.Currency {
+			return nil, nil
+		}
+
+		month := monthStart(now)
+		if month.Before(start) {
+			month = start
+		}
+
+		from = &month
+	}
+
+	var violations []Violation
+
+	if from.Before(start) {
+		violations = append(violations, Violation{"effective_from", RuleAfter, "must not be before start_date"})
+	}
+
+	if end != nil && !from.Before(*end) {
+		violations = append(violations, Violation{"effective_from", RuleBefore, "must be before end_date"})
+	}
+
+	if before.Currency != after.Currency && !from.Equal(start) {
+		violations = append(violations, Violation{
+			"currency", RuleUnchanged, "must not change unless effective_from is start_date",
+		})
+	}
+
+	if len(violations) > 0 {
+		return nil, &ValidationError{Violations: violations}
+	}
+
+	update := PriceUpdate{Since: *from, Change: PriceChange{EffectiveFrom: *from, Price: after.Price}}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if from.After(today) && before.Currency == after.Currency {
+		after.Price = before.Price
+	}
+
+	return &update, nil
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User Пользователь, на которого оформляются подписки.
+//
+// Примечание: Email уникален без учета регистра. Timezone - название
+// из базы IANA, по нему определяется текущий день пользователя,
+// Currency - валюта, в которой считаются его расходы.
+type User struct {
+	ID          uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Email       string    `json:"email" example:"user@example.com"`
+	DisplayName string    `json:"display_name,omitempty" example:"Иван Петров"`
+	Timezone    string    `json:"timezone" example:"Europe/Moscow"`
+	Currency    string    `json:"currency" example:"RUB"`
+	CreatedAt   time.Time `json:"created_at" readonly:"true"`
+	UpdatedAt   time.Time `json:"updated_at" readonly:"true"`
+}
+
+// Значения полей пользователя по умолчанию и ограничения длины.
+// MaxEmailLength совпадает с размером колонки VARCHAR(255).
+const (
+	DefaultTimezone      = "UTC"
+	MaxEmailLength       = 255
+	MaxDisplayNameLength = 255
+)
+
+// EmailKey Ключ уникальности email.
+func EmailKey(email string) string {
+	return strings.ToLower(email)
+}
+
+// SetDefaults Нормализация полей и заполнение непереданных
+// часового пояса и валюты значениями по умолчанию.
+func (u *User) SetDefaults() {
+	u.Email = strings.TrimSpace(u.Email)
+	u.DisplayName = strings.TrimSpace(u.DisplayName)
+
+	if u.Timezone == "" {
+		u.Timezone = DefaultTimezone
+	}
+
+	if u.Currency == "" {
+		u.Currency = DefaultCurrency
+	}
+}
+
+// Location Часовой пояс пользователя. Неизвестный пояс считается UTC.
+func (u *User) Location() *time.Location {
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// Today Текущий день пользователя в его часовом поясе как полночь UTC,
+// в том же виде, в котором хранятся даты подписок.
+func (u *User) Today(now time.Time) time.Time {
+	y, m, d := now.In(u.Location()).Date()
+
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// GetUserFromBody Получение тела запроса и маршал в User.
+// Ошибки такие же, как у GetSubFromBody.
+func GetUserFromBody(r *http.Request) (*User, error) {
+	user := User{}
+
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, MaxBodySize))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&user); err != nil {
+		return nil, decodeError(err)
+	}
+
+	if dec.More() {
+		return nil, fmt.Errorf("%w: unexpected data after JSON object", ErrBadBody)
+	}
+
+	user.SetDefaults()
+
+	return &user, nil
+}
@@ -0,0 +1,286 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Ограничения тела запроса и полей подписки.
+// MaxServiceNameLength совпадает с размером колонки VARCHAR(255).
+const (
+	MaxBodySize          = 1 << 20
+	MaxServiceNameLength = 255
+)
+
+// Правила валидации, которые нарушает поле.
+const (
+	RuleRequired     = "required"
+	RulePositive     = "positive"
+	RuleNonNegative  = "non_negative"
+	RuleMaxLength    = "max_length"
+	RuleFormat       = "format"
+	RuleAfter        = "after"
+	RuleBefore       = "before"
+	RuleUnchanged    = "unchanged"
+	RuleType         = "type"
+	RuleExists       = "exists"
+	RuleUnknownField = "unknown_field"
+)
+
+var (
+	ErrBadBody      = errors.New("invalid request body")
+	ErrBodyTooLarge = errors.New("request body too large")
+	ErrValidation   = errors.New("validation failed")
+)
+
+// Violation Нарушение правила валидации для одного поля.
+type Violation struct {
+	Field   string `json:"field" example:"price"`
+	Rule    string `json:"rule" example:"positive"`
+	Message string `json:"message" example:"must be positive"`
+}
+
+// ValidationError Ошибка валидации со списком нарушений по полям.
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Error Описание ошибки со всеми нарушениями.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.Field+": "+v.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", ErrValidation, strings.Join(msgs, "; "))
+}
+
+// Unwrap Позволяет проверять ошибку через errors.Is(err, ErrValidation).
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// NewValidationError Создание ошибки валидации с одним нарушением.
+func NewValidationError(field, rule, message string) *ValidationError {
+	return &ValidationError{Violations: []Violation{{field, rule, message}}}
+}
+
+// ValidateSubscription Валидация структуры Subscription.
+// Возвращает *ValidationError со всеми найденными нарушениями
+// или nil, если подписка валидна.
+func ValidateSubscription(sub *Subscription) error {
+	var violations []Violation
+
+	switch {
+	case NormalizeServiceName(sub.ServiceName) == "":
+		violations = append(violations, Violation{"service_name", RuleRequired, "must not be empty"})
+	case utf8.RuneCountInString(sub.ServiceName) > MaxServiceNameLength:
+		violations = append(violations, maxLengthViolation())
+	}
+
+	if sub.Price <= 0 {
+		violations = append(violations, Violation{"price", RulePositive, "must be positive"})
+	}
+
+	switch {
+	case sub.Currency == "":
+		violations = append(violations, Violation{"currency", RuleRequired, "must not be empty"})
+	case !IsCurrency(sub.Currency):
+		violations = append(violations, Violation{"currency", RuleFormat, "must be a supported ISO 4217 code"})
+	}
+
+	if sub.UserID == uuid.Nil {
+		violations = append(violations, Violation{"user_id", RuleRequired, "must be a non-nil UUID"})
+	}
+
+	startDate, err := ParseDate(sub.StartDate)
+
+	switch {
+	case sub.StartDate == "":
+		violations = append(violations, Violation{"start_date", RuleRequired, "must not be empty"})
+	case err != nil:
+		violations = append(violations, formatViolation("start_date"))
+	}
+
+	if sub.EndDate != "" {
+		endDate, endErr := ParseDate(sub.EndDate)
+
+		switch {
+		case endErr != nil:
+			violations = append(violations, formatViolation("end_date"))
+		case err == nil && !endDate.After(startDate):
+			violations = append(violations, Violation{"end_date", RuleAfter, "must be after start_date"})
+		}
+	}
+
+	if !billingUnits[sub.BillingPeriod.Unit] {
+		violations = append(violations, Violation{
+			"billing_period.unit",
+			RuleFormat,
+			"must be one of day, week, month, quarter, year",
+		})
+	}
+
+	if sub.BillingPeriod.Count <= 0 {
+		violations = append(violations, Violation{"billing_period.count", RulePositive, "must be positive"})
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+
+	return nil
+}
+
+// ValidateService Валидация структуры Service.
+// Возвращает *ValidationError со всеми найденными нарушениями
+// или nil, если сервис валиден.
+func ValidateService(svc *Service) error {
+	var violations []Violation
+
+	switch {
+	case NormalizeServiceName(svc.Name) == "":
+		violations = append(violations, Violation{"name", RuleRequired, "must not be empty"})
+	case utf8.RuneCountInString(svc.Name) > MaxServiceNameLength:
+		violations = append(violations, Violation{
+			"name",
+			RuleMaxLength,
+			fmt.Sprintf("must be at most %d characters", MaxServiceNameLength),
+		})
+	}
+
+	if len(svc.Aliases) > MaxAliases {
+		violations = append(violations, Violation{
+			"aliases",
+			RuleMaxLength,
+			fmt.Sprintf("must contain at most %d aliases", MaxAliases),
+		})
+	}
+
+	for i, alias := range svc.Aliases {
+		field := fmt.Sprintf("aliases[%d]", i)
+
+		switch {
+		case NormalizeServiceName(alias) == "":
+			violations = append(violations, Violation{field, RuleRequired, "must not be empty"})
+		case utf8.RuneCountInString(alias) > MaxServiceNameLength:
+			violations = append(violations, Violation{
+				field,
+				RuleMaxLength,
+				fmt.Sprintf("must be at most %d characters", MaxServiceNameLength),
+			})
+		}
+	}
+
+	if utf8.RuneCountInString(svc.Category) > MaxServiceNameLength {
+		violations = append(violations, Violation{
+			"category",
+			RuleMaxLength,
+			fmt.Sprintf("must be at most %d characters", MaxServiceNameLength),
+		})
+	}
+
+	if svc.DefaultPrice != nil && *svc.DefaultPrice <= 0 {
+		violations = append(violations, Violation{"default_price", RulePositive, "must be positive"})
+	}
+
+	if !IsCurrency(svc.Currency) {
+		violations = append(violations, Violation{"currency", RuleFormat, "must be a supported ISO 4217 code"})
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+
+	return nil
+}
+
+// ValidateUser Валидация структуры User.
+// Возвращает *ValidationError со всеми найденными нарушениями
+// или nil, если пользователь валиден.
+func ValidateUser(u *User) error {
+	var violations []Violation
+
+	switch addr, err := mail.ParseAddress(u.Email); {
+	case u.Email == "":
+		violations = append(violations, Violation{"email", RuleRequired, "must not be empty"})
+	case utf8.RuneCountInString(u.Email) > MaxEmailLength:
+		violations = append(violations, Violation{
+			"email",
+			RuleMaxLength,
+			fmt.Sprintf("must be at most %d characters", MaxEmailLength),
+		})
+	case err != nil || addr.Address != u.Email:
+		violations = append(violations, Violation{"email", RuleFormat, "must be an email address"})
+	}
+
+	if utf8.RuneCountInString(u.DisplayName) > MaxDisplayNameLength {
+		violations = append(violations, Violation{
+			"display_name",
+			RuleMaxLength,
+			fmt.Sprintf("must be at most %d characters", MaxDisplayNameLength),
+		})
+	}
+
+	if _, err := time.LoadLocation(u.Timezone); err != nil || u.Timezone == "Local" {
+		violations = append(violations, Violation{"timezone", RuleFormat, "must be an IANA time zone"})
+	}
+
+	if !IsCurrency(u.Currency) {
+		violations = append(violations, Violation{"currency", RuleFormat, "must be a supported ISO 4217 code"})
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+
+	return nil
+}
+
+// decodeError Преобразование ошибки чтения JSON в ошибку для
+// пользователя. Ошибки типов и неизвестные поля превращаются
+// в нарушения по полям. Сумма читается из тела запроса только
+// для цены, поэтому ErrInvalidAmount относится к полю price.
+func decodeError(err error) error {
+	var (
+		maxBytesErr *http.MaxBytesError
+		typeErr     *json.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return fmt.Errorf("%w: limit is %d bytes", ErrBodyTooLarge, maxBytesErr.Limit)
+	case errors.Is(err, ErrInvalidAmount):
+		return NewValidationError("price", RuleFormat, err.Error())
+	case errors.As(err, &typeErr):
+		return NewValidationError(typeErr.Field, RuleType, "must be of type "+typeErr.Type.String())
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+
+		return NewValidationError(field, RuleUnknownField, "unknown field")
+	}
+
+	return fmt.Errorf("%w: %w", ErrBadBody, err)
+}
+
+// maxLengthViolation Нарушение максимальной длины названия сервиса.
+func maxLengthViolation() Violation {
+	return Violation{
+		"service_name",
+		RuleMaxLength,
+		fmt.Sprintf("must be at most %d characters", MaxServiceNameLength),
+	}
+}
+
+// formatViolation Нарушение формата даты.
+func formatViolation(field string) Violation {
+	return Violation{field, RuleFormat, ErrDateFormat.Error()}
+}
@@ -0,0 +1,104 @@
+// Пакет policy для проверки прав вызывающего на данные пользователей.
+// Хендлеры передают действие и владельцев данных, политика решает
+// по роли вызывающего из контекста запроса:
+//   - admin может все;
+//   - support читает и изменяет данные всех пользователей, но не удаляет их;
+//   - user читает, изменяет и удаляет в корзину только свои данные.
+//
+// Удаление навсегда доступно только администратору.
+package policy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SHSanderland/EffMobTest/pkg/auth"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// Action Действие над данными пользователя.
+type Action string
+
+// Действия, которые проверяет политика.
+const (
+	ActionRead   Action = "read"
+	ActionWrite  Action = "write"
+	ActionDelete Action = "delete"
+	ActionPurge  Action = "purge"
+)
+
+// Authorize Проверка, что вызывающий из контекста может выполнить
+// действие над данными пользователей owners. При изменении владельца
+// передаются и старый, и новый. Возвращает auth.ErrForbidden.
+func Authorize(ctx context.Context, action Action, owners ...uuid.UUID) error {
+	p := auth.FromContext(ctx)
+	if p == nil {
+		return auth.ErrUnauthorized
+	}
+
+	if !allowed(p.Role, action) {
+		return fmt.Errorf("%w: role %s may not %s", auth.ErrForbidden, p.Role, action)
+	}
+
+	if p.Role != model.RoleUser {
+		return nil
+	}
+
+	for _, owner := range owners {
+		if owner != p.UserID {
+			return fmt.Errorf("%w: data of user %s belongs to another user", auth.ErrForbidden, owner)
+		}
+	}
+
+	return nil
+}
+
+// ScopeUser Ограничение фильтра по пользователю для списков и отчетов.
+// Для роли user возвращает ее пользователя, а фильтр по другому
+// пользователю запрещает. Остальным ролям возвращает фильтр без изменений.
+func ScopeUser(ctx context.Context, userID *uuid.UUID) (*uuid.UUID, error) {
+	p := auth.FromContext(ctx)
+	if p == nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	if p.Role != model.RoleUser {
+		return userID, nil
+	}
+
+	if userID != nil && *userID != p.UserID {
+		return nil, fmt.Errorf("%w: data of user %s belongs to another user", auth.ErrForbidden, *userID)
+	}
+
+	own := p.UserID
+
+	return &own, nil
+}
+
+// WithCatalogAccess Контекст, в котором хранилище создает сервисы
+// каталога для новых названий в подписках. Как и POST /services, это
+// доступно только сотрудникам (admin и support), подписки остальных
+// должны ссылаться на сервисы из каталога.
+func WithCatalogAccess(ctx context.Context) context.Context {
+	if auth.FromContext(ctx).HasRole(model.RoleAdmin, model.RoleSupport) {
+		return storage.WithServiceCreation(ctx)
+	}
+
+	return ctx
+}
+
+// allowed Проверка действия по роли без учета владельца.
+func allowed(role string, action Action) bool {
+	switch role {
+	case model.RoleAdmin:
+		return true
+	case model.RoleSupport:
+		return action == ActionRead || action == ActionWrite
+	case model.RoleUser:
+		return action != ActionPurge
+	default:
+		return false
+	}
+}
@@ -0,0 +1,125 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/SHSanderland/EffMobTest/pkg/auth"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/google/uuid"
+)
+
+var (
+	own   = uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	other = uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+)
+
+// withRole Контекст вызывающего с ролью role и пользователем own.
+func withRole(role string) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: role, Role: role, UserID: own})
+}
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		action  Action
+		owners  []uuid.UUID
+		wantErr error
+	}{
+		{name: "anonymous", ctx: context.Background(), action: ActionRead, wantErr: auth.ErrUnauthorized},
+		{name: "admin purges", ctx: withRole(model.RoleAdmin), action: ActionPurge, owners: []uuid.UUID{other}},
+		{name: "support reads other", ctx: withRole(model.RoleSupport), action: ActionRead, owners: []uuid.UUID{other}},
+		{name: "support writes other", ctx: withRole(model.RoleSupport), action: ActionWrite, owners: []uuid.UUID{other}},
+		{
+			name:    "support deletes",
+			ctx:     withRole(model.RoleSupport),
+			action:  ActionDelete,
+			owners:  []uuid.UUID{other},
+			wantErr: auth.ErrForbidden,
+		},
+		{name: "user reads own", ctx: withRole(model.RoleUser), action: ActionRead, owners: []uuid.UUID{own}},
+		{name: "user deletes own", ctx: withRole(model.RoleUser), action: ActionDelete, owners: []uuid.UUID{own}},
+		{
+			name:    "user reads other",
+			ctx:     withRole(model.RoleUser),
+			action:  ActionRead,
+			owners:  []uuid.UUID{other},
+			wantErr: auth.ErrForbidden,
+		},
+		{
+			name:    "user moves own to other",
+			ctx:     withRole(model.RoleUser),
+			action:  ActionWrite,
+			owners:  []uuid.UUID{own, other},
+			wantErr: auth.ErrForbidden,
+		},
+		{
+			name:    "user purges own",
+			ctx:     withRole(model.RoleUser),
+			action:  ActionPurge,
+			owners:  []uuid.UUID{own},
+			wantErr: auth.ErrForbidden,
+		},
+		{name: "unknown role", ctx: withRole("guest"), action: ActionRead, wantErr: auth.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Authorize(tt.ctx, tt.action, tt.owners...); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Authorize error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScopeUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		filter  *uuid.UUID
+		want    *uuid.UUID
+		wantErr error
+	}{
+		{name: "anonymous", ctx: context.Background(), wantErr: auth.ErrUnauthorized},
+		{name: "support without filter", ctx: withRole(model.RoleSupport)},
+		{name: "support with filter", ctx: withRole(model.RoleSupport), filter: &other, want: &other},
+		{name: "user without filter", ctx: withRole(model.RoleUser), want: &own},
+		{name: "user with own filter", ctx: withRole(model.RoleUser), filter: &own, want: &own},
+		{name: "user with other filter", ctx: withRole(model.RoleUser), filter: &other, wantErr: auth.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ScopeUser(tt.ctx, tt.filter)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ScopeUser error = %v, want %v", err, tt.wantErr)
+			}
+
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("ScopeUser = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithCatalogAccess(t *testing.T) {
+	tests := []struct {
+		role string
+		want bool
+	}{
+		{role: model.RoleAdmin, want: true},
+		{role: model.RoleSupport, want: true},
+		{role: model.RoleUser, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			if got := storage.CanCreateService(WithCatalogAccess(withRole(tt.role))); got != tt.want {
+				t.Errorf("CanCreateService = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
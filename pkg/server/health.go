@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Состояния сервера в ответах проб.
+const (
+	statusOK           = "ok"
+	statusStarting     = "starting"
+	statusShuttingDown = "shutting_down"
+	statusUnavailable  = "storage_unavailable"
+)
+
+// pingTimeout Ограничение времени проверки хранилища в /readyz.
+const pingTimeout = 2 * time.Second
+
+// probeResponse Ответ проб.
+type probeResponse struct {
+	Status string `json:"status"`
+}
+
+// api API сервера, готовое к работе после подключения к хранилищу.
+type api struct {
+	handler http.Handler
+	db      storage.Storage
+}
+
+// health Состояние сервера для проб Kubernetes и балансировщика.
+// API появляется после подключения к хранилищу, draining выставляется
+// при получении сигнала выключения.
+type health struct {
+	log      *slog.Logger
+	api      atomic.Pointer[api]
+	draining atomic.Bool
+}
+
+// storage Хранилище сервера или nil, если сервер еще запускается.
+func (h *health) storage() storage.Storage {
+	if a := h.api.Load(); a != nil {
+		return a.db
+	}
+
+	return nil
+}
+
+// ServeHTTP Передача запроса в API. До подключения к хранилищу
+// отвечает 503.
+func (h *health) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a := h.api.Load()
+	if a == nil {
+		w.Header().Set("Retry-After", "1")
+		response.SendError(w, r, storage.ErrUnavailable)
+
+		return
+	}
+
+	a.handler.ServeHTTP(w, r)
+}
+
+// live Проба живости: процесс запущен и отвечает на запросы.
+func (h *health) live(w http.ResponseWriter, _ *http.Request) {
+	sendProbe(w, http.StatusOK, statusOK)
+}
+
+// startup Проба запуска: сервер подключился к хранилищу и применил
+// миграции.
+func (h *health) startup(w http.ResponseWriter, _ *http.Request) {
+	if h.api.Load() == nil {
+		sendProbe(w, http.StatusServiceUnavailable, statusStarting)
+
+		return
+	}
+
+	sendProbe(w, http.StatusOK, statusOK)
+}
+
+// ready Проба готовности: сервер запущен, не выключается, хранилище
+// доступно и схема базы данных в ожидаемой версии.
+func (h *health) ready(w http.ResponseWriter, r *http.Request) {
+	const fn = "server.health.ready"
+
+	a := h.api.Load()
+
+	switch {
+	case h.draining.Load():
+		sendProbe(w, http.StatusServiceUnavailable, statusShuttingDown)
+
+		return
+	case a == nil:
+		sendProbe(w, http.StatusServiceUnavailable, statusStarting)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), pingTimeout)
+	defer cancel()
+
+	if err := a.db.Ping(ctx); err != nil {
+		h.log.WarnContext(
+			ctx,
+			"storage is not ready",
+			slog.String("fn", fn),
+			slog.String("requestID", middleware.GetReqID(r.Context())),
+			slog.String("err", err.Error()),
+		)
+		sendProbe(w, http.StatusServiceUnavailable, statusUnavailable)
+
+		return
+	}
+
+	sendProbe(w, http.StatusOK, statusOK)
+}
+
+// sendProbe Отправка ответа пробы.
+func sendProbe(w http.ResponseWriter, status int, state string) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	_ = response.JSON(w, status, probeResponse{Status: state})
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/actor"
+	"github.com/SHSanderland/EffMobTest/pkg/auth"
+	"github.com/SHSanderland/EffMobTest/pkg/handlers/response"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/policy"
+	"github.com/SHSanderland/EffMobTest/pkg/tenant"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// requestLogger Middleware журнала запросов вместо middleware.Logger.
+// Строка пишется через InfoContext после ответа, поэтому вместе со
+// статусом и длительностью содержит traceID и spanID запроса.
+func requestLogger(l *slog.Logger) func(http.Handler) http.Handler {
+	const fn = "server.requestLogger"
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			start := time.Now()
+
+			next.ServeHTTP(ww, r)
+
+			l.InfoContext(
+				r.Context(),
+				"request is served",
+				slog.String("fn", fn),
+				slog.String("requestID", middleware.GetReqID(r.Context())),
+				slog.String("method", r.Method),
+				slog.String("uri", r.RequestURI),
+				slog.Int("status", cmp.Or(ww.Status(), http.StatusOK)),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("remoteAddr", r.RemoteAddr),
+			)
+		})
+	}
+}
+
+// authenticate Middleware аутентификации запросов к API. Вызывающий
+// сохраняется в контексте запроса и становится исполнителем для журнала
+// изменений, заголовок X-Actor при этом не учитывается. Без
+// Authenticator (аутентификация выключена) запрос выполняется от имени
+// администратора с исполнителем из X-Actor. Сотрудникам контекст
+// разрешает создавать сервисы каталога через policy.WithCatalogAccess.
+func authenticate(l *slog.Logger, a *auth.Authenticator) func(http.Handler) http.Handler {
+	const fn = "server.authenticate"
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			if a == nil {
+				name := actor.FromContext(ctx)
+				p := auth.Principal{
+					Subject: name, Name: name, Method: auth.MethodNone, Role: model.RoleAdmin, CrossTenant: true,
+				}
+				next.ServeHTTP(w, r.WithContext(policy.WithCatalogAccess(auth.WithPrincipal(ctx, &p))))
+
+				return
+			}
+
+			p, err := a.Authenticate(r)
+			if err != nil {
+				l.WarnContext(
+					ctx,
+					"authentication failed",
+					slog.String("fn", fn),
+					slog.String("requestID", middleware.GetReqID(ctx)),
+					slog.String("err", err.Error()),
+				)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				response.SendError(w, r, err)
+
+				return
+			}
+
+			l.DebugContext(
+				ctx,
+				"request is authenticated",
+				slog.String("fn", fn),
+				slog.String("requestID", middleware.GetReqID(ctx)),
+				slog.String("subject", p.Subject),
+				slog.String("method", p.Method),
+				slog.String("role", p.Role),
+			)
+
+			ctx = actor.WithActor(auth.WithPrincipal(ctx, p), p.Subject)
+			ctx = policy.WithCatalogAccess(ctx)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// requireRole Middleware, которое пропускает только вызывающих
+// с одной из ролей. Остальные получают auth.ErrForbidden.
+func requireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !auth.FromContext(r.Context()).HasRole(roles...) {
+				response.SendError(w, r, auth.ErrForbidden)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// resolveTenant Middleware определения арендатора запроса. Вызывающий
+// работает только в арендаторе ключа или токена (tenant.Default, если
+// он не указан), и заголовок X-Tenant-ID может лишь совпадать с ним.
+// Выбирать арендатора заголовком может только администратор
+// с auth.Principal.CrossTenant, без заголовка - tenant.Default.
+func resolveTenant(l *slog.Logger) func(http.Handler) http.Handler {
+	const fn = "server.resolveTenant"
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			log := l.With(
+				slog.String("fn", fn),
+				slog.String("requestID", middleware.GetReqID(ctx)),
+			)
+
+			id, err := requestTenant(r)
+			if err != nil {
+				log.WarnContext(ctx, "failed to resolve tenant", slog.String("err", err.Error()))
+				response.SendError(w, r, err)
+
+				return
+			}
+
+			log.DebugContext(ctx, "tenant is resolved", slog.String("tenant", id))
+
+			next.ServeHTTP(w, r.WithContext(tenant.WithTenant(ctx, id)))
+		})
+	}
+}
+
+// requestTenant Арендатор запроса по вызывающему и заголовку X-Tenant-ID.
+func requestTenant(r *http.Request) (string, error) {
+	header := strings.TrimSpace(r.Header.Get(tenant.Header))
+
+	if header != "" {
+		if _, err := tenant.Parse(header); err != nil {
+			return "", err
+		}
+	}
+
+	p := auth.FromContext(r.Context())
+	if p != nil && p.CrossTenant {
+		return cmp.Or(header, tenant.Default), nil
+	}
+
+	id := tenant.Default
+	if p != nil && p.Tenant != "" {
+		id = p.Tenant
+	}
+
+	if header != "" && header != id {
+		return "", fmt.Errorf("%w: caller belongs to tenant %s", auth.ErrForbidden, id)
+	}
+
+	return id, nil
+}
@@ -10,10 +10,16 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/SHSanderland/EffMobTest/pkg/actor"
+	"github.com/SHSanderland/EffMobTest/pkg/auth"
 	"github.com/SHSanderland/EffMobTest/pkg/config"
 	"github.com/SHSanderland/EffMobTest/pkg/handlers"
+	"github.com/SHSanderland/EffMobTest/pkg/metrics"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
 	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/SHSanderland/EffMobTest/pkg/tracing"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
@@ -21,25 +27,56 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 )
 
-// InitServer Инициализация сервера.
-func InitServer(l *slog.Logger, cfg *config.Config, db storage.Storage) {
+// OpenStorage Подключение к хранилищу при запуске сервера.
+type OpenStorage func(ctx context.Context) (storage.Storage, error)
+
+// InitServer Инициализация сервера. Сервер начинает отвечать на пробы
+// сразу, а API - после подключения к хранилищу через open, которое
+// ограничено Database.ConnectTimeout. До этого API отвечает 503,
+// а /startupz и /readyz - что сервер запускается. Метрики отдаются
+// отдельным сервером на Metrics.MetricsAddr.
+func InitServer(l *slog.Logger, cfg *config.Config, open OpenStorage) {
 	const fn = "server.InitServer"
 	log := l.With(
 		slog.String("fn", fn),
 		slog.String("Address server", cfg.Addr),
 	)
 
+	h := &health{log: l}
+
 	srv := http.Server{
 		Addr:         cfg.Addr,
-		Handler:      initMux(l, db),
+		Handler:      initMux(h),
 		ReadTimeout:  cfg.ReadTimeout,
 		WriteTimeout: cfg.WriteTimeout,
 		IdleTimeout:  cfg.IdleTimeout,
 	}
+	// Основной сервер выключается последним: после него закрывается
+	// хранилище и завершается процесс.
+	servers := []*http.Server{}
+
+	if cfg.MetricsAddr != "" {
+		metricsSrv := http.Server{
+			Addr:         cfg.MetricsAddr,
+			Handler:      initMetricsMux(l),
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			IdleTimeout:  cfg.IdleTimeout,
+		}
+		servers = append(servers, &metricsSrv)
+
+		go serveMetrics(log, &metricsSrv)
+	}
+
+	servers = append(servers, &srv)
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
 
 	log.Info("Start server!")
 
-	go gracefulShutdown(log, &srv)
+	go gracefulShutdown(log, h, &cfg.Server, servers...)
+	go startAPI(ctx, l, cfg, h, open)
 
 	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
 		log.Error("error to start server", slog.String("err", err.Error()))
@@ -47,48 +84,199 @@ func InitServer(l *slog.Logger, cfg *config.Config, db storage.Storage) {
 		panic(err)
 	}
 
-	log.Info("Closing database...")
+	stop()
+
+	if db := h.storage(); db != nil {
+		log.Info("Closing database...")
 
-	db.CloseConnection()
+		db.CloseConnection()
+	}
 }
 
-// initMux Инициализация роутера.
-func initMux(log *slog.Logger, db storage.Storage) *chi.Mux {
+// serveMetrics Запуск сервера метрик. Без метрик сервис может работать,
+// поэтому ошибка только пишется в лог.
+func serveMetrics(log *slog.Logger, srv *http.Server) {
+	log.Info("Start metrics server!", slog.String("address", srv.Addr))
+
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Error("error to start metrics server", slog.String("err", err.Error()))
+	}
+}
+
+// startAPI Подключение к хранилищу и запуск API. Без хранилища
+// сервер работать не может, поэтому при ошибке паникует. Метрики
+// хранилища обновляются до отмены ctx.
+func startAPI(ctx context.Context, l *slog.Logger, cfg *config.Config, h *health, open OpenStorage) {
+	const fn = "server.startAPI"
+	log := l.With(
+		slog.String("fn", fn),
+	)
+
+	openCtx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+	defer cancel()
+
+	db, err := open(openCtx)
+	if err != nil {
+		log.Error("failed to open storage", slog.String("err", err.Error()))
+
+		panic(err)
+	}
+
+	var authn *auth.Authenticator
+
+	if cfg.Auth.Enabled {
+		a, err := auth.New(&cfg.Auth, db)
+		if err != nil {
+			log.Error("failed to init authentication", slog.String("err", err.Error()))
+
+			panic(err)
+		}
+
+		authn = a
+	} else {
+		log.Warn("Authentication is disabled, every request is executed as admin!")
+	}
+
+	metrics.RegisterStorage(ctx, l, db, cfg.RefreshInterval)
+
+	h.api.Store(&api{handler: initAPI(l, db, authn), db: db})
+
+	log.Info("API is ready!")
+}
+
+// initMux Инициализация корневого роутера. Пробы /healthz, /startupz
+// и /readyz не требуют аутентификации и не пишутся в лог запросов.
+// Каждый запрос, кроме проб, получает серверный спан трассировки.
+// Запросы к /api/v1 передаются в API после его запуска.
+func initMux(h *health) *chi.Mux {
 	router := chi.NewRouter()
-	h := handlers.InitHandlers(log, db)
 
 	router.Use(
+		metrics.Middleware,
 		middleware.Recoverer,
-		middleware.RequestID,
-		middleware.Logger,
 	)
 
-	router.Route("/api/v1", func(r chi.Router) {
+	router.Get("/healthz", h.live)
+	router.Get("/startupz", h.startup)
+	router.Get("/readyz", h.ready)
+
+	router.Group(func(r chi.Router) {
+		r.Use(
+			middleware.RequestID,
+			tracing.Middleware,
+			requestLogger(h.log),
+			actor.Middleware,
+		)
+
+		r.Mount("/api/v1", h)
+		r.Get("/swagger/*", httpSwagger.Handler(
+			httpSwagger.URL("http://localhost:8080/swagger/doc.json"),
+		))
+	})
+
+	return router
+}
+
+// initMetricsMux Инициализация роутера сервера метрик. Метрики
+// отдаются без аутентификации, поэтому адрес сервера метрик
+// не публикуется наружу.
+func initMetricsMux(log *slog.Logger) *chi.Mux {
+	router := chi.NewRouter()
+
+	router.Use(middleware.Recoverer)
+	router.Handle("/metrics", metrics.Handler(log))
+
+	return router
+}
+
+// initAPI Инициализация роутера /api/v1. Все маршруты требуют
+// аутентификации через authn и работают с данными арендатора запроса.
+// Пакетные операции, импорт, журнал и изменение справочников доступны
+// сотрудникам (admin и support), удаление справочников, пользователей
+// и /api/v1/admin - только администраторам. Без authn аутентификация
+// выключена.
+func initAPI(log *slog.Logger, db storage.Storage, authn *auth.Authenticator) *chi.Mux {
+	router := chi.NewRouter()
+	h := handlers.InitHandlers(log, db)
+
+	router.Group(func(r chi.Router) {
+		r.Use(authenticate(log, authn), resolveTenant(log))
+
+		// Права на подписки и данные пользователя проверяют хендлеры
+		// через policy.
 		r.Post("/subscriptions", h.CreateSubscription)
+		r.Get("/subscriptions/export", h.ExportSubscriptions)
 		r.Get("/subscriptions/{id}", h.ReadSubscription)
 		r.Put("/subscriptions/{id}", h.UpdateSubscription)
+		r.Patch("/subscriptions/{id}", h.PatchSubscription)
 		r.Delete("/subscriptions/{id}", h.DeleteSubscription)
+		r.Post("/subscriptions/{id}/restore", h.RestoreSubscription)
+		r.Get("/subscriptions/{id}/history", h.HistorySubscription)
+		r.Get("/subscriptions/{id}/prices", h.PricesSubscription)
 		r.Get("/subscriptions", h.ListSubscription)
 		r.Get("/subscriptions/cost", h.CostSubscription)
-	})
+		r.Get("/rates", h.ListRates)
+		r.Get("/services", h.ListServices)
+		r.Get("/services/{id}", h.ReadService)
+		r.Get("/users/{id}", h.ReadUser)
+		r.Put("/users/{id}", h.UpdateUser)
+		r.Get("/users/{id}/subscriptions", h.UserSubscriptions)
+		r.Get("/users/{id}/spending", h.UserSpending)
+
+		r.Group(func(r chi.Router) {
+			r.Use(requireRole(model.RoleAdmin, model.RoleSupport))
 
-	router.Get("/swagger/*", httpSwagger.Handler(
-		httpSwagger.URL("http://localhost:8080/swagger/doc.json"),
-	))
+			r.Post("/subscriptions:batch", h.BatchSubscriptions)
+			r.Post("/subscriptions/import", h.ImportSubscriptions)
+			r.Get("/audit", h.Audit)
+			r.Put("/rates/{from}/{to}", h.SetRate)
+			r.Post("/services", h.CreateService)
+			r.Put("/services/{id}", h.UpdateService)
+			r.Get("/users", h.ListUsers)
+			r.Post("/users", h.CreateUser)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(requireRole(model.RoleAdmin))
+
+			r.Delete("/rates/{from}/{to}", h.DeleteRate)
+			r.Delete("/services/{id}", h.DeleteService)
+			r.Delete("/users/{id}", h.DeleteUser)
+
+			r.Route("/admin", func(r chi.Router) {
+				r.Get("/api-keys", h.ListAPIKeys)
+				r.Post("/api-keys", h.CreateAPIKey)
+				r.Delete("/api-keys/{id}", h.RevokeAPIKey)
+			})
+		})
+	})
 
 	return router
 }
 
 // gracefulShutdown Функция для постепенного выключения сервера.
-// Слушает сигналы ОС. Запускать в горутине.
-func gracefulShutdown(log *slog.Logger, srv *http.Server) {
+// Слушает сигналы ОС. После сигнала /readyz отвечает ошибкой
+// ShutdownDelay, чтобы балансировщик успел убрать сервер, затем серверы
+// srvs по очереди завершают начатые запросы за общий ShutdownTimeout.
+// Запускать в горутине.
+func gracefulShutdown(log *slog.Logger, h *health, cfg *config.Server, srvs ...*http.Server) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
 	<-c
 
-	log.Info("Received shutdown signal, gracefully shutting down...")
+	log.Info("Received shutdown signal, draining traffic...", slog.Duration("delay", cfg.ShutdownDelay))
+
+	h.draining.Store(true)
+	time.Sleep(cfg.ShutdownDelay)
+
+	log.Info("Gracefully shutting down...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
 
-	if err := srv.Shutdown(context.TODO()); err != nil {
-		log.Warn("failed to shutdown server", slog.String("err", err.Error()))
+	for _, srv := range srvs {
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Warn("failed to shutdown server", slog.String("address", srv.Addr), slog.String("err", err.Error()))
+		}
 	}
 }
@@ -0,0 +1,1068 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/auth"
+	"github.com/SHSanderland/EffMobTest/pkg/config"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage/memory"
+	"github.com/SHSanderland/EffMobTest/pkg/tenant"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	adminKey  = "admin-key"
+	jwtSecret = "s3cret"
+	ownUser   = "550e8400-e29b-41d4-a716-446655440000"
+	otherUser = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+)
+
+// credential Учетные данные запроса: заголовок и его значение.
+type credential struct {
+	header string
+	value  string
+}
+
+// newTestServer Сервер с хранилищем в памяти, административным ключом
+// и JWT HS256.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	log := slog.New(slog.DiscardHandler)
+	db := memory.InitStorage(log)
+
+	authn, err := auth.New(&config.Auth{
+		Enabled:  true,
+		AdminKey: adminKey,
+		JWT: config.JWT{
+			Secret:   jwtSecret,
+			Issuer:   "subscriptions-auth",
+			Audience: "subscriptions-api",
+			Leeway:   30 * time.Second,
+		},
+	}, db)
+	if err != nil {
+		t.Fatalf("auth.New unexpected error: %v", err)
+	}
+
+	h := &health{log: log}
+	h.api.Store(&api{handler: initAPI(log, db, authn), db: db})
+
+	srv := httptest.NewServer(initMux(h))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// admin Учетные данные администратора.
+func admin() credential {
+	return credential{header: auth.KeyHeader, value: adminKey}
+}
+
+// bearer Учетные данные с JWT для роли role и пользователя sub.
+func bearer(t *testing.T, role, sub string) credential {
+	t.Helper()
+
+	return tenantBearer(t, role, sub, "")
+}
+
+// tenantBearer Учетные данные с JWT для роли role и пользователя sub
+// в арендаторе tenantID, пустой tenantID не попадает в токен.
+func tenantBearer(t *testing.T, role, sub, tenantID string) credential {
+	t.Helper()
+
+	claims := jwt.MapClaims{
+		"sub":  sub,
+		"iss":  "subscriptions-auth",
+		"aud":  "subscriptions-api",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+		"role": role,
+	}
+	if tenantID != "" {
+		claims["tenant_id"] = tenantID
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(jwtSecret))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	return credential{header: "Authorization", value: "Bearer " + token}
+}
+
+// do Запрос к серверу. Возвращает ответ с прочитанным телом.
+func do(
+	t *testing.T, srv *httptest.Server, method, path string, cred credential, ifMatch, body string,
+) (*http.Response, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	if cred.header != "" {
+		req.Header.Set(cred.header, cred.value)
+	}
+
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+
+	return resp, data
+}
+
+// subscriptionBody Тело запроса подписки пользователя userID.
+func subscriptionBody(userID, service string) string {
+	return `{"service_name":"` + service + `","price":400,"user_id":"` + userID + `","start_date":"07-2025"}`
+}
+
+// seed Пользователи ownUser и otherUser с подписками 1 и 2.
+func seed(t *testing.T, srv *httptest.Server) {
+	t.Helper()
+
+	requests := []struct {
+		path string
+		body string
+	}{
+		{path: "/api/v1/users", body: `{"id":"` + ownUser + `","email":"own@example.com"}`},
+		{path: "/api/v1/users", body: `{"id":"` + otherUser + `","email":"other@example.com"}`},
+		{path: "/api/v1/subscriptions", body: subscriptionBody(ownUser, "Netflix")},
+		{path: "/api/v1/subscriptions", body: subscriptionBody(otherUser, "Spotify")},
+	}
+
+	for _, req := range requests {
+		resp, body := do(t, srv, http.MethodPost, req.path, admin(), "", req.body)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("POST %s status = %d, want %d: %s", req.path, resp.StatusCode, http.StatusCreated, body)
+		}
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	srv := newTestServer(t)
+	seed(t, srv)
+
+	user := bearer(t, model.RoleUser, ownUser)
+	support := bearer(t, model.RoleSupport, "support@example.com")
+
+	// Запросы выполняются по порядку: обновления меняют версию подписки.
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		cred       credential
+		ifMatch    string
+		body       string
+		wantStatus int
+		wantETag   string
+	}{
+		{
+			name:       "create subscription",
+			method:     http.MethodPost,
+			path:       "/api/v1/subscriptions",
+			cred:       admin(),
+			body:       subscriptionBody(ownUser, "Yandex Plus"),
+			wantStatus: http.StatusCreated,
+			wantETag:   model.ETag(1),
+		},
+		{
+			name:       "create subscription with bad body",
+			method:     http.MethodPost,
+			path:       "/api/v1/subscriptions",
+			cred:       admin(),
+			body:       `{"service_name":`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "read subscription",
+			method:     http.MethodGet,
+			path:       "/api/v1/subscriptions/1",
+			cred:       admin(),
+			wantStatus: http.StatusOK,
+			wantETag:   model.ETag(1),
+		},
+		{
+			name:       "read missing subscription",
+			method:     http.MethodGet,
+			path:       "/api/v1/subscriptions/100",
+			cred:       admin(),
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "read subscription with bad ID",
+			method:     http.MethodGet,
+			path:       "/api/v1/subscriptions/abc",
+			cred:       admin(),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "update with stale If-Match",
+			method:     http.MethodPut,
+			path:       "/api/v1/subscriptions/1",
+			cred:       admin(),
+			ifMatch:    model.ETag(5),
+			body:       subscriptionBody(ownUser, "Netflix"),
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:       "update with If-Match",
+			method:     http.MethodPut,
+			path:       "/api/v1/subscriptions/1",
+			cred:       admin(),
+			ifMatch:    model.ETag(1),
+			body:       subscriptionBody(ownUser, "Netflix"),
+			wantStatus: http.StatusOK,
+			wantETag:   model.ETag(2),
+		},
+		{
+			name:       "no credentials",
+			method:     http.MethodGet,
+			path:       "/api/v1/subscriptions/1",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "invalid token",
+			method:     http.MethodGet,
+			path:       "/api/v1/subscriptions/1",
+			cred:       credential{header: "Authorization", value: "Bearer a.b.c"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "user reads own subscription",
+			method:     http.MethodGet,
+			path:       "/api/v1/subscriptions/1",
+			cred:       user,
+			wantStatus: http.StatusOK,
+			wantETag:   model.ETag(2),
+		},
+		{
+			name:       "user reads other subscription",
+			method:     http.MethodGet,
+			path:       "/api/v1/subscriptions/2",
+			cred:       user,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "user creates subscription for other",
+			method:     http.MethodPost,
+			path:       "/api/v1/subscriptions",
+			cred:       user,
+			body:       subscriptionBody(otherUser, "Netflix"),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "user creates user",
+			method:     http.MethodPost,
+			path:       "/api/v1/users",
+			cred:       user,
+			body:       `{"email":"new@example.com"}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "support reads other subscription",
+			method:     http.MethodGet,
+			path:       "/api/v1/subscriptions/2",
+			cred:       support,
+			wantStatus: http.StatusOK,
+			wantETag:   model.ETag(1),
+		},
+		{
+			name:       "support hard deletes subscription",
+			method:     http.MethodDelete,
+			path:       "/api/v1/subscriptions/2?hard=true",
+			cred:       support,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "support deletes user",
+			method:     http.MethodDelete,
+			path:       "/api/v1/users/" + otherUser,
+			cred:       support,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "support lists API keys",
+			method:     http.MethodGet,
+			path:       "/api/v1/admin/api-keys",
+			cred:       support,
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := do(t, srv, tt.method, tt.path, tt.cred, tt.ifMatch, tt.body)
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+
+			if etag := resp.Header.Get("ETag"); tt.wantETag != "" && etag != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", etag, tt.wantETag)
+			}
+		})
+	}
+}
+
+func TestListCursor(t *testing.T) {
+	srv := newTestServer(t)
+	seed(t, srv)
+
+	// page Страница списка подписок.
+	type page struct {
+		Subscriptions []model.Subscription `json:"subscriptions"`
+		NextCursor    string               `json:"next_cursor"`
+	}
+
+	var ids []int64
+
+	cursor := ""
+
+	for range 3 {
+		path := "/api/v1/subscriptions?limit=1"
+		if cursor != "" {
+			path += "&cursor=" + url.QueryEscape(cursor)
+		}
+
+		resp, body := do(t, srv, http.MethodGet, path, admin(), "", "")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want %d: %s", path, resp.StatusCode, http.StatusOK, body)
+		}
+
+		var p page
+		if err := json.Unmarshal(body, &p); err != nil {
+			t.Fatalf("invalid page %s: %v", body, err)
+		}
+
+		for _, sub := range p.Subscriptions {
+			ids = append(ids, sub.ID)
+		}
+
+		if cursor = p.NextCursor; cursor == "" {
+			break
+		}
+	}
+
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("subscription IDs = %v, want [1 2]", ids)
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "!!!"},
+		{
+			name:   "other sort field",
+			cursor: model.NewListCursor(&model.ListParams{Sort: "price"}, &model.Subscription{ID: 1}).Encode(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := "/api/v1/subscriptions?limit=1&cursor=" + url.QueryEscape(tt.cursor)
+
+			if resp, body := do(t, srv, http.MethodGet, path, admin(), "", ""); resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d: %s", resp.StatusCode, http.StatusBadRequest, body)
+			}
+		})
+	}
+}
+
+func TestAudit(t *testing.T) {
+	srv := newTestServer(t)
+	seed(t, srv)
+
+	support := bearer(t, model.RoleSupport, "support@example.com")
+	update := `{"service_name":"Spotify","price":500,"user_id":"` + otherUser + `","start_date":"07-2025"}`
+
+	resp, body := do(t, srv, http.MethodPut, "/api/v1/subscriptions/2", support, "", update)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("PUT status = %d, want %d: %s", resp.StatusCode, http.StatusOK, body)
+	}
+
+	// page Страница журнала изменений.
+	type page struct {
+		Events []model.SubscriptionEvent `json:"events"`
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		cred        credential
+		wantStatus  int
+		wantActions []string
+	}{
+		{
+			name:        "subscription history",
+			path:        "/api/v1/subscriptions/2/history",
+			cred:        admin(),
+			wantStatus:  http.StatusOK,
+			wantActions: []string{model.EventCreated, model.EventUpdated},
+		},
+		{
+			name:        "audit by support",
+			path:        "/api/v1/audit?actor=support@example.com",
+			cred:        admin(),
+			wantStatus:  http.StatusOK,
+			wantActions: []string{model.EventUpdated},
+		},
+		{
+			name:        "audit by admin",
+			path:        "/api/v1/audit?actor=admin",
+			cred:        admin(),
+			wantStatus:  http.StatusOK,
+			wantActions: []string{model.EventCreated, model.EventCreated},
+		},
+		{name: "audit with bad date", path: "/api/v1/audit?from=yesterday", cred: admin(), wantStatus: http.StatusBadRequest},
+		{
+			name:       "user reads audit",
+			path:       "/api/v1/audit",
+			cred:       bearer(t, model.RoleUser, ownUser),
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := do(t, srv, http.MethodGet, tt.path, tt.cred, "", "")
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var p page
+			if err := json.Unmarshal(body, &p); err != nil {
+				t.Fatalf("invalid page %s: %v", body, err)
+			}
+
+			actions := make([]string, 0, len(p.Events))
+			for _, event := range p.Events {
+				actions = append(actions, event.Action)
+			}
+
+			if !slices.Equal(actions, tt.wantActions) {
+				t.Errorf("actions = %v, want %v", actions, tt.wantActions)
+			}
+		})
+	}
+}
+
+func TestBatch(t *testing.T) {
+	srv := newTestServer(t)
+	seed(t, srv)
+
+	service := `{"name":"Kinopoisk","default_price":299,"currency":"RUB"}`
+
+	resp, body := do(t, srv, http.MethodPost, "/api/v1/services", admin(), "", service)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST service status = %d, want %d: %s", resp.StatusCode, http.StatusCreated, body)
+	}
+
+	// Подписки create без цены заполняются по каталогу, как в POST.
+	batch := `{"operations":[
+		{"op":"create","subscription":{"service_name":"kinopoisk","user_id":"` + ownUser + `","start_date":"07-2025"}},
+		{"op":"create","subscription":` + subscriptionBody(otherUser, "Kinopoisk") + `},
+		{"op":"create","subscription":{"service_name":"Okko","user_id":"` + ownUser + `","start_date":"07-2025"}},
+		{"op":"create","subscription":` + subscriptionBody(ownUser, "Netflix") + `},
+		{"op":"update","id":1,"version":5,"subscription":` + subscriptionBody(ownUser, "Netflix") + `},
+		{"op":"delete","id":2}
+	]}`
+
+	resp, body = do(t, srv, http.MethodPost, "/api/v1/subscriptions:batch", admin(), "", batch)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", resp.StatusCode, http.StatusOK, body)
+	}
+
+	var got struct {
+		Results []struct {
+			Status       int                 `json:"status"`
+			Subscription *model.Subscription `json:"subscription"`
+		} `json:"results"`
+		Succeeded int `json:"succeeded"`
+		Failed    int `json:"failed"`
+	}
+
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("invalid response %s: %v", body, err)
+	}
+
+	tests := []struct {
+		name        string
+		wantStatus  int
+		wantService string
+		wantPrice   model.Amount
+	}{
+		{name: "default price", wantStatus: http.StatusCreated, wantService: "Kinopoisk", wantPrice: 29900},
+		{name: "own price", wantStatus: http.StatusCreated, wantService: "Kinopoisk", wantPrice: 40000},
+		{name: "no price without default", wantStatus: http.StatusUnprocessableEntity},
+		{name: "overlap", wantStatus: http.StatusConflict},
+		{name: "stale version", wantStatus: http.StatusPreconditionFailed},
+		{name: "delete", wantStatus: http.StatusNoContent},
+	}
+
+	if len(got.Results) != len(tests) || got.Succeeded != 3 || got.Failed != 3 {
+		t.Fatalf("response = %s, want %d results, 3 succeeded", body, len(tests))
+	}
+
+	for i, tt := range tests {
+		res := got.Results[i]
+		if res.Status != tt.wantStatus {
+			t.Errorf("%s status = %d, want %d", tt.name, res.Status, tt.wantStatus)
+
+			continue
+		}
+
+		if tt.wantService != "" && (res.Subscription == nil ||
+			res.Subscription.ServiceName != tt.wantService || res.Subscription.Price != tt.wantPrice) {
+			t.Errorf("%s subscription = %+v, want %s for %d", tt.name, res.Subscription, tt.wantService, tt.wantPrice)
+		}
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	srv := newTestServer(t)
+	seed(t, srv)
+
+	row := "Okko,3.5," + ownUser + ",01-2024,01-2025"
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		cred       credential
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "export csv",
+			method:     http.MethodGet,
+			path:       "/api/v1/subscriptions/export?sort=-id",
+			cred:       admin(),
+			wantStatus: http.StatusOK,
+			wantBody:   "id,service_id,service_name,price,currency,billing_unit,billing_count,",
+		},
+		{
+			name:       "export jsonl with filter",
+			method:     http.MethodGet,
+			path:       "/api/v1/subscriptions/export?format=jsonl&service_name=spot",
+			cred:       admin(),
+			wantStatus: http.StatusOK,
+			wantBody:   `{"id":2,`,
+		},
+		{
+			name:       "export bad format",
+			method:     http.MethodGet,
+			path:       "/api/v1/subscriptions/export?format=xml",
+			cred:       admin(),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "export bad filter",
+			method:     http.MethodGet,
+			path:       "/api/v1/subscriptions/export?sort=color",
+			cred:       admin(),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "import csv",
+			method:     http.MethodPost,
+			path:       "/api/v1/subscriptions/import",
+			cred:       admin(),
+			body:       "service_name,price,user_id,start_date,end_date\n" + row + "\n" + row + "\n",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"accepted":1,"rejected":1,`,
+		},
+		{
+			name:       "import bad header",
+			method:     http.MethodPost,
+			path:       "/api/v1/subscriptions/import",
+			cred:       admin(),
+			body:       "service_name,color\n",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "user imports",
+			method:     http.MethodPost,
+			path:       "/api/v1/subscriptions/import?format=jsonl",
+			cred:       bearer(t, model.RoleUser, ownUser),
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := do(t, srv, tt.method, tt.path, tt.cred, "", tt.body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+
+			if !strings.HasPrefix(string(body), tt.wantBody) {
+				t.Errorf("body = %s, want prefix %s", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestCostRange(t *testing.T) {
+	srv := newTestServer(t)
+	seed(t, srv)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{
+			name:       "ten years",
+			path:       "/api/v1/subscriptions/cost?start_date=01-2016&end_date=12-2025",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "longer than ten years",
+			path:       "/api/v1/subscriptions/cost?start_date=12-2015&end_date=12-2025",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "spending longer than ten years",
+			path:       "/api/v1/users/" + ownUser + "/spending?start_date=01-2000",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := do(t, srv, http.MethodGet, tt.path, admin(), "", "")
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+
+			if tt.wantStatus == http.StatusBadRequest && !strings.Contains(string(body), `"cost_period_too_long"`) {
+				t.Errorf("body = %s, want code cost_period_too_long", body)
+			}
+		})
+	}
+}
+
+func TestTenant(t *testing.T) {
+	srv := newTestServer(t)
+	// Подписки без арендатора в ключе и токене создаются в tenant.Default.
+	seed(t, srv)
+
+	tests := []struct {
+		name       string
+		cred       credential
+		header     string
+		wantStatus int
+	}{
+		{name: "key without tenant", cred: admin(), wantStatus: http.StatusOK},
+		{name: "key with own tenant", cred: admin(), header: tenant.Default, wantStatus: http.StatusOK},
+		{name: "key with other tenant", cred: admin(), header: "acme", wantStatus: http.StatusForbidden},
+		{name: "token without tenant", cred: bearer(t, "support", "support"), wantStatus: http.StatusOK},
+		{name: "token of other tenant", cred: tenantBearer(t, "admin", "admin", "acme"), wantStatus: http.StatusNotFound},
+		{
+			name:       "token of other tenant with its header",
+			cred:       tenantBearer(t, "admin", "admin", "acme"),
+			header:     "acme",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "token of other tenant with default header",
+			cred:       tenantBearer(t, "admin", "admin", "acme"),
+			header:     tenant.Default,
+			wantStatus: http.StatusForbidden,
+		},
+		{name: "cross tenant token", cred: tenantBearer(t, "admin", "admin", auth.AnyTenant), wantStatus: http.StatusOK},
+		{
+			name:       "cross tenant token with header",
+			cred:       tenantBearer(t, "admin", "admin", auth.AnyTenant),
+			header:     "acme",
+			wantStatus: http.StatusNotFound,
+		},
+		{name: "invalid header", cred: admin(), header: "Bad Tenant", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/v1/subscriptions/1", nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			req.Header.Set(tt.cred.header, tt.cred.value)
+
+			if tt.header != "" {
+				req.Header.Set(tenant.Header, tt.header)
+			}
+
+			resp, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatalf("GET failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				body, _ := io.ReadAll(resp.Body)
+				t.Errorf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+		})
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	srv := newTestServer(t)
+	// Подписки seed создают сервисы 1 Netflix и 2 Spotify.
+	seed(t, srv)
+
+	user := bearer(t, model.RoleUser, ownUser)
+	support := bearer(t, model.RoleSupport, "support@example.com")
+
+	// Запросы выполняются по порядку: создается сервис 3 и подписка 3.
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		cred       credential
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "create service",
+			method:     http.MethodPost,
+			path:       "/api/v1/services",
+			cred:       support,
+			body:       `{"name":" Kinopoisk ","aliases":["KP"],"category":"video","default_price":299,"currency":"RUB"}`,
+			wantStatus: http.StatusCreated,
+			wantBody:   `"id":3`,
+		},
+		{
+			name:       "create service as user",
+			method:     http.MethodPost,
+			path:       "/api/v1/services",
+			cred:       user,
+			body:       `{"name":"Okko"}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "create service with taken alias",
+			method:     http.MethodPost,
+			path:       "/api/v1/services",
+			cred:       admin(),
+			body:       `{"name":"Okko","aliases":["kinopoisk"]}`,
+			wantStatus: http.StatusConflict,
+			wantBody:   `"service_exists"`,
+		},
+		{
+			name:       "list services by category",
+			method:     http.MethodGet,
+			path:       "/api/v1/services?category=video",
+			cred:       user,
+			wantStatus: http.StatusOK,
+			wantBody:   `"name":"Kinopoisk"`,
+		},
+		{
+			name:       "create subscription by alias",
+			method:     http.MethodPost,
+			path:       "/api/v1/subscriptions",
+			cred:       admin(),
+			body:       `{"service_name":"kp","user_id":"` + ownUser + `","start_date":"07-2025"}`,
+			wantStatus: http.StatusCreated,
+			wantBody:   `"service_name":"Kinopoisk","currency":"RUB"`,
+		},
+		{
+			name:       "rename service",
+			method:     http.MethodPut,
+			path:       "/api/v1/services/3",
+			cred:       admin(),
+			body:       `{"name":"Kinopoisk HD","aliases":["KP"],"category":"video","currency":"RUB"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "read renamed subscription",
+			method:     http.MethodGet,
+			path:       "/api/v1/subscriptions/3",
+			cred:       admin(),
+			wantStatus: http.StatusOK,
+			wantBody:   `"service_name":"Kinopoisk HD"`,
+		},
+		{
+			name:       "delete service in use",
+			method:     http.MethodDelete,
+			path:       "/api/v1/services/3",
+			cred:       admin(),
+			wantStatus: http.StatusConflict,
+			wantBody:   `"service_in_use"`,
+		},
+		{
+			name:       "delete service as support",
+			method:     http.MethodDelete,
+			path:       "/api/v1/services/3",
+			cred:       support,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "read missing service",
+			method:     http.MethodGet,
+			path:       "/api/v1/services/100",
+			cred:       admin(),
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		resp, body := do(t, srv, tt.method, tt.path, tt.cred, "", tt.body)
+		if resp.StatusCode != tt.wantStatus {
+			t.Fatalf("%s status = %d, want %d: %s", tt.name, resp.StatusCode, tt.wantStatus, body)
+		}
+
+		if !strings.Contains(string(body), tt.wantBody) {
+			t.Errorf("%s body = %s, want %s", tt.name, body, tt.wantBody)
+		}
+	}
+}
+
+func TestUsers(t *testing.T) {
+	srv := newTestServer(t)
+	// Пользователи ownUser и otherUser с подписками 1 и 2.
+	seed(t, srv)
+
+	user := bearer(t, model.RoleUser, ownUser)
+	support := bearer(t, model.RoleSupport, "support@example.com")
+	newUser := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+	// Запросы выполняются по порядку.
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		cred       credential
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "create user",
+			method:     http.MethodPost,
+			path:       "/api/v1/users",
+			cred:       support,
+			body:       `{"id":"` + newUser + `","email":"new@example.com"}`,
+			wantStatus: http.StatusCreated,
+			wantBody:   `"timezone":"UTC"`,
+		},
+		{
+			name:       "create user with taken email",
+			method:     http.MethodPost,
+			path:       "/api/v1/users",
+			cred:       admin(),
+			body:       `{"id":"9b2f3c1e-1d2a-4c5b-8e6f-7a8b9c0d1e2f","email":"NEW@example.com"}`,
+			wantStatus: http.StatusConflict,
+			wantBody:   `"user_exists"`,
+		},
+		{
+			name:       "create user as user",
+			method:     http.MethodPost,
+			path:       "/api/v1/users",
+			cred:       user,
+			body:       `{"id":"3f2504e0-4f89-11d3-9a0c-0305e82c3301","email":"mine@example.com"}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "list users as user",
+			method:     http.MethodGet,
+			path:       "/api/v1/users",
+			cred:       user,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "read own user",
+			method:     http.MethodGet,
+			path:       "/api/v1/users/" + ownUser,
+			cred:       user,
+			wantStatus: http.StatusOK,
+			wantBody:   `"email":"own@example.com"`,
+		},
+		{
+			name:       "update own user",
+			method:     http.MethodPut,
+			path:       "/api/v1/users/" + ownUser,
+			cred:       user,
+			body:       `{"email":"own@example.com","timezone":"Europe/Moscow"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `"timezone":"Europe/Moscow"`,
+		},
+		{
+			name:       "delete user with subscriptions",
+			method:     http.MethodDelete,
+			path:       "/api/v1/users/" + ownUser,
+			cred:       admin(),
+			wantStatus: http.StatusConflict,
+			wantBody:   `"user_in_use"`,
+		},
+		{
+			name:       "delete user",
+			method:     http.MethodDelete,
+			path:       "/api/v1/users/" + newUser,
+			cred:       admin(),
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "read deleted user",
+			method:     http.MethodGet,
+			path:       "/api/v1/users/" + newUser,
+			cred:       admin(),
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		resp, body := do(t, srv, tt.method, tt.path, tt.cred, "", tt.body)
+		if resp.StatusCode != tt.wantStatus {
+			t.Fatalf("%s status = %d, want %d: %s", tt.name, resp.StatusCode, tt.wantStatus, body)
+		}
+
+		if !strings.Contains(string(body), tt.wantBody) {
+			t.Errorf("%s body = %s, want %s", tt.name, body, tt.wantBody)
+		}
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	srv := newTestServer(t)
+	// Пользователи ownUser и otherUser с подписками 1 и 2.
+	seed(t, srv)
+
+	body := `{"name":"billing-cron","role":"user","user_id":"` + ownUser + `"}`
+
+	resp, data := do(t, srv, http.MethodPost, "/api/v1/admin/api-keys", bearer(t, model.RoleSupport, "support"), "", body)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("support create status = %d, want %d: %s", resp.StatusCode, http.StatusForbidden, data)
+	}
+
+	resp, data = do(t, srv, http.MethodPost, "/api/v1/admin/api-keys", admin(), "", body)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d: %s", resp.StatusCode, http.StatusCreated, data)
+	}
+
+	var created struct {
+		ID  int64  `json:"id"`
+		Key string `json:"key"`
+	}
+
+	if err := json.Unmarshal(data, &created); err != nil || !strings.HasPrefix(created.Key, auth.KeyPrefix) {
+		t.Fatalf("invalid response %s: %v", data, err)
+	}
+
+	key := credential{header: auth.KeyHeader, value: created.Key}
+	revoke := "/api/v1/admin/api-keys/" + strconv.FormatInt(created.ID, 10)
+
+	// Запросы выполняются по порядку: ключ действует до отзыва.
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		cred       credential
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "own subscription",
+			method:     http.MethodGet,
+			path:       "/api/v1/subscriptions/1",
+			cred:       key,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "other subscription",
+			method:     http.MethodGet,
+			path:       "/api/v1/subscriptions/2",
+			cred:       key,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "admin route",
+			method:     http.MethodGet,
+			path:       "/api/v1/admin/api-keys",
+			cred:       key,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "bearer key",
+			method:     http.MethodGet,
+			path:       "/api/v1/subscriptions/1",
+			cred:       credential{header: "Authorization", value: "Bearer " + created.Key},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "list keys",
+			method:     http.MethodGet,
+			path:       "/api/v1/admin/api-keys",
+			cred:       admin(),
+			wantStatus: http.StatusOK,
+			wantBody:   `"name":"billing-cron"`,
+		},
+		{
+			name:       "revoke",
+			method:     http.MethodDelete,
+			path:       revoke,
+			cred:       admin(),
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "revoke again",
+			method:     http.MethodDelete,
+			path:       revoke,
+			cred:       admin(),
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "revoked key",
+			method:     http.MethodGet,
+			path:       "/api/v1/subscriptions/1",
+			cred:       key,
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		resp, body := do(t, srv, tt.method, tt.path, tt.cred, "", "")
+		if resp.StatusCode != tt.wantStatus {
+			t.Fatalf("%s status = %d, want %d: %s", tt.name, resp.StatusCode, tt.wantStatus, body)
+		}
+
+		if !strings.Contains(string(body), tt.wantBody) {
+			t.Errorf("%s body = %s, want %s", tt.name, body, tt.wantBody)
+		}
+
+		if strings.Contains(string(body), created.Key) {
+			t.Errorf("%s body = %s, want no key", tt.name, body)
+		}
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/SHSanderland/EffMobTest/pkg/model"
@@ -17,21 +19,45 @@ import (
 
 var (
 	ErrInvalidSubID       = errors.New("invalid subscription ID")
+	ErrInvalidServiceID   = errors.New("invalid service ID")
+	ErrInvalidKeyID       = errors.New("invalid API key ID")
 	ErrInvalidUserID      = errors.New("invalid user ID")
 	ErrInvalidServiceName = errors.New("invalid service name")
 	ErrInvalidDate        = errors.New("invalid date")
+	ErrInvalidGroupBy     = errors.New("invalid group by")
+	ErrInvalidPrice       = errors.New("invalid price")
+	ErrInvalidSort        = errors.New("invalid sort")
+	ErrInvalidLimit       = errors.New("invalid limit")
+	ErrInvalidIfMatch     = errors.New("invalid If-Match header")
+	ErrInvalidFlag        = errors.New("invalid boolean parameter")
+	ErrInvalidFormat      = errors.New("invalid format")
+	ErrInvalidCurrency    = errors.New("invalid currency")
 )
 
 // SubscriptionService Интерефейс со всеми методами, которые используют
 // хендлеры.
 type SubscriptionService interface {
-	CheckBody(sub *model.Subscription) bool
-	CheckSubscription(ctx context.Context, sub *model.Subscription) (bool, error)
-	CheckSubscriptionID(ctx context.Context, subID int64) (bool, error)
-	CheckSubscriptionForUpdate(ctx context.Context, subID int64, sub *model.Subscription) (bool, error)
+	CheckBody(sub *model.Subscription) error
+	CheckService(svc *model.Service) error
+	CheckUser(user *model.User) error
+	CheckAPIKey(key *model.APIKey) error
+	CheckOperation(op *model.BatchOperation) error
+	CheckSubscriptionID(ctx context.Context, subID int64, includeDeleted bool) (bool, error)
 	GetSubID(r *http.Request) (int64, error)
-	GetUserIDAndServiceName(r *http.Request) (uuid.UUID, string, error)
+	GetServiceID(r *http.Request) (int64, error)
+	GetServiceCategory(r *http.Request) string
+	GetKeyID(r *http.Request) (int64, error)
+	GetUserID(r *http.Request) (uuid.UUID, error)
+	GetUserListParams(r *http.Request, user *model.User) (*model.ListParams, error)
+	GetSpendingParams(r *http.Request, user *model.User) (*model.CostParams, error)
+	GetIfMatch(r *http.Request) (int64, error)
+	GetEffectiveFrom(r *http.Request) (*time.Time, error)
+	GetQueryFlag(r *http.Request, key string) (bool, error)
+	GetFormat(r *http.Request) (string, error)
+	GetRatePair(r *http.Request) (string, string, error)
+	GetListParams(r *http.Request) (*model.ListParams, error)
 	GetCostParams(r *http.Request) (*model.CostParams, error)
+	GetEventParams(r *http.Request) (*model.EventParams, error)
 }
 
 // Service Структура-помощник хендлеров. В данном сервисе необходима
@@ -45,111 +71,516 @@ func InitService(db storage.Storage) *Service {
 	return &Service{database: db}
 }
 
-// CheckBody Проверка модели.
-func (s *Service) CheckBody(sub *model.Subscription) bool {
-	return model.IsValidSubscription(sub)
+// CheckBody Проверка модели. Возвращает *model.ValidationError
+// с нарушениями по полям.
+func (s *Service) CheckBody(sub *model.Subscription) error {
+	return model.ValidateSubscription(sub)
 }
 
-// CheckSubscription Проверка на существование подписки
-// в базе данных по телу подписки.
-func (s *Service) CheckSubscription(ctx context.Context, sub *model.Subscription) (bool, error) {
-	return s.database.CheckSubscription(ctx, sub)
+// CheckService Проверка сервиса каталога. Возвращает
+// *model.ValidationError с нарушениями по полям.
+func (s *Service) CheckService(svc *model.Service) error {
+	return model.ValidateService(svc)
 }
 
-// CheckSubscriptionID Проверка на существование подписки
-// в базе данных по ID подписки.
-func (s *Service) CheckSubscriptionID(ctx context.Context, subID int64) (bool, error) {
-	return s.database.CheckSubscriptionID(ctx, subID)
+// CheckUser Проверка пользователя. Возвращает *model.ValidationError
+// с нарушениями по полям.
+func (s *Service) CheckUser(user *model.User) error {
+	return model.ValidateUser(user)
 }
 
-// CheckSubscriptionForUpdate Проверка подписки на валидность обнавления.
-func (s *Service) CheckSubscriptionForUpdate(ctx context.Context, subID int64, sub *model.Subscription) (bool, error) {
-	return s.database.CheckSubscriptionForUpdate(ctx, subID, sub)
+// CheckAPIKey Проверка API-ключа перед созданием.
+func (s *Service) CheckAPIKey(key *model.APIKey) error {
+	return model.ValidateAPIKey(key)
+}
+
+// CheckOperation Проверка операции пакетного запроса. Подписка
+// проверяется так же, как тело CreateSubscription и UpdateSubscription.
+func (s *Service) CheckOperation(op *model.BatchOperation) error {
+	if err := model.ValidateBatchOperation(op); err != nil {
+		return err
+	}
+
+	if op.Op == model.BatchDelete {
+		return nil
+	}
+
+	return s.CheckBody(op.Subscription)
+}
+
+// CheckSubscriptionID Проверка на существование подписки
+// в базе данных по ID подписки.
+func (s *Service) CheckSubscriptionID(ctx context.Context, subID int64, includeDeleted bool) (bool, error) {
+	return s.database.CheckSubscriptionID(ctx, subID, includeDeleted)
 }
 
 // GetSubID Получение ID подписки из URL.
 func (s *Service) GetSubID(r *http.Request) (int64, error) {
-	subID := chi.URLParam(r, "id")
+	return parseURLID(r, ErrInvalidSubID)
+}
+
+// GetServiceID Получение ID сервиса каталога из URL.
+func (s *Service) GetServiceID(r *http.Request) (int64, error) {
+	return parseURLID(r, ErrInvalidServiceID)
+}
+
+// GetKeyID Получение ID API-ключа из URL.
+func (s *Service) GetKeyID(r *http.Request) (int64, error) {
+	return parseURLID(r, ErrInvalidKeyID)
+}
+
+// GetServiceCategory Получение необязательной категории сервисов
+// из параметра category. Пустая строка означает все категории.
+func (s *Service) GetServiceCategory(r *http.Request) string {
+	return strings.TrimSpace(r.URL.Query().Get("category"))
+}
 
-	intsubID, err := strconv.ParseInt(subID, 10, 64)
+// GetUserID Получение UUID пользователя из URL.
+func (s *Service) GetUserID(r *http.Request) (uuid.UUID, error) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		return intsubID, fmt.Errorf("%w: %w", ErrInvalidSubID, err)
+		return uuid.Nil, fmt.Errorf("%w: %w", ErrInvalidUserID, err)
 	}
 
-	if intsubID < 0 {
-		return intsubID, ErrInvalidSubID
+	return userID, nil
+}
+
+// GetIfMatch Получение ожидаемой версии подписки из заголовка If-Match.
+// Без заголовка или со значением "*" возвращает storage.AnyVersion,
+// то есть версия не проверяется. Слабый ETag никогда не совпадает
+// при строгом сравнении (RFC 9110), поэтому для него возвращается
+// storage.ErrVersion.
+func (s *Service) GetIfMatch(r *http.Request) (int64, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return storage.AnyVersion, nil
 	}
 
-	return intsubID, nil
+	if strings.HasPrefix(value, "W/") {
+		return 0, storage.ErrVersion
+	}
+
+	tag, err := strconv.Unquote(value)
+	if err != nil || !strings.HasPrefix(value, `"`) {
+		return 0, ErrInvalidIfMatch
+	}
+
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, ErrInvalidIfMatch
+	}
+
+	return version, nil
+}
+
+// GetEffectiveFrom Получение необязательной даты effective_from из URL,
+// с которой действует новая цена подписки. MM-YYYY означает первое
+// число месяца.
+func (s *Service) GetEffectiveFrom(r *http.Request) (*time.Time, error) {
+	from, _, err := parseQueryDateRange(r.URL.Query().Get("effective_from"))
+
+	return from, err
+}
+
+// GetQueryFlag Получение необязательного логического параметра
+// из URL. Отсутствующий параметр равен false.
+func (s *Service) GetQueryFlag(r *http.Request, key string) (bool, error) {
+	return parseQueryBool(r.URL.Query().Get(key))
+}
+
+// GetFormat Получение формата импорта или экспорта из параметра
+// format. По умолчанию csv.
+func (s *Service) GetFormat(r *http.Request) (string, error) {
+	return ParseFormat(r.URL.Query().Get("format"))
+}
+
+// ParseFormat Проверка формата импорта или экспорта. Пустая строка
+// означает csv.
+func ParseFormat(format string) (string, error) {
+	switch format {
+	case "", model.FormatCSV:
+		return model.FormatCSV, nil
+	case model.FormatJSONL:
+		return model.FormatJSONL, nil
+	default:
+		return "", ErrInvalidFormat
+	}
 }
 
-// GetUserIDAndServiceName Получение UUID пользователя и
-// название подписки из URL.
-func (s *Service) GetUserIDAndServiceName(r *http.Request) (uuid.UUID, string, error) {
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		return uuid.Nil, "", ErrInvalidUserID
+// GetRatePair Получение пары валют курса из URL.
+// Валюты должны быть поддерживаемыми и различаться.
+func (s *Service) GetRatePair(r *http.Request) (string, string, error) {
+	from, to := chi.URLParam(r, "from"), chi.URLParam(r, "to")
+
+	if !model.IsCurrency(from) || !model.IsCurrency(to) || from == to {
+		return "", "", ErrInvalidCurrency
 	}
 
-	userUUID, err := uuid.Parse(userID)
+	return from, to, nil
+}
+
+// GetListParams Получение параметров из URL для
+// структуры ListParams, см. ParseListParams.
+func (s *Service) GetListParams(r *http.Request) (*model.ListParams, error) {
+	return ParseListParams(r.URL.Query())
+}
+
+// ParseListParams Разбор параметров query в ListParams.
+// Все параметры необязательные. Сортировка задается именем поля,
+// "-" в начале означает сортировку по убыванию. include_deleted
+// принимает true, false или only для выборки только удаленных подписок.
+func ParseListParams(query url.Values) (*model.ListParams, error) {
+	list := model.ListParams{
+		ServiceName: query.Get("service_name"),
+		Sort:        "id",
+		Limit:       model.DefaultListLimit,
+	}
+
+	if userID := query.Get("user_id"); userID != "" {
+		userUUID, err := uuid.Parse(userID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidUserID, err)
+		}
+
+		list.UserID = &userUUID
+	}
+
+	var err error
+
+	if list.Currency, err = parseQueryCurrency(query.Get("currency")); err != nil {
+		return nil, err
+	}
+
+	if list.MinPrice, err = parseQueryAmount(query.Get("min_price"), list.Currency); err != nil {
+		return nil, err
+	}
+
+	if list.MaxPrice, err = parseQueryAmount(query.Get("max_price"), list.Currency); err != nil {
+		return nil, err
+	}
+
+	if list.ActiveFrom, list.ActiveTo, err = parseQueryDateRange(query.Get("active_at")); err != nil {
+		return nil, err
+	}
+
+	if list.StartFrom, _, err = parseQueryDateRange(query.Get("start_from")); err != nil {
+		return nil, err
+	}
+
+	if _, list.StartTo, err = parseQueryDateRange(query.Get("start_to")); err != nil {
+		return nil, err
+	}
+
+	if list.EndFrom, _, err = parseQueryDateRange(query.Get("end_from")); err != nil {
+		return nil, err
+	}
+
+	if _, list.EndTo, err = parseQueryDateRange(query.Get("end_to")); err != nil {
+		return nil, err
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		list.Desc = strings.HasPrefix(sort, "-")
+		list.Sort = strings.TrimPrefix(sort, "-")
+
+		if !model.SortFields[list.Sort] {
+			return nil, ErrInvalidSort
+		}
+	}
+
+	limit, err := parseQueryInt(query.Get("limit"), ErrInvalidLimit)
 	if err != nil {
-		return userUUID, "", fmt.Errorf("%w: %w", ErrInvalidUserID, err)
+		return nil, err
+	}
+
+	if limit != nil {
+		if *limit <= 0 || *limit > model.MaxListLimit {
+			return nil, ErrInvalidLimit
+		}
+
+		list.Limit = *limit
+	}
+
+	switch includeDeleted := query.Get("include_deleted"); includeDeleted {
+	case "only":
+		list.Deleted = model.DeletedOnly
+	default:
+		include, err := parseQueryBool(includeDeleted)
+		if err != nil {
+			return nil, err
+		}
+
+		if include {
+			list.Deleted = model.DeletedInclude
+		}
 	}
 
-	serviceName := r.URL.Query().Get("service_name")
-	if serviceName == "" {
-		return userUUID, serviceName, ErrInvalidServiceName
+	if cursor := query.Get("cursor"); cursor != "" {
+		if list.Cursor, err = model.DecodeListCursor(cursor, &list); err != nil {
+			return nil, err
+		}
 	}
 
-	return userUUID, serviceName, nil
+	return &list, nil
 }
 
 // GetCostParams Получение параметров из URL для
-// структуры CostParams.
+// структуры CostParams. Все параметры необязательные,
+// service_name может передаваться несколько раз, currency задает
+// валюту, в которую пересчитываются суммы. Период не длиннее
+// model.MaxCostMonths месяцев.
 func (s *Service) GetCostParams(r *http.Request) (*model.CostParams, error) {
-	serviceName := r.URL.Query().Get("service_name")
-	userID := r.URL.Query().Get("user_id")
-	startDateStr := r.URL.Query().Get("start_date")
-	endDateStr := r.URL.Query().Get("end_date")
-	layout := "01-2006"
+	query := r.URL.Query()
+	cost := model.CostParams{GroupBy: query.Get("group_by")}
+
+	for _, serviceName := range query["service_name"] {
+		if serviceName == "" {
+			return nil, ErrInvalidServiceName
+		}
+
+		cost.ServiceNames = append(cost.ServiceNames, serviceName)
+	}
 
-	if serviceName == "" {
-		return nil, ErrInvalidServiceName
+	if userID := query.Get("user_id"); userID != "" {
+		userUUID, err := uuid.Parse(userID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidUserID, err)
+		}
+
+		cost.UserID = &userUUID
 	}
 
-	if userID == "" {
-		return nil, ErrInvalidUserID
+	var err error
+
+	if cost.StartDate, _, err = parseQueryDateRange(query.Get("start_date")); err != nil {
+		return nil, err
 	}
 
-	userUUID, err := uuid.Parse(userID)
+	if _, cost.EndDate, err = parseQueryDateRange(query.Get("end_date")); err != nil {
+		return nil, err
+	}
+
+	if cost.StartDate != nil && cost.EndDate != nil {
+		if cost.EndDate.Before(*cost.StartDate) {
+			return nil, ErrInvalidDate
+		}
+
+		if err := model.CheckCostRange(*cost.StartDate, *cost.EndDate); err != nil {
+			return nil, err
+		}
+	}
+
+	if cost.IncludeDeleted, err = parseQueryBool(query.Get("include_deleted")); err != nil {
+		return nil, err
+	}
+
+	if cost.Currency, err = parseQueryCurrency(query.Get("currency")); err != nil {
+		return nil, err
+	}
+
+	switch cost.GroupBy {
+	case "", model.GroupByUser, model.GroupByService, model.GroupByMonth:
+	default:
+		return nil, ErrInvalidGroupBy
+	}
+
+	return &cost, nil
+}
+
+// GetUserListParams Получение параметров списка подписок пользователя
+// user. Параметры те же, что у GetListParams, кроме user_id. Без active_at
+// и фильтров по датам возвращаются подписки, активные сегодня
+// в часовом поясе пользователя.
+func (s *Service) GetUserListParams(r *http.Request, user *model.User) (*model.ListParams, error) {
+	list, err := s.GetListParams(r)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrInvalidUserID, err)
+		return nil, err
 	}
 
-	if startDateStr == "" {
-		return nil, ErrInvalidDate
+	list.UserID = &user.ID
+
+	if list.ActiveFrom == nil && list.StartFrom == nil && list.StartTo == nil &&
+		list.EndFrom == nil && list.EndTo == nil {
+		today := user.Today(time.Now())
+		list.ActiveFrom, list.ActiveTo = &today, &today
 	}
 
-	startDate, err := time.Parse(layout, startDateStr)
+	return list, nil
+}
+
+// GetSpendingParams Получение параметров расходов пользователя user.
+// Параметры те же, что у GetCostParams, кроме user_id и group_by.
+// По умолчанию суммы считаются в валюте пользователя за последние
+// 12 месяцев, включая текущий месяц в часовом поясе пользователя.
+func (s *Service) GetSpendingParams(r *http.Request, user *model.User) (*model.CostParams, error) {
+	cost, err := s.GetCostParams(r)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrInvalidDate, err)
+		return nil, err
+	}
+
+	cost.UserID = &user.ID
+	cost.GroupBy = ""
+
+	if cost.Currency == "" {
+		cost.Currency = user.Currency
+	}
+
+	today := user.Today(time.Now())
+
+	if cost.EndDate == nil {
+		end := time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+		cost.EndDate = &end
+	}
+
+	if cost.StartDate == nil {
+		start := time.Date(cost.EndDate.Year(), cost.EndDate.Month()-11, 1, 0, 0, 0, 0, time.UTC)
+		cost.StartDate = &start
+	}
+
+	if cost.EndDate.Before(*cost.StartDate) {
+		return nil, ErrInvalidDate
+	}
+
+	if err := model.CheckCostRange(*cost.StartDate, *cost.EndDate); err != nil {
+		return nil, err
+	}
+
+	return cost, nil
+}
+
+// GetEventParams Получение параметров из URL для
+// структуры EventParams. Все параметры необязательные,
+// границы периода from и to передаются в формате RFC 3339.
+func (s *Service) GetEventParams(r *http.Request) (*model.EventParams, error) {
+	query := r.URL.Query()
+	events := model.EventParams{
+		Actor: query.Get("actor"),
+		Limit: model.DefaultListLimit,
+	}
+
+	for key, date := range map[string]**time.Time{"from": &events.From, "to": &events.To} {
+		value := query.Get(key)
+		if value == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidDate, err)
+		}
+
+		*date = &t
 	}
 
-	if endDateStr == "" {
+	if events.From != nil && events.To != nil && events.To.Before(*events.From) {
 		return nil, ErrInvalidDate
 	}
 
-	endDate, err := time.Parse(layout, endDateStr)
+	limit, err := parseQueryInt(query.Get("limit"), ErrInvalidLimit)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrInvalidDate, err)
+		return nil, err
 	}
 
-	cost := model.CostParams{
-		ServiceName: serviceName,
-		UserID:      userUUID,
-		StartDate:   startDate,
-		EndDate:     &endDate,
+	if limit != nil {
+		if *limit <= 0 || *limit > model.MaxListLimit {
+			return nil, ErrInvalidLimit
+		}
+
+		events.Limit = *limit
 	}
 
-	return &cost, nil
+	if cursor := query.Get("cursor"); cursor != "" {
+		afterID, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || afterID <= 0 {
+			return nil, model.ErrInvalidCursor
+		}
+
+		events.AfterID = afterID
+	}
+
+	return &events, nil
+}
+
+// parseURLID Получение неотрицательного ID из параметра id в URL.
+func parseURLID(r *http.Request, errInvalid error) (int64, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return id, fmt.Errorf("%w: %w", errInvalid, err)
+	}
+
+	if id < 0 {
+		return id, errInvalid
+	}
+
+	return id, nil
+}
+
+// parseQueryInt Получение необязательного числа из параметра URL.
+func parseQueryInt(value string, errInvalid error) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalid, err)
+	}
+
+	return &n, nil
+}
+
+// parseQueryAmount Получение необязательной суммы из параметра URL
+// в валюте currency, без валюты - с двумя знаками после точки.
+func parseQueryAmount(value, currency string) (*model.Amount, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	amount, err := model.ParseAmount(value, currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPrice, err)
+	}
+
+	return &amount, nil
+}
+
+// parseQueryCurrency Получение необязательного кода валюты
+// из параметра URL.
+func parseQueryCurrency(value string) (string, error) {
+	if value != "" && !model.IsCurrency(value) {
+		return "", ErrInvalidCurrency
+	}
+
+	return value, nil
+}
+
+// parseQueryBool Получение необязательного логического значения
+// из параметра URL.
+func parseQueryBool(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrInvalidFlag, err)
+	}
+
+	return b, nil
+}
+
+// parseQueryDateRange Получение необязательной даты в формате MM-YYYY
+// или YYYY-MM-DD из параметра URL. Возвращает первый и последний день,
+// которые обозначает дата: для MM-YYYY это весь месяц.
+func parseQueryDateRange(value string) (*time.Time, *time.Time, error) {
+	if value == "" {
+		return nil, nil, nil
+	}
+
+	from, to, err := model.ParseDateRange(value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidDate, err)
+	}
+
+	return &from, &to, nil
 }
@@ -0,0 +1,725 @@
+// Пакет memory для хранения подписок в памяти процесса.
+// Повторяет поведение пакета psql и нужен для тестов и локального
+// запуска без PostgreSQL. Данные теряются при остановке сервиса.
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/SHSanderland/EffMobTest/pkg/tenant"
+	"github.com/google/uuid"
+)
+
+// record Подписка вместе с разобранными датами и историей цен.
+// Сохраненная запись не изменяется, при изменении подписки в map
+// кладется новая запись.
+type record struct {
+	sub    model.Subscription
+	start  time.Time
+	end    *time.Time
+	prices model.PriceHistory
+}
+
+// Storage Структура хранения подписок в памяти. Безопасна
+// для использования из нескольких горутин. Данные каждого арендатора
+// хранятся отдельно, поэтому запрос видит только данные арендатора
+// из своего контекста.
+type Storage struct {
+	mu      sync.RWMutex
+	log     *slog.Logger
+	ids     *sequences
+	tenants map[string]*tenantData
+	keys    map[int64]*model.APIKey
+}
+
+// sequences Счетчики ID, общие для всех арендаторов,
+// как последовательности в psql.
+type sequences struct {
+	lastID        int64
+	lastEventID   int64
+	lastServiceID int64
+	lastKeyID     int64
+}
+
+// tenantData Данные одного арендатора. Методы tenantData
+// вызываются под блокировкой Storage.mu.
+type tenantData struct {
+	*sequences
+	subs        map[int64]*record
+	events      []*model.SubscriptionEvent
+	rates       map[[2]string]*model.Rate
+	services    map[int64]*model.Service
+	serviceKeys map[string]int64
+	users       map[uuid.UUID]*model.User
+	userEmails  map[string]uuid.UUID
+}
+
+// InitStorage Инициализация хранилища в памяти.
+func InitStorage(log *slog.Logger) *Storage {
+	return &Storage{
+		log:     log,
+		ids:     &sequences{},
+		tenants: make(map[string]*tenantData),
+		keys:    make(map[int64]*model.APIKey),
+	}
+}
+
+// newTenantData Пустые данные арендатора.
+func newTenantData(ids *sequences) *tenantData {
+	return &tenantData{
+		sequences:   ids,
+		subs:        make(map[int64]*record),
+		rates:       make(map[[2]string]*model.Rate),
+		services:    make(map[int64]*model.Service),
+		serviceKeys: make(map[string]int64),
+		users:       make(map[uuid.UUID]*model.User),
+		userEmails:  make(map[string]uuid.UUID),
+	}
+}
+
+// read Данные арендатора из контекста для чтения. У арендатора
+// без данных возвращаются пустые данные, которые не сохраняются.
+// Возвращает tenant.ErrNoTenant, если арендатор не задан.
+// Вызывать под блокировкой s.mu.
+func (s *Storage) read(ctx context.Context) (*tenantData, error) {
+	id, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if t, ok := s.tenants[id]; ok {
+		return t, nil
+	}
+
+	return newTenantData(s.ids), nil
+}
+
+// write Данные арендатора из контекста для изменения, данные
+// нового арендатора создаются. Возвращает tenant.ErrNoTenant, если
+// арендатор не задан. Вызывать под блокировкой s.mu на запись.
+func (s *Storage) write(ctx context.Context) (*tenantData, error) {
+	id, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := s.tenants[id]
+	if !ok {
+		t = newTenantData(s.ids)
+		s.tenants[id] = t
+	}
+
+	return t, nil
+}
+
+// CreateSubscription Создание подписки в памяти.
+// Возвращает созданную подписку с ID и временем создания.
+func (s *Storage) CreateSubscription(ctx context.Context, sub *model.Subscription) (*model.Subscription, error) {
+	const fn = "memory.CreateSubscription"
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.String("userID", sub.UserID.String()),
+	)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := t.createSubscription(ctx, sub)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to create subscription", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	log.InfoContext(ctx, "Subscription is created!", slog.Int64("subID", created.ID))
+
+	return created, nil
+}
+
+// ReadSubscription Чтение подписки из памяти.
+func (s *Storage) ReadSubscription(
+	ctx context.Context, subID int64, includeDeleted bool,
+) (*model.Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rec, ok := t.subs[subID]
+	if !ok || (!includeDeleted && rec.sub.DeletedAt != nil) {
+		return nil, storage.ErrSubNotFound
+	}
+
+	sub := rec.sub
+
+	return &sub, nil
+}
+
+// UpdateSubscription Обновление подписки в памяти.
+// Проверка версии, изменение через update и запись выполняются
+// под одной блокировкой, как в транзакции psql.
+func (s *Storage) UpdateSubscription(
+	ctx context.Context, subID, version int64, priceFrom *time.Time, update storage.UpdateFunc,
+) (*model.Subscription, error) {
+	const fn = "memory.UpdateSubscription"
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.Int64("subID", subID),
+	)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := t.updateSubscription(ctx, subID, version, priceFrom, update)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to update subscription", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	log.InfoContext(ctx, "Subscription is update!", slog.Int64("version", updated.Version))
+
+	return updated, nil
+}
+
+// DeleteSubscription Удаление подписки из памяти.
+// По умолчанию подписка удаляется мягко, с hard - навсегда.
+func (s *Storage) DeleteSubscription(ctx context.Context, subID, version int64, hard bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.write(ctx)
+	if err != nil {
+		return err
+	}
+
+	return t.deleteSubscription(ctx, subID, version, hard)
+}
+
+// RestoreSubscription Восстановление мягко удаленной подписки в памяти.
+func (s *Storage) RestoreSubscription(ctx context.Context, subID, version int64) (*model.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rec, err := t.lockSubscription(subID, version, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if rec.sub.DeletedAt == nil {
+		return nil, storage.ErrNotDeleted
+	}
+
+	if err := t.checkOverlap(ctx, subID, rec); err != nil {
+		return nil, err
+	}
+
+	restored := *rec
+	restored.sub.DeletedAt = nil
+	restored.sub.UpdatedAt = time.Now().UTC()
+	restored.sub.Version++
+	t.subs[subID] = &restored
+	t.writeEvent(storage.NewEvent(ctx, model.EventRestored, subID, &rec.sub, &restored.sub))
+
+	sub := restored.sub
+
+	return &sub, nil
+}
+
+// GetListSubscription Получение страницы списка подписок из памяти.
+// Сортировка и курсор работают так же, как в psql.
+func (s *Storage) GetListSubscription(
+	ctx context.Context, filter *model.ListParams,
+) (*model.SubscriptionPage, error) {
+	var cursorValue any
+
+	if filter.Cursor != nil {
+		value, err := filter.Cursor.Arg(filter.Sort)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", model.ErrInvalidCursor, err)
+		}
+
+		cursorValue = value
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	recs := make([]*record, 0, len(t.subs))
+
+	for _, rec := range t.subs {
+		if !matchList(rec, filter) {
+			continue
+		}
+
+		if filter.Cursor != nil {
+			c := compareSort(sortValue(rec, filter.Sort), rec.sub.ID, cursorValue, filter.Cursor.ID)
+			if filter.Desc {
+				c = -c
+			}
+
+			if c <= 0 {
+				continue
+			}
+		}
+
+		recs = append(recs, rec)
+	}
+
+	slices.SortFunc(recs, func(a, b *record) int {
+		c := compareSort(sortValue(a, filter.Sort), a.sub.ID, sortValue(b, filter.Sort), b.sub.ID)
+		if filter.Desc {
+			return -c
+		}
+
+		return c
+	})
+
+	page := model.SubscriptionPage{Subscriptions: []*model.Subscription{}}
+
+	for _, rec := range recs {
+		if len(page.Subscriptions) == filter.Limit {
+			last := page.Subscriptions[len(page.Subscriptions)-1]
+			page.NextCursor = model.NewListCursor(filter, last).Encode()
+
+			break
+		}
+
+		sub := rec.sub
+		page.Subscriptions = append(page.Subscriptions, &sub)
+	}
+
+	return &page, nil
+}
+
+// ExportSubscriptions Выгрузка подписок из памяти по фильтрам списка.
+// Подписки копируются под блокировкой, export вызывается без нее.
+func (s *Storage) ExportSubscriptions(
+	ctx context.Context, filter *model.ListParams, export storage.ExportFunc,
+) error {
+	all := *filter
+	all.Limit = math.MaxInt
+
+	page, err := s.GetListSubscription(ctx, &all)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range page.Subscriptions {
+		if err := export(sub); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CostSubscription Подсчет суммы, потраченной на подписки за период.
+// Стоимость считается по истории цен, как в psql.
+func (s *Storage) CostSubscription(ctx context.Context, filter *model.CostParams) (*model.CostReport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		sources    []*model.CostSource
+		serviceIDs map[int64]bool
+	)
+
+	if len(filter.ServiceNames) > 0 {
+		serviceIDs = make(map[int64]bool)
+
+		for _, name := range filter.ServiceNames {
+			if id, ok := t.serviceKeys[model.ServiceKey(name)]; ok {
+				serviceIDs[id] = true
+			}
+		}
+	}
+
+	for _, rec := range t.subs {
+		if !matchCost(rec, filter, serviceIDs) {
+			continue
+		}
+
+		sources = append(sources, &model.CostSource{
+			SubscriptionID: rec.sub.ID,
+			UserID:         rec.sub.UserID,
+			ServiceName:    rec.sub.ServiceName,
+			Price:          rec.sub.Price,
+			Prices:         rec.prices,
+			Currency:       rec.sub.Currency,
+			BillingPeriod:  rec.sub.BillingPeriod,
+			StartDate:      rec.start,
+			EndDate:        rec.end,
+		})
+	}
+
+	slices.SortFunc(sources, func(a, b *model.CostSource) int {
+		return cmp.Compare(a.SubscriptionID, b.SubscriptionID)
+	})
+
+	var rates model.RateTable
+
+	if filter.Currency != "" {
+		var err error
+
+		if rates, err = model.NewRateTable(t.listRates()); err != nil {
+			return nil, err
+		}
+	}
+
+	return model.NewCostReport(sources, filter, rates)
+}
+
+// CountActiveSubscriptions Подсчет действующих подписок в памяти
+// по арендаторам.
+func (s *Storage) CountActiveSubscriptions(_ context.Context) (map[string]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now().UTC()
+	counts := make(map[string]int64, len(s.tenants))
+
+	for id, t := range s.tenants {
+		for _, rec := range t.subs {
+			if rec.sub.DeletedAt != nil || rec.start.After(now) || (rec.end != nil && !rec.end.After(now)) {
+				continue
+			}
+
+			counts[id]++
+		}
+	}
+
+	return counts, nil
+}
+
+// Ping Хранилище в памяти всегда доступно.
+func (s *Storage) Ping(_ context.Context) error {
+	return nil
+}
+
+// CloseConnection Очистка хранилища.
+func (s *Storage) CloseConnection() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tenants = make(map[string]*tenantData)
+	s.keys = make(map[int64]*model.APIKey)
+	s.log.Info("Memory storage is closed!")
+}
+
+// CheckSubscriptionID Проверка существует ли подписка в памяти.
+func (s *Storage) CheckSubscriptionID(ctx context.Context, subID int64, includeDeleted bool) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, err := s.read(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	rec, ok := t.subs[subID]
+
+	return ok && (includeDeleted || rec.sub.DeletedAt == nil), nil
+}
+
+// createSubscription Создание подписки.
+// Вызывать под блокировкой Storage.mu.
+func (t *tenantData) createSubscription(ctx context.Context, sub *model.Subscription) (*model.Subscription, error) {
+	now := time.Now().UTC()
+
+	change, err := storage.PrepareSubscription(ctx, t, nil, sub, nil, now)
+	if err != nil {
+		return nil, err
+	}
+
+	t.lastID++
+	rec := &record{sub: *change.Sub, start: change.Start, end: change.End}
+	rec.sub.ID = t.lastID
+	rec.sub.ServiceID = t.ensureService(change.Service)
+	rec.sub.CreatedAt = now
+	rec.sub.UpdatedAt = now
+	rec.sub.Version = 1
+	rec.prices = model.PriceHistory{{EffectiveFrom: rec.start, Price: rec.sub.Price}}
+	t.subs[rec.sub.ID] = rec
+	t.writeEvent(storage.NewEvent(ctx, model.EventCreated, rec.sub.ID, nil, &rec.sub))
+
+	created := rec.sub
+
+	return &created, nil
+}
+
+// updateSubscription Обновление подписки и ее истории цен.
+// Вызывать под блокировкой Storage.mu.
+func (t *tenantData) updateSubscription(
+	ctx context.Context, subID, version int64, priceFrom *time.Time, update storage.UpdateFunc,
+) (*model.Subscription, error) {
+	old, err := t.lockSubscription(subID, version, false)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := old.sub
+	if err := update(&sub); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	change, err := storage.PrepareSubscription(ctx, t, &old.sub, &sub, priceFrom, now)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &record{sub: *change.Sub, start: change.Start, end: change.End, prices: old.prices}
+	if change.Price != nil {
+		rec.prices = old.prices.Apply(change.Price)
+	}
+
+	rec.sub.ID = subID
+	rec.sub.ServiceID = t.ensureService(change.Service)
+	rec.sub.CreatedAt = old.sub.CreatedAt
+	rec.sub.UpdatedAt = now
+	rec.sub.Version = old.sub.Version + 1
+	t.subs[subID] = rec
+	t.writeEvent(storage.NewEvent(ctx, model.EventUpdated, subID, &old.sub, &rec.sub))
+
+	updated := rec.sub
+
+	return &updated, nil
+}
+
+// deleteSubscription Удаление подписки.
+// Вызывать под блокировкой Storage.mu.
+func (t *tenantData) deleteSubscription(ctx context.Context, subID, version int64, hard bool) error {
+	rec, err := t.lockSubscription(subID, version, hard)
+	if err != nil {
+		return err
+	}
+
+	if hard {
+		delete(t.subs, subID)
+		t.writeEvent(storage.NewEvent(ctx, model.EventPurged, subID, &rec.sub, nil))
+
+		return nil
+	}
+
+	deleted := *rec
+	now := time.Now().UTC()
+	deleted.sub.DeletedAt = &now
+	deleted.sub.UpdatedAt = now
+	deleted.sub.Version++
+	t.subs[subID] = &deleted
+	t.writeEvent(storage.NewEvent(ctx, model.EventDeleted, subID, &rec.sub, &deleted.sub))
+
+	return nil
+}
+
+// lockSubscription Получение записи подписки и проверка ее версии,
+// как в psql. Вызывать под блокировкой Storage.mu.
+func (t *tenantData) lockSubscription(subID, version int64, includeDeleted bool) (*record, error) {
+	rec, ok := t.subs[subID]
+	if !ok || (!includeDeleted && rec.sub.DeletedAt != nil) {
+		return nil, storage.ErrSubNotFound
+	}
+
+	if version != storage.AnyVersion && rec.sub.Version != version {
+		return nil, fmt.Errorf("%w: expected %d, current %d", storage.ErrVersion, version, rec.sub.Version)
+	}
+
+	return rec, nil
+}
+
+// checkOverlap Проверка пересечения периода записи rec с другими
+// подписками того же пользователя на тот же сервис. subID исключается
+// из проверки. Вызывать под блокировкой Storage.mu.
+func (t *tenantData) checkOverlap(ctx context.Context, subID int64, rec *record) error {
+	conflictID, err := t.FindOverlap(ctx, &rec.sub, subID, rec.start, rec.end)
+	if err != nil {
+		return err
+	}
+
+	if conflictID != 0 {
+		return &storage.OverlapError{ConflictID: conflictID}
+	}
+
+	return nil
+}
+
+// UserExists Проверка пользователя для storage.PrepareSubscription.
+// Вызывать под блокировкой Storage.mu.
+func (t *tenantData) UserExists(_ context.Context, userID uuid.UUID) (bool, error) {
+	_, ok := t.users[userID]
+
+	return ok, nil
+}
+
+// FindService Поиск сервиса каталога для storage.PrepareSubscription.
+// Вызывать под блокировкой Storage.mu.
+func (t *tenantData) FindService(_ context.Context, name string) (*model.Service, error) {
+	id, ok := t.serviceKeys[model.ServiceKey(name)]
+	if !ok {
+		return nil, storage.ErrServiceNotFound
+	}
+
+	return t.services[id], nil
+}
+
+// FindOverlap Поиск пересекающейся подписки для
+// storage.PrepareSubscription. Вызывать под блокировкой Storage.mu.
+func (t *tenantData) FindOverlap(
+	_ context.Context, sub *model.Subscription, subID int64, start time.Time, end *time.Time,
+) (int64, error) {
+	var conflict *record
+
+	for _, other := range t.subs {
+		if other.sub.ID == subID || other.sub.DeletedAt != nil ||
+			other.sub.UserID != sub.UserID ||
+			other.sub.ServiceName != sub.ServiceName {
+			continue
+		}
+
+		if (end != nil && !end.After(other.start)) ||
+			(other.end != nil && !other.end.After(start)) {
+			continue
+		}
+
+		if conflict == nil || other.start.Before(conflict.start) ||
+			(other.start.Equal(conflict.start) && other.sub.ID < conflict.sub.ID) {
+			conflict = other
+		}
+	}
+
+	if conflict == nil {
+		return 0, nil
+	}
+
+	return conflict.sub.ID, nil
+}
+
+// matchList Проверка подписки по фильтрам ListSubscription.
+func matchList(rec *record, filter *model.ListParams) bool {
+	switch {
+	case filter.Deleted == model.DeletedExclude && rec.sub.DeletedAt != nil,
+		filter.Deleted == model.DeletedOnly && rec.sub.DeletedAt == nil,
+		filter.UserID != nil && rec.sub.UserID != *filter.UserID,
+		!strings.HasPrefix(strings.ToLower(rec.sub.ServiceName), strings.ToLower(filter.ServiceName)),
+		filter.Currency != "" && rec.sub.Currency != filter.Currency,
+		filter.MinPrice != nil && rec.sub.Price < *filter.MinPrice,
+		filter.MaxPrice != nil && rec.sub.Price > *filter.MaxPrice,
+		filter.ActiveFrom != nil && !isActiveIn(rec, *filter.ActiveFrom, *filter.ActiveTo),
+		filter.StartFrom != nil && rec.start.Before(*filter.StartFrom),
+		filter.StartTo != nil && rec.start.After(*filter.StartTo),
+		filter.EndFrom != nil && (rec.end == nil || rec.end.Before(*filter.EndFrom)),
+		filter.EndTo != nil && (rec.end == nil || rec.end.After(*filter.EndTo)):
+		return false
+	}
+
+	return true
+}
+
+// matchCost Проверка подписки по фильтрам CostSubscription.
+// serviceIDs - сервисы, найденные по названиям фильтра, nil означает
+// все сервисы.
+func matchCost(rec *record, filter *model.CostParams, serviceIDs map[int64]bool) bool {
+	switch {
+	case !filter.IncludeDeleted && rec.sub.DeletedAt != nil,
+		filter.UserID != nil && rec.sub.UserID != *filter.UserID,
+		serviceIDs != nil && !serviceIDs[rec.sub.ServiceID],
+		filter.StartDate != nil && rec.end != nil && !rec.end.After(*filter.StartDate),
+		filter.EndDate != nil && rec.start.After(*filter.EndDate):
+		return false
+	}
+
+	return true
+}
+
+// isActiveIn Проверка действует ли подписка хотя бы в один день
+// периода [from, to].
+func isActiveIn(rec *record, from, to time.Time) bool {
+	return !rec.start.After(to) && (rec.end == nil || rec.end.After(from))
+}
+
+// sortValue Значение поля сортировки подписки того же типа,
+// что и model.ListCursor.Arg.
+func sortValue(rec *record, sort string) any {
+	switch sort {
+	case "service_name":
+		return rec.sub.ServiceName
+	case "price":
+		return int64(rec.sub.Price)
+	case "user_id":
+		return rec.sub.UserID.String()
+	case "start_date":
+		return rec.start
+	case "end_date":
+		if rec.end == nil {
+			end, _ := time.Parse(time.DateOnly, model.OpenEndDate)
+
+			return end
+		}
+
+		return *rec.end
+	default:
+		return rec.sub.ID
+	}
+}
+
+// compareSort Сравнение пар (значение поля сортировки, ID) по возрастанию.
+func compareSort(a any, aID int64, b any, bID int64) int {
+	var c int
+
+	switch av := a.(type) {
+	case string:
+		c = strings.Compare(av, fmt.Sprint(b))
+	case int:
+		c = cmp.Compare(av, b.(int))
+	case int64:
+		c = cmp.Compare(av, b.(int64))
+	case time.Time:
+		c = av.Compare(b.(time.Time))
+	}
+
+	if c != 0 {
+		return c
+	}
+
+	return cmp.Compare(aID, bID)
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+)
+
+// GetListEvents Получение страницы журнала изменений из памяти.
+func (s *Storage) GetListEvents(ctx context.Context, filter *model.EventParams) (*model.EventPage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	page := model.EventPage{Events: []*model.SubscriptionEvent{}}
+
+	for _, event := range t.events {
+		if !matchEvent(event, filter) {
+			continue
+		}
+
+		if len(page.Events) == filter.Limit {
+			page.NextCursor = strconv.FormatInt(page.Events[len(page.Events)-1].ID, 10)
+
+			break
+		}
+
+		e := *event
+		page.Events = append(page.Events, &e)
+	}
+
+	return &page, nil
+}
+
+// writeEvent Запись события в журнал изменений.
+// Вызывать под блокировкой Storage.mu.
+func (t *tenantData) writeEvent(event *model.SubscriptionEvent) {
+	t.lastEventID++
+	event.ID = t.lastEventID
+	event.CreatedAt = time.Now().UTC()
+	t.events = append(t.events, event)
+}
+
+// matchEvent Проверка события по фильтрам GetListEvents.
+func matchEvent(event *model.SubscriptionEvent, filter *model.EventParams) bool {
+	switch {
+	case filter.SubscriptionID != nil && event.SubscriptionID != *filter.SubscriptionID,
+		filter.Actor != "" && event.Actor != filter.Actor,
+		filter.From != nil && event.CreatedAt.Before(*filter.From),
+		filter.To != nil && event.CreatedAt.After(*filter.To),
+		event.ID <= filter.AfterID:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+)
+
+// BatchSubscriptions Выполнение пакета операций под одной блокировкой.
+// С atomic при ошибке операции хранилище возвращается к состоянию
+// до пакета, как при откате транзакции в psql.
+func (s *Storage) BatchSubscriptions(
+	ctx context.Context, ops []*model.BatchOperation, atomic bool,
+) ([]*model.BatchResult, error) {
+	const fn = "memory.BatchSubscriptions"
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.Int("size", len(ops)),
+		slog.Bool("atomic", atomic),
+	)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Записи и сервисы не изменяются на месте, поэтому для отката
+	// достаточно поверхностных копий.
+	subs, lastID, events, lastEventID := maps.Clone(t.subs), t.lastID, len(t.events), t.lastEventID
+	services, serviceKeys, lastServiceID := maps.Clone(t.services), maps.Clone(t.serviceKeys), t.lastServiceID
+
+	results := make([]*model.BatchResult, len(ops))
+
+	for i, op := range ops {
+		sub, err := t.batchOperation(ctx, op)
+		results[i] = &model.BatchResult{Subscription: sub, Err: err}
+
+		if err == nil {
+			continue
+		}
+
+		log.ErrorContext(ctx, "failed batch operation", slog.Int("index", i), slog.String("err", err.Error()))
+
+		if atomic {
+			t.subs, t.lastID, t.events, t.lastEventID = subs, lastID, t.events[:events], lastEventID
+			t.services, t.serviceKeys, t.lastServiceID = services, serviceKeys, lastServiceID
+			storage.AbortBatch(results, i)
+
+			return results, nil
+		}
+	}
+
+	log.InfoContext(ctx, "Batch is done!")
+
+	return results, nil
+}
+
+// batchOperation Выполнение одной операции пакета.
+// Вызывать под блокировкой Storage.mu.
+func (t *tenantData) batchOperation(ctx context.Context, op *model.BatchOperation) (*model.Subscription, error) {
+	switch op.Op {
+	case model.BatchCreate:
+		return t.createSubscription(ctx, op.Subscription)
+	case model.BatchUpdate:
+		priceFrom, _ := op.PriceFrom()
+
+		return t.updateSubscription(
+			ctx, op.ID, op.Version, priceFrom,
+			func(cur *model.Subscription) error {
+				cur.Replace(op.Subscription)
+
+				return nil
+			},
+		)
+	case model.BatchDelete:
+		return nil, t.deleteSubscription(ctx, op.ID, op.Version, op.Hard)
+	default:
+		return nil, fmt.Errorf("unknown batch operation: %s", op.Op)
+	}
+}
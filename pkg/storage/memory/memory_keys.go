@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/SHSanderland/EffMobTest/pkg/tenant"
+)
+
+// CreateAPIKey Сохранение API-ключа арендатора из контекста в памяти.
+func (s *Storage) CreateAPIKey(ctx context.Context, key *model.APIKey) (*model.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.UserID != nil {
+		t, err := s.read(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := t.checkUser(*key.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	s.ids.lastKeyID++
+
+	created := *key
+	created.ID = s.ids.lastKeyID
+	created.TenantID = id
+	created.CreatedAt = time.Now().UTC()
+	created.RevokedAt = nil
+	s.keys[created.ID] = &created
+
+	c := created
+	c.Hash = ""
+
+	return &c, nil
+}
+
+// FindAPIKey Поиск действующего API-ключа в памяти по хешу
+// среди ключей всех арендаторов.
+func (s *Storage) FindAPIKey(_ context.Context, hash string) (*model.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.keys {
+		if key.Hash == hash && key.RevokedAt == nil {
+			c := *key
+			c.Hash = ""
+
+			return &c, nil
+		}
+	}
+
+	return nil, storage.ErrAPIKeyNotFound
+}
+
+// ListAPIKeys Получение всех API-ключей арендатора из памяти.
+func (s *Storage) ListAPIKeys(ctx context.Context) ([]*model.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*model.APIKey, 0, len(s.keys))
+
+	for _, key := range s.keys {
+		if key.TenantID != id {
+			continue
+		}
+
+		c := *key
+		c.Hash = ""
+		keys = append(keys, &c)
+	}
+
+	slices.SortFunc(keys, func(a, b *model.APIKey) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return keys, nil
+}
+
+// RevokeAPIKey Отзыв API-ключа арендатора в памяти.
+func (s *Storage) RevokeAPIKey(ctx context.Context, keyID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, ok := s.keys[keyID]
+	if !ok || key.RevokedAt != nil || key.TenantID != id {
+		return storage.ErrAPIKeyNotFound
+	}
+
+	now := time.Now().UTC()
+
+	revoked := *key
+	revoked.RevokedAt = &now
+	s.keys[keyID] = &revoked
+
+	return nil
+}
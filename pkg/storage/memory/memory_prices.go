@@ -0,0 +1,27 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+)
+
+// ListPrices Получение истории цен подписки из памяти.
+func (s *Storage) ListPrices(ctx context.Context, subID int64) (model.PriceHistory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rec, ok := t.subs[subID]
+	if !ok || rec.sub.DeletedAt != nil {
+		return nil, storage.ErrSubNotFound
+	}
+
+	return slices.Clone(rec.prices), nil
+}
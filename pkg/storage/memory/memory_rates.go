@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+)
+
+// ListRates Получение всех курсов валют из памяти.
+func (s *Storage) ListRates(ctx context.Context) ([]*model.Rate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.listRates(), nil
+}
+
+// SetRate Создание или замена курса пары валют в памяти.
+func (s *Storage) SetRate(ctx context.Context, from, to string, rate *big.Rat) (*model.Rate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	saved := model.Rate{
+		From:      from,
+		To:        to,
+		Rate:      model.FormatRate(rate),
+		UpdatedAt: time.Now().UTC(),
+	}
+	t.rates[[2]string{from, to}] = &saved
+
+	s.log.InfoContext(ctx, "Rate is set!", slog.String("from", from), slog.String("to", to))
+
+	r := saved
+
+	return &r, nil
+}
+
+// DeleteRate Удаление курса пары валют из памяти.
+func (s *Storage) DeleteRate(ctx context.Context, from, to string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.write(ctx)
+	if err != nil {
+		return err
+	}
+
+	key := [2]string{from, to}
+	if _, ok := t.rates[key]; !ok {
+		return storage.ErrRateNotFound
+	}
+
+	delete(t.rates, key)
+
+	return nil
+}
+
+// listRates Копия всех курсов, отсортированная по паре валют.
+// Вызывать под блокировкой Storage.mu.
+func (t *tenantData) listRates() []*model.Rate {
+	rates := make([]*model.Rate, 0, len(t.rates))
+
+	for _, rate := range t.rates {
+		r := *rate
+		rates = append(rates, &r)
+	}
+
+	slices.SortFunc(rates, func(a, b *model.Rate) int {
+		return cmp.Or(cmp.Compare(a.From, b.From), cmp.Compare(a.To, b.To))
+	})
+
+	return rates
+}
@@ -0,0 +1,234 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+)
+
+// CreateService Создание сервиса в каталоге в памяти.
+func (s *Storage) CreateService(ctx context.Context, svc *model.Service) (*model.Service, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.checkServiceKeys(0, svc); err != nil {
+		return nil, err
+	}
+
+	created := t.createService(svc)
+
+	return cloneService(created), nil
+}
+
+// ReadService Чтение сервиса из каталога в памяти по ID.
+func (s *Storage) ReadService(ctx context.Context, serviceID int64) (*model.Service, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, ok := t.services[serviceID]
+	if !ok {
+		return nil, storage.ErrServiceNotFound
+	}
+
+	return cloneService(svc), nil
+}
+
+// FindService Поиск сервиса в каталоге в памяти по названию или синониму.
+func (s *Storage) FindService(ctx context.Context, name string) (*model.Service, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id, ok := t.serviceKeys[model.ServiceKey(name)]
+	if !ok {
+		return nil, storage.ErrServiceNotFound
+	}
+
+	return cloneService(t.services[id]), nil
+}
+
+// UpdateService Замена сервиса в каталоге в памяти. При переименовании
+// подписки сервиса получают новое название и событие update, как в psql.
+func (s *Storage) UpdateService(
+	ctx context.Context, serviceID int64, svc *model.Service,
+) (*model.Service, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	old, ok := t.services[serviceID]
+	if !ok {
+		return nil, storage.ErrServiceNotFound
+	}
+
+	if err := t.checkServiceKeys(serviceID, svc); err != nil {
+		return nil, err
+	}
+
+	updated := cloneService(svc)
+	updated.ID = serviceID
+	updated.CreatedAt = old.CreatedAt
+	updated.UpdatedAt = time.Now().UTC()
+
+	t.deleteServiceKeys(old)
+	t.services[serviceID] = updated
+
+	for _, key := range updated.Keys() {
+		t.serviceKeys[key] = serviceID
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(t.subs)) {
+		rec := t.subs[id]
+		if rec.sub.ServiceID != serviceID || rec.sub.ServiceName == updated.Name {
+			continue
+		}
+
+		renamed := *rec
+		renamed.sub.ServiceName = updated.Name
+		renamed.sub.UpdatedAt = updated.UpdatedAt
+		renamed.sub.Version++
+		t.subs[id] = &renamed
+		t.writeEvent(storage.NewEvent(ctx, model.EventUpdated, id, &rec.sub, &renamed.sub))
+	}
+
+	return cloneService(updated), nil
+}
+
+// DeleteService Удаление сервиса из каталога в памяти.
+func (s *Storage) DeleteService(ctx context.Context, serviceID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.write(ctx)
+	if err != nil {
+		return err
+	}
+
+	svc, ok := t.services[serviceID]
+	if !ok {
+		return storage.ErrServiceNotFound
+	}
+
+	for _, rec := range t.subs {
+		if rec.sub.ServiceID == serviceID {
+			return storage.ErrServiceInUse
+		}
+	}
+
+	t.deleteServiceKeys(svc)
+	delete(t.services, serviceID)
+
+	return nil
+}
+
+// ListServices Получение сервисов каталога в памяти, отсортированных
+// по названию.
+func (s *Storage) ListServices(ctx context.Context, category string) ([]*model.Service, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	services := []*model.Service{}
+
+	for _, svc := range t.services {
+		if category == "" || svc.Category == category {
+			services = append(services, cloneService(svc))
+		}
+	}
+
+	slices.SortFunc(services, func(a, b *model.Service) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+
+	return services, nil
+}
+
+// ensureService ID сервиса подписки. Сервис без ID, который
+// storage.PrepareSubscription вернул для нового названия, создается.
+// Вызывать под блокировкой Storage.mu.
+func (t *tenantData) ensureService(svc *model.Service) int64 {
+	if svc.ID != 0 {
+		return svc.ID
+	}
+
+	return t.createService(svc).ID
+}
+
+// createService Создание сервиса и его ключей без проверки.
+// Вызывать под блокировкой Storage.mu.
+func (t *tenantData) createService(svc *model.Service) *model.Service {
+	t.lastServiceID++
+	now := time.Now().UTC()
+
+	created := cloneService(svc)
+	created.ID = t.lastServiceID
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	t.services[created.ID] = created
+
+	for _, key := range created.Keys() {
+		t.serviceKeys[key] = created.ID
+	}
+
+	return created
+}
+
+// checkServiceKeys Проверка, что названия и синонимы svc не заняты
+// другими сервисами, кроме serviceID. Вызывать под блокировкой Storage.mu.
+func (t *tenantData) checkServiceKeys(serviceID int64, svc *model.Service) error {
+	for _, key := range svc.Keys() {
+		if id, ok := t.serviceKeys[key]; ok && id != serviceID {
+			return fmt.Errorf("%w: %q", storage.ErrServiceExists, key)
+		}
+	}
+
+	return nil
+}
+
+// deleteServiceKeys Удаление ключей сервиса. Вызывать под блокировкой Storage.mu.
+func (t *tenantData) deleteServiceKeys(svc *model.Service) {
+	for _, key := range svc.Keys() {
+		delete(t.serviceKeys, key)
+	}
+}
+
+// cloneService Копия сервиса, которую можно изменять.
+func cloneService(svc *model.Service) *model.Service {
+	c := *svc
+	c.Aliases = slices.Clone(svc.Aliases)
+
+	if svc.DefaultPrice != nil {
+		price := *svc.DefaultPrice
+		c.DefaultPrice = &price
+	}
+
+	return &c
+}
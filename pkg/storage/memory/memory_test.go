@@ -0,0 +1,15 @@
+package memory
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/SHSanderland/EffMobTest/pkg/storage/storagetest"
+)
+
+func TestStorage(t *testing.T) {
+	storagetest.Run(t, func(*testing.T) storage.Storage {
+		return InitStorage(slog.New(slog.DiscardHandler))
+	})
+}
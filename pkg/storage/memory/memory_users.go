@@ -0,0 +1,194 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/SHSanderland/EffMobTest/pkg/tenant"
+	"github.com/google/uuid"
+)
+
+// CreateUser Создание пользователя в памяти.
+func (s *Storage) CreateUser(ctx context.Context, user *model.User) (*model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// ID пользователей уникальны среди всех арендаторов, как первичный
+	// ключ в psql.
+	for _, other := range s.tenants {
+		if _, ok := other.users[user.ID]; ok {
+			return nil, storage.ErrUserExists
+		}
+	}
+
+	if err := t.checkEmail(user.ID, user.Email); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	created := *user
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	t.users[created.ID] = &created
+	t.setEmail(created.ID, "", created.Email)
+
+	c := created
+
+	return &c, nil
+}
+
+// ReadUser Чтение пользователя из памяти по ID.
+func (s *Storage) ReadUser(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, ok := t.users[userID]
+	if !ok {
+		return nil, storage.ErrUserNotFound
+	}
+
+	c := *user
+
+	return &c, nil
+}
+
+// UpdateUser Замена данных пользователя в памяти.
+func (s *Storage) UpdateUser(ctx context.Context, userID uuid.UUID, user *model.User) (*model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	old, ok := t.users[userID]
+	if !ok {
+		return nil, storage.ErrUserNotFound
+	}
+
+	if err := t.checkEmail(userID, user.Email); err != nil {
+		return nil, err
+	}
+
+	updated := *user
+	updated.ID = userID
+	updated.CreatedAt = old.CreatedAt
+	updated.UpdatedAt = time.Now().UTC()
+	t.users[userID] = &updated
+	t.setEmail(userID, old.Email, updated.Email)
+
+	c := updated
+
+	return &c, nil
+}
+
+// DeleteUser Удаление пользователя из памяти вместе с его API-ключами.
+func (s *Storage) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.write(ctx)
+	if err != nil {
+		return err
+	}
+
+	user, ok := t.users[userID]
+	if !ok {
+		return storage.ErrUserNotFound
+	}
+
+	for _, rec := range t.subs {
+		if rec.sub.UserID == userID {
+			return storage.ErrUserInUse
+		}
+	}
+
+	tenantID, _ := tenant.FromContext(ctx)
+
+	for id, key := range s.keys {
+		if key.UserID != nil && *key.UserID == userID && key.TenantID == tenantID {
+			delete(s.keys, id)
+		}
+	}
+
+	t.setEmail(userID, user.Email, "")
+	delete(t.users, userID)
+
+	return nil
+}
+
+// ListUsers Получение всех пользователей из памяти в порядке создания.
+func (s *Storage) ListUsers(ctx context.Context) ([]*model.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*model.User, 0, len(t.users))
+
+	for _, user := range t.users {
+		c := *user
+		users = append(users, &c)
+	}
+
+	slices.SortFunc(users, func(a, b *model.User) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID.String(), b.ID.String()))
+	})
+
+	return users, nil
+}
+
+// checkUser Проверка, что пользователь подписки существует.
+// Вызывать под блокировкой Storage.mu.
+func (t *tenantData) checkUser(userID uuid.UUID) error {
+	if _, ok := t.users[userID]; !ok {
+		return model.NewValidationError("user_id", model.RuleExists, "user does not exist")
+	}
+
+	return nil
+}
+
+// checkEmail Проверка, что email не занят другим пользователем.
+// Пустой email не проверяется. Вызывать под блокировкой Storage.mu.
+func (t *tenantData) checkEmail(userID uuid.UUID, email string) error {
+	if email == "" {
+		return nil
+	}
+
+	if id, ok := t.userEmails[model.EmailKey(email)]; ok && id != userID {
+		return storage.ErrUserExists
+	}
+
+	return nil
+}
+
+// setEmail Замена email пользователя в индексе уникальности.
+// Вызывать под блокировкой Storage.mu.
+func (t *tenantData) setEmail(userID uuid.UUID, oldEmail, newEmail string) {
+	if oldEmail != "" {
+		delete(t.userEmails, model.EmailKey(oldEmail))
+	}
+
+	if newEmail != "" {
+		t.userEmails[model.EmailKey(newEmail)] = userID
+	}
+}
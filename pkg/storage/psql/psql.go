@@ -13,45 +13,64 @@ import (
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
-	"github.com/google/uuid"
 
 	"github.com/SHSanderland/EffMobTest/pkg/config"
+	"github.com/SHSanderland/EffMobTest/pkg/metrics"
 	"github.com/SHSanderland/EffMobTest/pkg/model"
 	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/SHSanderland/EffMobTest/pkg/tenant"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // Storage Структура работы с PostgreSQL. Использует pgxpool.
+// version - версия миграций, примененная при запуске, с которой
+// сверяется Ping.
 type Storage struct {
-	db  *pgxpool.Pool
-	log *slog.Logger
+	db      *pgxpool.Pool
+	log     *slog.Logger
+	version uint
 }
 
-// InitDB Инициализации базы данных.
+// InitDB Инициализации базы данных. Запросы к базе трассируются
+// через queryTracer.
 func InitDB(ctx context.Context, log *slog.Logger, cfg *config.Config) (*Storage, error) {
-	pool, err := pgxpool.New(ctx, cfg.DSN)
+	poolCfg, err := pgxpool.ParseConfig(cfg.DSN)
+	if err != nil {
+		log.Error("failed to parse DSN", slog.String("err", err.Error()))
+
+		return nil, fmt.Errorf("failed to parse DSN: %w", err)
+	}
+
+	poolCfg.ConnConfig.Tracer = queryTracer{}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
 		log.Error("failed to add new connection to DB", slog.String("err", err.Error()))
 
 		return nil, fmt.Errorf("failed to add new connection: %w", err)
 	}
 
-	// Если сразу подключиться не получилось, то пробуем еще три раза.
+	// Пока база данных не готова, пробуем подключиться раз в секунду
+	// до истечения ctx.
 	err = pool.Ping(ctx)
-	for i := 0; err != nil && i < 3; i++ {
+	for try := 1; err != nil && ctx.Err() == nil; try++ {
 		log.Error(
 			"failed to connect to DB",
-			slog.Int("Try", i+1),
+			slog.Int("Try", try),
 			slog.String("err", err.Error()),
 		)
-		time.Sleep(time.Second)
 
-		err = pool.Ping(ctx)
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			err = pool.Ping(ctx)
+		}
 	}
 
 	if err != nil {
 		log.Error("failed to init DB", slog.String("err", err.Error()))
+		pool.Close()
 
 		return nil, fmt.Errorf("failed to init DB: %w", err)
 	}
@@ -69,313 +88,658 @@ func InitDB(ctx context.Context, log *slog.Logger, cfg *config.Config) (*Storage
 		return nil, fmt.Errorf("failed to migrate DB: %w", err)
 	}
 
-	return &Storage{log: log, db: pool}, nil
+	version, _, err := m.Version()
+	if err != nil {
+		log.Error("failed to get schema version", slog.String("err", err.Error()))
+
+		return nil, fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	if srcErr, dbErr := m.Close(); srcErr != nil || dbErr != nil {
+		log.Warn("failed to close migrator", slog.Any("source", srcErr), slog.Any("database", dbErr))
+	}
+
+	log.Info("DB is migrated!", slog.Uint64("version", uint64(version)))
+
+	return &Storage{log: log, db: pool, version: version}, nil
 }
 
 // CreateSubscription Создание подписки в базе данных.
-func (s *Storage) CreateSubscription(ctx context.Context, sub *model.Subscription) error {
+// Возвращает созданную подписку с ID и временем создания.
+func (s *Storage) CreateSubscription(ctx context.Context, sub *model.Subscription) (*model.Subscription, error) {
 	const fn = "psql.CreateSubscription"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
 	log := s.log.With(
 		slog.String("fn", fn),
 		slog.String("userID", sub.UserID.String()),
 	)
 
-	tx, err := s.db.Begin(ctx)
+	tx, err := s.begin(ctx)
 	if err != nil {
-		log.Error("failed to begin transaction", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
 
-		return fmt.Errorf("%w: %w", storage.ErrBeginTrans, err)
+		return nil, storageErr(storage.ErrBeginTrans, err)
 	}
 
 	defer func() {
 		err := tx.Rollback(ctx)
 		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
-			log.Error("failed to rollback transaction", slog.String("err", err.Error()))
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
 		}
 	}()
 
-	if sub.EndDate == "" {
-		_, err = tx.Exec(
-			ctx,
-			storage.CreateSubscriptionSchema,
-			sub.ServiceName,
-			sub.Price,
-			sub.UserID,
-			sub.StartDate,
-			nil,
-		)
-	} else {
-		_, err = tx.Exec(
-			ctx,
-			storage.CreateSubscriptionSchema,
-			sub.ServiceName,
-			sub.Price,
-			sub.UserID,
-			sub.StartDate,
-			sub.EndDate,
-		)
+	created, event, err := s.createSubscription(ctx, tx, sub)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to create subscription", slog.String("err", err.Error()))
+
+		return nil, err
 	}
 
-	if err != nil {
-		log.Error("failed to exec schema", slog.String("err", err.Error()))
+	if err := s.writeEvent(ctx, tx, event); err != nil {
+		log.ErrorContext(ctx, "failed to write event", slog.String("err", err.Error()))
 
-		return fmt.Errorf("%w: %w", storage.ErrExecSchema, err)
+		return nil, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		log.Error("failed to commit transaction", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
 
-		return fmt.Errorf("%w: %w", storage.ErrCommitTrans, err)
+		return nil, storageErr(storage.ErrCommitTrans, err)
 	}
 
-	log.Info("Subscription is created!")
+	log.InfoContext(ctx, "Subscription is created!", slog.Int64("subID", created.ID))
 
-	return nil
+	return created, nil
 }
 
 // ReadSubscription Чтение подписки в базе данных.
-func (s *Storage) ReadSubscription(ctx context.Context, subID int64) (*model.Subscription, error) {
+// Мягко удаленная подписка читается только с includeDeleted.
+func (s *Storage) ReadSubscription(
+	ctx context.Context, subID int64, includeDeleted bool,
+) (*model.Subscription, error) {
 	const fn = "psql.ReadSubscription"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
 	log := s.log.With(
 		slog.String("fn", fn),
 		slog.Int64("subID", subID),
 	)
 
-	var (
-		sub       model.Subscription
-		startTime time.Time
-		endTime   *time.Time
-	)
-
-	tx, err := s.db.Begin(ctx)
+	tx, err := s.begin(ctx)
 	if err != nil {
-		log.Error("failed to begin transaction", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
 
-		return nil, fmt.Errorf("%w: %w", storage.ErrBeginTrans, err)
+		return nil, storageErr(storage.ErrBeginTrans, err)
 	}
 
 	defer func() {
 		err := tx.Rollback(ctx)
 		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
-			log.Error("failed to rollback transaction", slog.String("err", err.Error()))
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
 		}
 	}()
 
-	err = tx.QueryRow(ctx, storage.ReadSubscriptionSchema, subID).Scan(
-		&sub.ServiceName,
-		&sub.Price,
-		&sub.UserID,
-		&startTime,
-		&endTime,
-	)
+	sub, err := scanSubscription(tx.QueryRow(ctx, storage.ReadSubscriptionSchema, subID, includeDeleted))
 	if err != nil {
-		log.Error("failed to exec schema", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
 
-		return nil, fmt.Errorf("%w: %w", storage.ErrExecSchema, err)
+		return nil, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		log.Error("failed to commit transaction", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
 
-		return nil, fmt.Errorf("%w: %w", storage.ErrCommitTrans, err)
+		return nil, storageErr(storage.ErrCommitTrans, err)
 	}
 
-	sub.StartDate = startTime.Format("01-2006")
-	if endTime != nil {
-		sub.EndDate = endTime.Format("01-2006")
-	}
+	log.InfoContext(ctx, "Subscription is readed!")
 
-	log.Info("Subscription is readed!")
-
-	return &sub, nil
+	return sub, nil
 }
 
 // UpdateSubscription Обновление подписки в базе данных.
-// Обновляет только название сервиса, цену и дату окончания.
-func (s *Storage) UpdateSubscription(ctx context.Context, subID int64, sub *model.Subscription) error {
+// Подписка блокируется на время транзакции, поэтому проверка версии,
+// изменение через update и запись выполняются атомарно. update получает
+// текущее состояние подписки, результат должен быть проверен целиком.
+// История цен меняется в той же транзакции.
+// Возвращает обновленную подписку.
+func (s *Storage) UpdateSubscription(
+	ctx context.Context, subID, version int64, priceFrom *time.Time, update storage.UpdateFunc,
+) (*model.Subscription, error) {
 	const fn = "psql.UpdateSubscription"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
 	log := s.log.With(
 		slog.String("fn", fn),
 		slog.Int64("subID", subID),
 	)
 
-	tx, err := s.db.Begin(ctx)
+	tx, err := s.begin(ctx)
 	if err != nil {
-		log.Error("failed to begin transaction", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
 
-		return fmt.Errorf("%w: %w", storage.ErrBeginTrans, err)
+		return nil, storageErr(storage.ErrBeginTrans, err)
 	}
 
 	defer func() {
 		err := tx.Rollback(ctx)
 		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
-			log.Error("failed to rollback transaction", slog.String("err", err.Error()))
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
 		}
 	}()
 
-	updates, args := prepareUpdate(sub)
+	updated, event, err := s.updateSubscription(ctx, tx, subID, version, priceFrom, update)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to update subscription", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	if err := s.writeEvent(ctx, tx, event); err != nil {
+		log.ErrorContext(ctx, "failed to write event", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
 
-	if len(updates) == 0 {
-		return storage.ErrEmptySub
+		return nil, storageErr(storage.ErrCommitTrans, err)
 	}
 
-	query := fmt.Sprintf(
-		"UPDATE subscriptions SET %s WHERE id = $%d",
-		strings.Join(updates, ", "),
-		len(updates)+1,
+	log.InfoContext(ctx, "Subscription is update!", slog.Int64("version", updated.Version))
+
+	return updated, nil
+}
+
+// DeleteSubscription Удаление подписки в базе данных.
+// По умолчанию подписка удаляется мягко и может быть восстановлена,
+// с hard строка удаляется навсегда, в том числе из корзины.
+// Проверка версии и удаление выполняются в одной транзакции.
+func (s *Storage) DeleteSubscription(ctx context.Context, subID, version int64, hard bool) error {
+	const fn = "psql.DeleteSubscription"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.Int64("subID", subID),
+		slog.Bool("hard", hard),
 	)
 
-	args = append(args, subID)
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
 
-	_, err = tx.Exec(ctx, query, args...)
+		return storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	event, err := s.deleteSubscription(ctx, tx, subID, version, hard)
 	if err != nil {
-		log.Error("failed to exec schema", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to delete subscription", slog.String("err", err.Error()))
+
+		return err
+	}
 
-		return fmt.Errorf("%w: %w", storage.ErrExecSchema, err)
+	if err := s.writeEvent(ctx, tx, event); err != nil {
+		log.ErrorContext(ctx, "failed to write event", slog.String("err", err.Error()))
+
+		return err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		log.Error("failed to commit transaction", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
 
-		return fmt.Errorf("%w: %w", storage.ErrCommitTrans, err)
+		return storageErr(storage.ErrCommitTrans, err)
 	}
 
-	log.Info("Subscription is update!")
+	log.InfoContext(ctx, "Subscription is deleted!")
 
 	return nil
 }
 
-// DeleteSubscription Удаление подписки в базе данных.
-func (s *Storage) DeleteSubscription(ctx context.Context, subID int64) error {
-	const fn = "psql.DeleteSubscription"
+// RestoreSubscription Восстановление мягко удаленной подписки.
+// Возвращает storage.ErrNotDeleted, если подписка не удалена, и
+// *storage.OverlapError, если за время нахождения в корзине появилась
+// пересекающаяся подписка.
+func (s *Storage) RestoreSubscription(ctx context.Context, subID, version int64) (*model.Subscription, error) {
+	const fn = "psql.RestoreSubscription"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
 	log := s.log.With(
 		slog.String("fn", fn),
 		slog.Int64("subID", subID),
 	)
 
-	tx, err := s.db.Begin(ctx)
+	tx, err := s.begin(ctx)
 	if err != nil {
-		log.Error("failed to begin transaction", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
 
-		return fmt.Errorf("%w: %w", storage.ErrBeginTrans, err)
+		return nil, storageErr(storage.ErrBeginTrans, err)
 	}
 
 	defer func() {
 		err := tx.Rollback(ctx)
 		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
-			log.Error("failed to rollback transaction", slog.String("err", err.Error()))
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
 		}
 	}()
 
-	_, err = tx.Exec(ctx, storage.DeleteSubscriptionSchema, subID)
+	sub, err := s.lockSubscription(ctx, tx, subID, version, true)
 	if err != nil {
-		log.Error("failed to exec schema", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to lock subscription", slog.String("err", err.Error()))
 
-		return fmt.Errorf("%w: %w", storage.ErrExecSchema, err)
+		return nil, err
+	}
+
+	if sub.DeletedAt == nil {
+		return nil, storage.ErrNotDeleted
+	}
+
+	if err := s.checkOverlap(ctx, tx, subID, sub); err != nil {
+		log.ErrorContext(ctx, "failed to check overlap", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	restored, err := scanSubscription(tx.QueryRow(ctx, storage.RestoreSubscriptionSchema, subID))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return nil, overlapError(err)
+	}
+
+	event := storage.NewEvent(ctx, model.EventRestored, subID, sub, restored)
+	if err := s.writeEvent(ctx, tx, event); err != nil {
+		log.ErrorContext(ctx, "failed to write event", slog.String("err", err.Error()))
+
+		return nil, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		log.Error("failed to commit transaction", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
 
-		return fmt.Errorf("%w: %w", storage.ErrCommitTrans, err)
+		return nil, storageErr(storage.ErrCommitTrans, err)
 	}
 
-	return nil
+	log.InfoContext(ctx, "Subscription is restored!")
+
+	return restored, nil
 }
 
-// GetListSubscription Получение списка данных о подписке из базы данных.
+// createSubscription Создание подписки внутри транзакции. Подписка
+// проверяется через storage.PrepareSubscription, новый сервис
+// создается вместе с ней.
+// Возвращает созданную подписку и событие для журнала изменений.
+func (s *Storage) createSubscription(
+	ctx context.Context, tx pgx.Tx, sub *model.Subscription,
+) (*model.Subscription, *model.SubscriptionEvent, error) {
+	change, err := storage.PrepareSubscription(ctx, txState{tx}, nil, sub, nil, time.Now().UTC())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	svc, err := ensureService(ctx, tx, change.Service)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	created, err := scanSubscription(tx.QueryRow(
+		ctx,
+		storage.CreateSubscriptionSchema,
+		svc.Name,
+		int64(change.Sub.Price),
+		change.Sub.Currency,
+		change.Sub.BillingPeriod.Unit,
+		change.Sub.BillingPeriod.Count,
+		change.Sub.UserID,
+		change.Start,
+		change.End,
+		svc.ID,
+	))
+	if err != nil {
+		return nil, nil, overlapError(err)
+	}
+
+	_, err = tx.Exec(ctx, storage.CreatePriceSchema, created.ID, change.Start, int64(created.Price))
+	if err != nil {
+		return nil, nil, storageErr(storage.ErrExecSchema, err)
+	}
+
+	return created, storage.NewEvent(ctx, model.EventCreated, created.ID, nil, created), nil
+}
+
+// updateSubscription Обновление подписки и ее истории цен внутри
+// транзакции. Подписка проверяется через storage.PrepareSubscription.
+// Возвращает обновленную подписку и событие для журнала изменений.
+func (s *Storage) updateSubscription(
+	ctx context.Context, tx pgx.Tx, subID, version int64, priceFrom *time.Time, update storage.UpdateFunc,
+) (*model.Subscription, *model.SubscriptionEvent, error) {
+	sub, err := s.lockSubscription(ctx, tx, subID, version, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	before := *sub
+
+	if err := update(sub); err != nil {
+		return nil, nil, err
+	}
+
+	change, err := storage.PrepareSubscription(ctx, txState{tx}, &before, sub, priceFrom, time.Now().UTC())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	svc, err := ensureService(ctx, tx, change.Service)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updated, err := scanSubscription(tx.QueryRow(
+		ctx,
+		storage.UpdateSubscriptionSchema,
+		svc.Name,
+		int64(change.Sub.Price),
+		change.Sub.Currency,
+		change.Sub.BillingPeriod.Unit,
+		change.Sub.BillingPeriod.Count,
+		change.Sub.UserID,
+		change.Start,
+		change.End,
+		svc.ID,
+		subID,
+	))
+	if err != nil {
+		return nil, nil, overlapError(err)
+	}
+
+	if err := updatePrices(ctx, tx, subID, change.Price); err != nil {
+		return nil, nil, err
+	}
+
+	return updated, storage.NewEvent(ctx, model.EventUpdated, subID, &before, updated), nil
+}
+
+// deleteSubscription Удаление подписки внутри транзакции.
+// Возвращает событие для журнала изменений.
+func (s *Storage) deleteSubscription(
+	ctx context.Context, tx pgx.Tx, subID, version int64, hard bool,
+) (*model.SubscriptionEvent, error) {
+	before, err := s.lockSubscription(ctx, tx, subID, version, hard)
+	if err != nil {
+		return nil, err
+	}
+
+	event := storage.NewEvent(ctx, model.EventPurged, subID, before, nil)
+
+	if hard {
+		_, err = tx.Exec(ctx, storage.DeleteSubscriptionSchema, subID)
+	} else {
+		event.Action = model.EventDeleted
+		event.After, err = scanSubscription(tx.QueryRow(ctx, storage.SoftDeleteSubscriptionSchema, subID))
+	}
+
+	if err != nil {
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
+
+	return event, nil
+}
+
+// GetListSubscription Получение страницы списка подписок из базы данных.
+// Использует keyset-пагинацию по полю сортировки и ID подписки.
 func (s *Storage) GetListSubscription(
-	ctx context.Context, userID uuid.UUID, serviceName string,
-) ([]*model.Subscription, error) {
+	ctx context.Context, filter *model.ListParams,
+) (*model.SubscriptionPage, error) {
 	const fn = "psql.GetListSubscription"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
 	log := s.log.With(
 		slog.String("fn", fn),
-		slog.String("userID", userID.String()),
-		slog.String("serviceName", serviceName),
+		slog.Any("userID", filter.UserID),
+		slog.String("serviceName", filter.ServiceName),
+		slog.String("sort", filter.SortKey()),
 	)
 
-	tx, err := s.db.Begin(ctx)
+	tx, err := s.begin(ctx)
 	if err != nil {
-		log.Error("failed to begin transaction", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
 
-		return nil, fmt.Errorf("%w: %w", storage.ErrBeginTrans, err)
+		return nil, storageErr(storage.ErrBeginTrans, err)
 	}
 
 	defer func() {
 		err := tx.Rollback(ctx)
 		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
-			log.Error("failed to rollback transaction", slog.String("err", err.Error()))
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
 		}
 	}()
 
-	rows, err := tx.Query(ctx, storage.ListSubscriptionSchema, userID, serviceName)
+	where, orderBy, args, err := prepareListFilter(filter)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to prepare filter", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	// Выбираем на одну запись больше, чтобы понять, есть ли следующая страница.
+	args = append(args, filter.Limit+1)
+	query := fmt.Sprintf(storage.ListSubscriptionSchema, where, orderBy, len(args))
+
+	rows, err := tx.Query(ctx, query, args...)
 	if err != nil {
-		log.Error("failed to exec schema", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
 
-		return nil, fmt.Errorf("%w: %w", storage.ErrExecSchema, err)
+		return nil, storageErr(storage.ErrExecSchema, err)
 	}
 
-	subs, err := s.getSubList(rows)
+	page, err := s.getSubPage(rows, filter)
 	if err != nil {
-		log.Error("failed to scan rows", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to scan rows", slog.String("err", err.Error()))
 
-		return nil, fmt.Errorf("%w: %w", storage.ErrExecSchema, err)
+		return nil, storageErr(storage.ErrExecSchema, err)
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		log.Error("failed to commit transaction", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
 
-		return nil, fmt.Errorf("%w: %w", storage.ErrCommitTrans, err)
+		return nil, storageErr(storage.ErrCommitTrans, err)
 	}
 
-	return subs, nil
+	return page, nil
 }
 
-// CostSubscription Получение всей суммы, потраченную на подписку пользователем.
-func (s *Storage) CostSubscription(ctx context.Context, filter *model.CostParams) (int64, error) {
+// CostSubscription Подсчет суммы, потраченной пользователем на подписку
+// за период. Подписки и их история цен читаются в одной транзакции,
+// стоимость считает model.NewCostReport.
+func (s *Storage) CostSubscription(ctx context.Context, filter *model.CostParams) (*model.CostReport, error) {
 	const fn = "psql.CostSubscription"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
 	log := s.log.With(
 		slog.String("fn", fn),
-		slog.String("userID", filter.UserID.String()),
-		slog.String("serviceName", filter.ServiceName),
+		slog.Any("userID", filter.UserID),
+		slog.Any("serviceNames", filter.ServiceNames),
 	)
 
-	var total int64
-
-	tx, err := s.db.Begin(ctx)
+	tx, err := s.begin(ctx)
 	if err != nil {
-		log.Error("failed to begin transaction", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
 
-		return total, fmt.Errorf("%w: %w", storage.ErrBeginTrans, err)
+		return nil, storageErr(storage.ErrBeginTrans, err)
 	}
 
 	defer func() {
 		err := tx.Rollback(ctx)
 		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
-			log.Error("failed to rollback transaction", slog.String("err", err.Error()))
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
 		}
 	}()
 
-	err = tx.QueryRow(
-		ctx, storage.CountSubscriptionsSchema,
-		filter.UserID,
-		filter.ServiceName,
-		filter.StartDate,
-		filter.EndDate,
-	).Scan(&total)
+	where, args := prepareCostFilter(filter)
+	query := fmt.Sprintf(storage.CountSubscriptionsSchema, where)
+
+	rows, err := tx.Query(ctx, query, args...)
 	if err != nil {
-		log.Error("failed to scan rows", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
+
+	sources, err := s.getCostSources(rows)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to scan rows", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
+
+	if err := costPrices(ctx, tx, sources); err != nil {
+		log.ErrorContext(ctx, "failed to get prices", slog.String("err", err.Error()))
+
+		return nil, err
+	}
 
-		return total, fmt.Errorf("%w: %w", storage.ErrExecSchema, err)
+	var rates model.RateTable
+
+	if filter.Currency != "" {
+		list, err := listRates(ctx, tx)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to get rates", slog.String("err", err.Error()))
+
+			return nil, err
+		}
+
+		if rates, err = model.NewRateTable(list); err != nil {
+			log.ErrorContext(ctx, "failed to prepare rates", slog.String("err", err.Error()))
+
+			return nil, err
+		}
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		log.Error("failed to commit transaction", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrCommitTrans, err)
+	}
+
+	return model.NewCostReport(sources, filter, rates)
+}
+
+// begin Начало транзакции от имени арендатора из контекста. Запросы
+// транзакции выполняются от роли storage.TenantRole, для которой политики
+// row-level security оставляют только строки арендатора, а новые строки
+// получают его tenant_id по умолчанию. Возвращает tenant.ErrNoTenant,
+// если арендатор не задан.
+func (s *Storage) begin(ctx context.Context) (pgx.Tx, error) {
+	id, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, storage.SetTenantSchema, id, storage.TenantRole); err != nil {
+		if err := tx.Rollback(ctx); err != nil {
+			s.log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+
+		return nil, fmt.Errorf("failed to set tenant: %w", err)
+	}
+
+	return tx, nil
+}
+
+// CountActiveSubscriptions Подсчет действующих подписок по арендаторам.
+// Запрос выполняется от владельца таблиц, на которого не действует
+// row-level security, поэтому без транзакции арендатора.
+func (s *Storage) CountActiveSubscriptions(ctx context.Context) (map[string]int64, error) {
+	const fn = "psql.CountActiveSubscriptions"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+	)
+
+	rows, err := s.db.Query(ctx, storage.CountActiveSubscriptionsSchema)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+
+	for rows.Next() {
+		var (
+			id    string
+			count int64
+		)
+
+		if err := rows.Scan(&id, &count); err != nil {
+			log.ErrorContext(ctx, "failed to scan row", slog.String("err", err.Error()))
+
+			return nil, storageErr(storage.ErrExecSchema, err)
+		}
+
+		counts[id] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		log.ErrorContext(ctx, "failed to read rows", slog.String("err", err.Error()))
 
-		return total, fmt.Errorf("%w: %w", storage.ErrCommitTrans, err)
+		return nil, storageErr(storage.ErrExecSchema, err)
 	}
 
-	return total, nil
+	return counts, nil
+}
+
+// Ping Проверка доступности базы данных и версии ее схемы.
+func (s *Storage) Ping(ctx context.Context) error {
+	if err := s.db.Ping(ctx); err != nil {
+		return storageErr(storage.ErrUnavailable, err)
+	}
+
+	var (
+		version int64
+		dirty   bool
+	)
+
+	if err := s.db.QueryRow(ctx, storage.MigrationVersionSchema).Scan(&version, &dirty); err != nil {
+		return storageErr(storage.ErrExecSchema, err)
+	}
+
+	if dirty || version != int64(s.version) {
+		return fmt.Errorf(
+			"%w: got %d (dirty %t), expected %d", storage.ErrSchemaVersion, version, dirty, s.version,
+		)
+	}
+
+	return nil
+}
+
+// Stat Статистика пула соединений с базой данных.
+func (s *Storage) Stat() *pgxpool.Stat {
+	return s.db.Stat()
 }
 
 // CloseConnection Закрытие соединения с базой данных.
@@ -384,107 +748,276 @@ func (s *Storage) CloseConnection() {
 	s.log.Info("Connection to DB is closed!")
 }
 
-// prepareUpdate Проверка какие параметры нужно обновлять для
-// UpdateSubscription, а также преобразование дат к нужному типу.
-func prepareUpdate(sub *model.Subscription) ([]string, []any) {
+// sortColumns Выражения SQL для полей сортировки списка подписок.
+var sortColumns = map[string]string{
+	"id":           "id",
+	"service_name": "service_name",
+	"price":        "price_minor",
+	"user_id":      "user_id",
+	"start_date":   "start_date",
+	"end_date":     "COALESCE(end_date, DATE '" + model.OpenEndDate + "')",
+}
+
+// prepareListFilter Сборка условия WHERE и ORDER BY для
+// GetListSubscription из заданных фильтров и курсора.
+func prepareListFilter(filter *model.ListParams) (string, string, []any, error) {
 	var (
-		updates []string
-		args    []any
+		conds []string
+		args  []any
 	)
 
-	if sub.ServiceName != "" {
-		updates = append(updates, fmt.Sprintf("service_name = $%d", len(updates)+1))
-		args = append(args, sub.ServiceName)
+	column, ok := sortColumns[filter.Sort]
+	if !ok {
+		return "", "", nil, fmt.Errorf("unknown sort field: %s", filter.Sort)
+	}
+
+	switch filter.Deleted {
+	case model.DeletedExclude:
+		conds = append(conds, "deleted_at IS NULL")
+	case model.DeletedOnly:
+		conds = append(conds, "deleted_at IS NOT NULL")
+	}
+
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conds = append(conds, fmt.Sprintf("user_id = $%d", len(args)))
 	}
 
-	if sub.Price > 0 {
-		updates = append(updates, fmt.Sprintf("price = $%d", len(updates)+1))
-		args = append(args, sub.Price)
+	if filter.ServiceName != "" {
+		args = append(args, likePrefix(filter.ServiceName))
+		conds = append(conds, fmt.Sprintf(`service_name ILIKE $%d ESCAPE '\'`, len(args)))
 	}
 
-	if sub.EndDate != "" {
-		updates = append(updates, fmt.Sprintf("end_date = TO_DATE($%d, 'MM-YYYY')", len(updates)+1))
-		args = append(args, sub.EndDate)
+	if filter.Currency != "" {
+		args = append(args, filter.Currency)
+		conds = append(conds, fmt.Sprintf("currency = $%d", len(args)))
 	}
 
-	return updates, args
+	if filter.MinPrice != nil {
+		args = append(args, int64(*filter.MinPrice))
+		conds = append(conds, fmt.Sprintf("price_minor >= $%d", len(args)))
+	}
+
+	if filter.MaxPrice != nil {
+		args = append(args, int64(*filter.MaxPrice))
+		conds = append(conds, fmt.Sprintf("price_minor <= $%d", len(args)))
+	}
+
+	if filter.ActiveFrom != nil {
+		args = append(args, *filter.ActiveFrom, *filter.ActiveTo)
+		conds = append(conds, fmt.Sprintf(
+			"start_date <= $%d AND (end_date IS NULL OR end_date > $%d)", len(args), len(args)-1,
+		))
+	}
+
+	if filter.StartFrom != nil {
+		args = append(args, *filter.StartFrom)
+		conds = append(conds, fmt.Sprintf("start_date >= $%d", len(args)))
+	}
+
+	if filter.StartTo != nil {
+		args = append(args, *filter.StartTo)
+		conds = append(conds, fmt.Sprintf("start_date <= $%d", len(args)))
+	}
+
+	if filter.EndFrom != nil {
+		args = append(args, *filter.EndFrom)
+		conds = append(conds, fmt.Sprintf("end_date >= $%d", len(args)))
+	}
+
+	if filter.EndTo != nil {
+		args = append(args, *filter.EndTo)
+		conds = append(conds, fmt.Sprintf("end_date <= $%d", len(args)))
+	}
+
+	direction, cmp := "ASC", ">"
+	if filter.Desc {
+		direction, cmp = "DESC", "<"
+	}
+
+	if filter.Cursor != nil {
+		value, err := filter.Cursor.Arg(filter.Sort)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("%w: %w", model.ErrInvalidCursor, err)
+		}
+
+		args = append(args, value, filter.Cursor.ID)
+		conds = append(conds, fmt.Sprintf(
+			"(%s, id) %s ($%d, $%d)", column, cmp, len(args)-1, len(args),
+		))
+	}
+
+	orderBy := fmt.Sprintf("%s %s, id %s", column, direction, direction)
+
+	if len(conds) == 0 {
+		return "", orderBy, args, nil
+	}
+
+	return "WHERE " + strings.Join(conds, " AND "), orderBy, args, nil
 }
 
-// getSubscriptionStartDate Получение start_date из базы данных.
-func (s *Storage) getSubscriptionStartDate(ctx context.Context, subID int64) *time.Time {
-	const fn = "psql.UpdateSubscription"
-	log := s.log.With(
-		slog.String("fn", fn),
-		slog.Int64("subID", subID),
+// likePrefix Экранирование строки для поиска по префиксу через ILIKE.
+func likePrefix(prefix string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+	return replacer.Replace(prefix) + "%"
+}
+
+// prepareCostFilter Сборка условия WHERE для CostSubscription
+// из заданных фильтров. Пустые фильтры не попадают в условие.
+func prepareCostFilter(filter *model.CostParams) (string, []any) {
+	var (
+		conds []string
+		args  []any
 	)
 
-	tx, err := s.db.Begin(ctx)
-	if err != nil {
-		log.Error("failed to begin transaction", slog.String("err", err.Error()))
+	if !filter.IncludeDeleted {
+		conds = append(conds, "deleted_at IS NULL")
+	}
 
-		return nil
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conds = append(conds, fmt.Sprintf("user_id = $%d", len(args)))
 	}
 
-	defer func() {
-		err := tx.Rollback(ctx)
-		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
-			log.Error("failed to rollback transaction", slog.String("err", err.Error()))
+	if len(filter.ServiceNames) > 0 {
+		keys := make([]string, len(filter.ServiceNames))
+		for i, name := range filter.ServiceNames {
+			keys[i] = model.ServiceKey(name)
 		}
-	}()
 
-	var startDateFromDB *time.Time
+		args = append(args, keys)
+		conds = append(conds, fmt.Sprintf(
+			"service_id IN (SELECT service_id FROM service_names WHERE name_key = ANY($%d))", len(args),
+		))
+	}
+
+	if filter.StartDate != nil {
+		args = append(args, *filter.StartDate)
+		conds = append(conds, fmt.Sprintf("(end_date IS NULL OR end_date > $%d)", len(args)))
+	}
+
+	if filter.EndDate != nil {
+		args = append(args, *filter.EndDate)
+		conds = append(conds, fmt.Sprintf("start_date <= $%d", len(args)))
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
 
-	err = tx.QueryRow(ctx, storage.ReadSubscriptionStartDateSchema, subID).Scan(
-		&startDateFromDB,
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+// getSubPage Сканирование ответа для формирования страницы списка подписок.
+// Ответ должен содержать на одну запись больше размера страницы,
+// если следующая страница существует.
+func (s *Storage) getSubPage(rows pgx.Rows, filter *model.ListParams) (*model.SubscriptionPage, error) {
+	page := model.SubscriptionPage{Subscriptions: []*model.Subscription{}}
+
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(page.Subscriptions) == filter.Limit {
+			last := page.Subscriptions[len(page.Subscriptions)-1]
+			page.NextCursor = model.NewListCursor(filter, last).Encode()
+
+			break
+		}
+
+		page.Subscriptions = append(page.Subscriptions, sub)
+	}
+
+	rows.Close()
+
+	if rows.Err() != nil {
+		return nil, storageErr(storage.ErrExecSchema, rows.Err())
+	}
+
+	return &page, nil
+}
+
+// scanSubscription Сканирование подписки из строки ответа
+// и преобразование дат через model.FormatDate. Если строки нет,
+// возвращает storage.ErrSubNotFound.
+func scanSubscription(row pgx.Row) (*model.Subscription, error) {
+	var (
+		sub       model.Subscription
+		startDate time.Time
+		endDate   *time.Time
 	)
-	if err != nil {
-		log.Error("failed to exec schema", slog.String("err", err.Error()))
 
-		return nil
+	err := row.Scan(
+		&sub.ID,
+		&sub.ServiceID,
+		&sub.ServiceName,
+		&sub.Price,
+		&sub.Currency,
+		&sub.BillingPeriod.Unit,
+		&sub.BillingPeriod.Count,
+		&sub.UserID,
+		&startDate,
+		&endDate,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+		&sub.Version,
+		&sub.DeletedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, storage.ErrSubNotFound
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		log.Error("failed to commit transaction", slog.String("err", err.Error()))
+	if err != nil {
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
 
-		return nil
+	sub.StartDate = model.FormatDate(startDate)
+	if endDate != nil {
+		sub.EndDate = model.FormatDate(*endDate)
 	}
 
-	return startDateFromDB
+	return &sub, nil
 }
 
-// getSubList Сканирование ответа для формирования списка подписок.
-func (s *Storage) getSubList(rows pgx.Rows) ([]*model.Subscription, error) {
-	var subs []*model.Subscription
+// getCostSources Сканирование ответа для подсчета стоимости подписок.
+func (s *Storage) getCostSources(rows pgx.Rows) ([]*model.CostSource, error) {
+	var sources []*model.CostSource
 
 	for rows.Next() {
-		var (
-			sub       model.Subscription
-			startDate time.Time
-			endDate   *time.Time
-		)
+		var src model.CostSource
 
 		err := rows.Scan(
-			&sub.ServiceName,
-			&sub.Price,
-			&sub.UserID,
-			&startDate,
-			&endDate,
+			&src.SubscriptionID,
+			&src.UserID,
+			&src.ServiceName,
+			&src.Price,
+			&src.Currency,
+			&src.BillingPeriod.Unit,
+			&src.BillingPeriod.Count,
+			&src.StartDate,
+			&src.EndDate,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("%w: %w", storage.ErrExecSchema, err)
+			return nil, storageErr(storage.ErrExecSchema, err)
 		}
 
-		sub.StartDate = startDate.Format("01-2006")
-		if endDate != nil {
-			sub.EndDate = endDate.Format("01-2006")
-		}
-
-		subs = append(subs, &sub)
+		sources = append(sources, &src)
 	}
 
 	if rows.Err() != nil {
-		return nil, fmt.Errorf("%w: %w", storage.ErrExecSchema, rows.Err())
+		return nil, storageErr(storage.ErrExecSchema, rows.Err())
 	}
 
-	return subs, nil
+	return sources, nil
+}
+
+// storageErr Ошибка хранилища sentinel с причиной err. Сбои базы данных
+// учитываются в метриках там, где возникают, а не в ответе клиенту.
+func storageErr(sentinel, err error) error {
+	metrics.ObserveStorageError(sentinel)
+
+	return fmt.Errorf("%w: %w", sentinel, err)
 }
@@ -0,0 +1,173 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetListEvents Получение страницы журнала изменений подписок.
+func (s *Storage) GetListEvents(ctx context.Context, filter *model.EventParams) (*model.EventPage, error) {
+	const fn = "psql.GetListEvents"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+	)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	where, args := prepareEventFilter(filter)
+	args = append(args, filter.Limit+1)
+	query := fmt.Sprintf(storage.ListEventsSchema, where, len(args))
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
+
+	page, err := s.getEventPage(rows, filter)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to scan rows", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrCommitTrans, err)
+	}
+
+	return page, nil
+}
+
+// writeEvent Запись события в журнал изменений внутри
+// транзакции изменения подписки.
+func (s *Storage) writeEvent(ctx context.Context, tx pgx.Tx, event *model.SubscriptionEvent) error {
+	_, err := tx.Exec(
+		ctx,
+		storage.CreateEventSchema,
+		event.SubscriptionID,
+		event.Action,
+		event.Actor,
+		event.RequestID,
+		event.Before,
+		event.After,
+	)
+	if err != nil {
+		return storageErr(storage.ErrExecSchema, err)
+	}
+
+	return nil
+}
+
+// prepareEventFilter Сборка условия WHERE для GetListEvents
+// из заданных фильтров.
+func prepareEventFilter(filter *model.EventParams) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+
+	if filter.SubscriptionID != nil {
+		args = append(args, *filter.SubscriptionID)
+		conds = append(conds, fmt.Sprintf("subscription_id = $%d", len(args)))
+	}
+
+	if filter.Actor != "" {
+		args = append(args, filter.Actor)
+		conds = append(conds, fmt.Sprintf("actor = $%d", len(args)))
+	}
+
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conds = append(conds, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conds = append(conds, fmt.Sprintf("created_at <= $%d", len(args)))
+	}
+
+	if filter.AfterID > 0 {
+		args = append(args, filter.AfterID)
+		conds = append(conds, fmt.Sprintf("id > $%d", len(args)))
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+// getEventPage Сканирование ответа для формирования страницы журнала.
+// Снимкам подписок из старых событий заполняются недостающие поля.
+// Ответ должен содержать на одну запись больше размера страницы,
+// если следующая страница существует.
+func (s *Storage) getEventPage(rows pgx.Rows, filter *model.EventParams) (*model.EventPage, error) {
+	page := model.EventPage{Events: []*model.SubscriptionEvent{}}
+
+	for rows.Next() {
+		var (
+			event     model.SubscriptionEvent
+			serviceID int64
+		)
+
+		err := rows.Scan(
+			&event.ID,
+			&event.SubscriptionID,
+			&event.Action,
+			&event.Actor,
+			&event.RequestID,
+			&event.Before,
+			&event.After,
+			&event.CreatedAt,
+			&serviceID,
+		)
+		if err != nil {
+			return nil, storageErr(storage.ErrExecSchema, err)
+		}
+
+		event.SetDefaults(serviceID)
+
+		if len(page.Events) == filter.Limit {
+			page.NextCursor = strconv.FormatInt(page.Events[len(page.Events)-1].ID, 10)
+
+			break
+		}
+
+		page.Events = append(page.Events, &event)
+	}
+
+	rows.Close()
+
+	if rows.Err() != nil {
+		return nil, storageErr(storage.ErrExecSchema, rows.Err())
+	}
+
+	return &page, nil
+}
@@ -0,0 +1,659 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// BatchSubscriptions Выполнение пакета операций в одной транзакции.
+// Подписки, пользователи и сервисы пакета читаются и блокируются
+// несколькими запросами, операции проверяются по порядку в памяти,
+// а изменения успешных операций и события журнала изменений
+// отправляются пакетами pgx, поэтому число обращений к базе данных
+// не зависит от размера пакета. Без atomic ошибка проверки операции
+// исключает из пакета только ее.
+//
+// Примечание: если параллельная транзакция создала пересекающуюся
+// подписку после проверки, ограничение subscriptions_no_overlap
+// откатывает весь пакет, и остальные успешные операции получают
+// storage.ErrBatchAbort даже без atomic.
+func (s *Storage) BatchSubscriptions(
+	ctx context.Context, ops []*model.BatchOperation, atomic bool,
+) ([]*model.BatchResult, error) {
+	const fn = "psql.BatchSubscriptions"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.Int("size", len(ops)),
+		slog.Bool("atomic", atomic),
+	)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	plan, err := loadBatch(ctx, tx, ops)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to load batch", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	results := make([]*model.BatchResult, len(ops))
+	steps := make([]*batchStep, 0, len(ops))
+
+	for i, op := range ops {
+		step, err := plan.apply(ctx, tx, op)
+		if err == nil {
+			step.index = i
+			steps = append(steps, step)
+
+			continue
+		}
+
+		results[i] = &model.BatchResult{Err: err}
+
+		log.ErrorContext(ctx, "failed batch operation", slog.Int("index", i), slog.String("err", err.Error()))
+
+		if atomic {
+			storage.AbortBatch(results, i)
+
+			return results, nil
+		}
+	}
+
+	events, failed, err := execBatch(ctx, tx, steps, results)
+	if failed >= 0 && errors.Is(err, storage.ErrSubOverlap) {
+		log.ErrorContext(
+			ctx,
+			"batch is rejected",
+			slog.Int("index", steps[failed].index),
+			slog.String("err", err.Error()),
+		)
+
+		for i, step := range steps {
+			results[step.index] = &model.BatchResult{Err: storage.ErrBatchAbort}
+			if i == failed {
+				results[step.index].Err = err
+			}
+		}
+
+		return results, nil
+	}
+
+	if err != nil {
+		log.ErrorContext(ctx, "failed to exec batch", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	if err := s.writeEvents(ctx, tx, events); err != nil {
+		log.ErrorContext(ctx, "failed to write events", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrCommitTrans, err)
+	}
+
+	log.InfoContext(ctx, "Batch is done!")
+
+	return results, nil
+}
+
+// batchRow Подписка в состоянии пакета с разобранными датами.
+type batchRow struct {
+	sub   *model.Subscription
+	start time.Time
+	end   *time.Time
+}
+
+// batchPlan Состояние подписок, пользователей и сервисов пакета,
+// по которому storage.PrepareSubscription проверяет операции без
+// обращений к базе данных.
+// Успешные операции сразу применяются к состоянию, чтобы следующие
+// операции пакета видели их результат.
+type batchPlan struct {
+	subs     map[int64]*batchRow
+	users    map[uuid.UUID]bool
+	services map[string]*model.Service
+	ids      []int64
+	now      time.Time
+}
+
+// batchStep Проверенная операция пакета и ее изменения.
+// Для delete sub пустая.
+type batchStep struct {
+	index int
+	op    *model.BatchOperation
+	prev  *model.Subscription
+	sub   *model.Subscription
+	start time.Time
+	end   *time.Time
+	price *model.PriceUpdate
+}
+
+// loadBatch Чтение состояния пакета внутри транзакции: блокировка
+// подписок update и delete и пользователей, поиск сервисов, чтение
+// подписок для проверки пересечения и выдача ID для create.
+func loadBatch(ctx context.Context, tx pgx.Tx, ops []*model.BatchOperation) (*batchPlan, error) {
+	plan := &batchPlan{
+		subs:     make(map[int64]*batchRow),
+		users:    make(map[uuid.UUID]bool),
+		services: make(map[string]*model.Service),
+		now:      time.Now().UTC(),
+	}
+
+	var (
+		subIDs  []int64
+		userIDs []uuid.UUID
+		keys    []string
+		creates int
+	)
+
+	for _, op := range ops {
+		switch op.Op {
+		case model.BatchCreate:
+			creates++
+		case model.BatchUpdate, model.BatchDelete:
+			subIDs = append(subIDs, op.ID)
+		}
+
+		if op.Op != model.BatchDelete && op.Subscription != nil {
+			userIDs = append(userIDs, op.Subscription.UserID)
+			keys = append(keys, model.ServiceKey(op.Subscription.ServiceName))
+		}
+	}
+
+	if len(subIDs) > 0 {
+		if err := plan.loadSubscriptions(ctx, tx, storage.LockSubscriptionsSchema, subIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := plan.loadUsers(ctx, tx, userIDs); err != nil {
+		return nil, err
+	}
+
+	if err := plan.loadServices(ctx, tx, keys); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(plan.services))
+	for _, svc := range plan.services {
+		names = append(names, svc.Name)
+	}
+
+	if len(names) > 0 && len(plan.users) > 0 {
+		err := plan.loadSubscriptions(
+			ctx, tx, storage.ListOverlapCandidatesSchema, slices.Collect(maps.Keys(plan.users)), names,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if creates > 0 {
+		rows, err := tx.Query(ctx, storage.ReserveSubscriptionIDsSchema, creates)
+		if err != nil {
+			return nil, storageErr(storage.ErrExecSchema, err)
+		}
+
+		plan.ids, err = pgx.CollectRows(rows, pgx.RowTo[int64])
+		if err != nil {
+			return nil, storageErr(storage.ErrExecSchema, err)
+		}
+	}
+
+	return plan, nil
+}
+
+// loadSubscriptions Добавление в состояние пакета подписок из ответа
+// на schema. Уже прочитанные подписки не заменяются.
+func (p *batchPlan) loadSubscriptions(ctx context.Context, tx pgx.Tx, schema string, args ...any) error {
+	rows, err := tx.Query(ctx, schema, args...)
+	if err != nil {
+		return storageErr(storage.ErrExecSchema, err)
+	}
+
+	subs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*model.Subscription, error) {
+		return scanSubscription(row)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		if _, ok := p.subs[sub.ID]; ok {
+			continue
+		}
+
+		start, end, err := sub.Dates()
+		if err != nil {
+			return err
+		}
+
+		p.subs[sub.ID] = &batchRow{sub: sub, start: start, end: end}
+	}
+
+	return nil
+}
+
+// loadUsers Блокировка существующих пользователей пакета от удаления.
+func (p *batchPlan) loadUsers(ctx context.Context, tx pgx.Tx, userIDs []uuid.UUID) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(ctx, storage.LockUsersSchema, userIDs)
+	if err != nil {
+		return storageErr(storage.ErrExecSchema, err)
+	}
+
+	users, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return storageErr(storage.ErrExecSchema, err)
+	}
+
+	for _, id := range users {
+		p.users[id] = true
+	}
+
+	return nil
+}
+
+// loadServices Поиск сервисов пакета по ключам названий.
+func (p *batchPlan) loadServices(ctx context.Context, tx pgx.Tx, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(ctx, storage.FindServicesSchema, keys)
+	if err != nil {
+		return storageErr(storage.ErrExecSchema, err)
+	}
+
+	services, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*model.Service, error) {
+		return scanService(row)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, svc := range services {
+		p.addService(svc)
+	}
+
+	return nil
+}
+
+// addService Добавление сервиса в состояние пакета по всем его ключам.
+func (p *batchPlan) addService(svc *model.Service) {
+	for _, key := range svc.Keys() {
+		p.services[key] = svc
+	}
+}
+
+// apply Проверка операции пакета по состоянию и применение к нему.
+// Ошибки такие же, как у одиночных запросов.
+func (p *batchPlan) apply(ctx context.Context, tx pgx.Tx, op *model.BatchOperation) (*batchStep, error) {
+	switch op.Op {
+	case model.BatchCreate:
+		return p.create(ctx, tx, op)
+	case model.BatchUpdate:
+		return p.update(ctx, tx, op)
+	case model.BatchDelete:
+		return p.delete(op)
+	default:
+		return nil, fmt.Errorf("unknown batch operation: %s", op.Op)
+	}
+}
+
+// create Проверка операции create через storage.PrepareSubscription.
+// Подписка получает заранее выданный ID, а отсутствующий сервис
+// создается только для прошедшей проверку операции.
+func (p *batchPlan) create(ctx context.Context, tx pgx.Tx, op *model.BatchOperation) (*batchStep, error) {
+	change, err := storage.PrepareSubscription(ctx, p, nil, op.Subscription, nil, p.now)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := p.ensureService(ctx, tx, change.Service)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := change.Sub
+	sub.ServiceID, sub.ServiceName = svc.ID, svc.Name
+	sub.ID, p.ids = p.ids[0], p.ids[1:]
+	sub.Version = 1
+	p.subs[sub.ID] = &batchRow{sub: sub, start: change.Start, end: change.End}
+
+	return &batchStep{op: op, sub: sub, start: change.Start, end: change.End}, nil
+}
+
+// update Проверка операции update через storage.PrepareSubscription.
+// Подписка заменяется целиком, как в PUT.
+func (p *batchPlan) update(ctx context.Context, tx pgx.Tx, op *model.BatchOperation) (*batchStep, error) {
+	row, err := p.lock(op.ID, op.Version, false)
+	if err != nil {
+		return nil, err
+	}
+
+	prev := *row.sub
+	replaced := prev
+	replaced.Replace(op.Subscription)
+
+	priceFrom, _ := op.PriceFrom()
+
+	change, err := storage.PrepareSubscription(ctx, p, &prev, &replaced, priceFrom, p.now)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := p.ensureService(ctx, tx, change.Service)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := change.Sub
+	sub.ServiceID, sub.ServiceName = svc.ID, svc.Name
+	sub.Version++
+	p.subs[op.ID] = &batchRow{sub: sub, start: change.Start, end: change.End}
+
+	return &batchStep{
+		op:    op,
+		prev:  &prev,
+		sub:   sub,
+		start: change.Start,
+		end:   change.End,
+		price: change.Price,
+	}, nil
+}
+
+// delete Проверка операции delete. С Hard удаляется и подписка
+// из корзины.
+func (p *batchPlan) delete(op *model.BatchOperation) (*batchStep, error) {
+	row, err := p.lock(op.ID, op.Version, op.Hard)
+	if err != nil {
+		return nil, err
+	}
+
+	prev := *row.sub
+
+	if op.Hard {
+		delete(p.subs, op.ID)
+	} else {
+		deleted := prev
+		deleted.DeletedAt = &p.now
+		deleted.Version++
+		p.subs[op.ID] = &batchRow{sub: &deleted, start: row.start, end: row.end}
+	}
+
+	return &batchStep{op: op, prev: &prev}, nil
+}
+
+// lock Подписка subID из состояния пакета с проверкой версии,
+// как в lockSubscription.
+func (p *batchPlan) lock(subID, version int64, includeDeleted bool) (*batchRow, error) {
+	row, ok := p.subs[subID]
+	if !ok || (!includeDeleted && row.sub.DeletedAt != nil) {
+		return nil, storage.ErrSubNotFound
+	}
+
+	if version != storage.AnyVersion && row.sub.Version != version {
+		return nil, fmt.Errorf("%w: expected %d, current %d", storage.ErrVersion, version, row.sub.Version)
+	}
+
+	return row, nil
+}
+
+// UserExists Проверка пользователя по пользователям, заблокированным
+// в loadBatch.
+func (p *batchPlan) UserExists(_ context.Context, userID uuid.UUID) (bool, error) {
+	return p.users[userID], nil
+}
+
+// FindService Сервис из состояния пакета по ключу названия.
+func (p *batchPlan) FindService(_ context.Context, name string) (*model.Service, error) {
+	if svc, ok := p.services[model.ServiceKey(name)]; ok {
+		return svc, nil
+	}
+
+	return nil, storage.ErrServiceNotFound
+}
+
+// FindOverlap Поиск пересекающейся подписки в состоянии пакета,
+// как в SubscriptionOverlapSchema.
+func (p *batchPlan) FindOverlap(
+	_ context.Context, sub *model.Subscription, subID int64, start time.Time, end *time.Time,
+) (int64, error) {
+	var conflict *batchRow
+
+	for _, other := range p.subs {
+		if other.sub.ID == subID || other.sub.DeletedAt != nil ||
+			other.sub.UserID != sub.UserID ||
+			other.sub.ServiceName != sub.ServiceName {
+			continue
+		}
+
+		if (end != nil && !end.After(other.start)) ||
+			(other.end != nil && !other.end.After(start)) {
+			continue
+		}
+
+		if conflict == nil || other.start.Before(conflict.start) ||
+			(other.start.Equal(conflict.start) && other.sub.ID < conflict.sub.ID) {
+			conflict = other
+		}
+	}
+
+	if conflict == nil {
+		return 0, nil
+	}
+
+	return conflict.sub.ID, nil
+}
+
+// ensureService Создание сервиса без ID внутри транзакции
+// и добавление его в состояние пакета.
+func (p *batchPlan) ensureService(ctx context.Context, tx pgx.Tx, svc *model.Service) (*model.Service, error) {
+	if svc.ID != 0 {
+		return svc, nil
+	}
+
+	created, err := ensureService(ctx, tx, svc)
+	if err != nil {
+		return nil, err
+	}
+
+	p.addService(created)
+
+	return created, nil
+}
+
+// execBatch Отправка изменений проверенных операций одним пакетом pgx
+// и заполнение их результатов. Возвращает события журнала изменений,
+// а при ошибке - индекс шага, на котором она возникла, или -1.
+func execBatch(
+	ctx context.Context, tx pgx.Tx, steps []*batchStep, results []*model.BatchResult,
+) ([]*model.SubscriptionEvent, int, error) {
+	if len(steps) == 0 {
+		return nil, -1, nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, step := range steps {
+		step.queue(batch)
+	}
+
+	br := tx.SendBatch(ctx, batch)
+	events := make([]*model.SubscriptionEvent, 0, len(steps))
+
+	for i, step := range steps {
+		sub, event, err := step.read(ctx, br)
+		if err != nil {
+			_ = br.Close()
+
+			return nil, i, err
+		}
+
+		results[step.index] = &model.BatchResult{Subscription: sub}
+		events = append(events, event)
+	}
+
+	if err := br.Close(); err != nil {
+		return nil, -1, storageErr(storage.ErrExecSchema, err)
+	}
+
+	return events, -1, nil
+}
+
+// queue Добавление запросов шага в пакет pgx.
+func (s *batchStep) queue(batch *pgx.Batch) {
+	switch s.op.Op {
+	case model.BatchCreate:
+		batch.Queue(
+			storage.BatchCreateSubscriptionSchema,
+			s.sub.ServiceName,
+			int64(s.sub.Price),
+			s.sub.Currency,
+			s.sub.BillingPeriod.Unit,
+			s.sub.BillingPeriod.Count,
+			s.sub.UserID,
+			s.start,
+			s.end,
+			s.sub.ServiceID,
+			s.sub.ID,
+		)
+		batch.Queue(storage.CreatePriceSchema, s.sub.ID, s.start, int64(s.sub.Price))
+	case model.BatchUpdate:
+		batch.Queue(
+			storage.UpdateSubscriptionSchema,
+			s.sub.ServiceName,
+			int64(s.sub.Price),
+			s.sub.Currency,
+			s.sub.BillingPeriod.Unit,
+			s.sub.BillingPeriod.Count,
+			s.sub.UserID,
+			s.start,
+			s.end,
+			s.sub.ServiceID,
+			s.sub.ID,
+		)
+
+		if s.price != nil {
+			batch.Queue(storage.DeletePricesSinceSchema, s.sub.ID, s.price.Since)
+			batch.Queue(storage.CreatePriceSchema, s.sub.ID, s.price.Change.EffectiveFrom, int64(s.price.Change.Price))
+		}
+	case model.BatchDelete:
+		if s.op.Hard {
+			batch.Queue(storage.DeleteSubscriptionSchema, s.op.ID)
+		} else {
+			batch.Queue(storage.SoftDeleteSubscriptionSchema, s.op.ID)
+		}
+	}
+}
+
+// read Чтение ответов на запросы шага в порядке queue. Возвращает
+// подписку для результата операции и событие для журнала изменений.
+func (s *batchStep) read(
+	ctx context.Context, br pgx.BatchResults,
+) (*model.Subscription, *model.SubscriptionEvent, error) {
+	switch s.op.Op {
+	case model.BatchCreate:
+		created, err := scanSubscription(br.QueryRow())
+		if err != nil {
+			return nil, nil, overlapError(err)
+		}
+
+		if _, err := br.Exec(); err != nil {
+			return nil, nil, storageErr(storage.ErrExecSchema, err)
+		}
+
+		return created, storage.NewEvent(ctx, model.EventCreated, created.ID, nil, created), nil
+	case model.BatchUpdate:
+		updated, err := scanSubscription(br.QueryRow())
+		if err != nil {
+			return nil, nil, overlapError(err)
+		}
+
+		if s.price != nil {
+			for range 2 {
+				if _, err := br.Exec(); err != nil {
+					return nil, nil, storageErr(storage.ErrExecSchema, err)
+				}
+			}
+		}
+
+		return updated, storage.NewEvent(ctx, model.EventUpdated, updated.ID, s.prev, updated), nil
+	default:
+		if s.op.Hard {
+			if _, err := br.Exec(); err != nil {
+				return nil, nil, storageErr(storage.ErrExecSchema, err)
+			}
+
+			return nil, storage.NewEvent(ctx, model.EventPurged, s.op.ID, s.prev, nil), nil
+		}
+
+		deleted, err := scanSubscription(br.QueryRow())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return nil, storage.NewEvent(ctx, model.EventDeleted, s.op.ID, s.prev, deleted), nil
+	}
+}
+
+// writeEvents Запись событий в журнал изменений одним пакетом pgx
+// внутри транзакции.
+func (s *Storage) writeEvents(ctx context.Context, tx pgx.Tx, events []*model.SubscriptionEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+
+	for _, event := range events {
+		batch.Queue(
+			storage.CreateEventSchema,
+			event.SubscriptionID,
+			event.Action,
+			event.Actor,
+			event.RequestID,
+			event.Before,
+			event.After,
+		)
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return storageErr(storage.ErrExecSchema, err)
+	}
+
+	return nil
+}
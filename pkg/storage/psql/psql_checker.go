@@ -9,12 +9,18 @@ import (
 
 	"github.com/SHSanderland/EffMobTest/pkg/model"
 	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // CheckSubscriptionID Проверка существует ли подписка в базе.
-func (s *Storage) CheckSubscriptionID(ctx context.Context, subID int64) (bool, error) {
+// Мягко удаленные подписки учитываются только с includeDeleted.
+func (s *Storage) CheckSubscriptionID(ctx context.Context, subID int64, includeDeleted bool) (bool, error) {
 	const fn = "psql.CheckSubscriptionID"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
 	log := s.log.With(
 		slog.String("fn", fn),
 		slog.Int64("subID", subID),
@@ -22,122 +28,148 @@ func (s *Storage) CheckSubscriptionID(ctx context.Context, subID int64) (bool, e
 
 	var hasID bool
 
-	tx, err := s.db.Begin(ctx)
+	tx, err := s.begin(ctx)
 	if err != nil {
-		log.Error("failed to begin transaction", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
 
-		return hasID, fmt.Errorf("%w: %w", storage.ErrBeginTrans, err)
+		return hasID, storageErr(storage.ErrBeginTrans, err)
 	}
 
 	defer func() {
 		err := tx.Rollback(ctx)
 		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
-			log.Error("failed to rollback transaction", slog.String("err", err.Error()))
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
 		}
 	}()
 
-	err = tx.QueryRow(ctx, storage.SubscriptionExistsSchema, subID).Scan(&hasID)
+	err = tx.QueryRow(ctx, storage.SubscriptionExistsSchema, subID, includeDeleted).Scan(&hasID)
 	if err != nil {
-		log.Error("failed to exec schema", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
 
-		return hasID, fmt.Errorf("%w: %w", storage.ErrExecSchema, err)
+		return hasID, storageErr(storage.ErrExecSchema, err)
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		log.Error("failed to commit transaction", slog.String("err", err.Error()))
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
 
-		return hasID, fmt.Errorf("%w: %w", storage.ErrCommitTrans, err)
+		return hasID, storageErr(storage.ErrCommitTrans, err)
 	}
 
-	log.Info("Subscription is checked!")
+	log.InfoContext(ctx, "Subscription is checked!")
 
 	return hasID, nil
 }
 
-// CheckSubscription Проверка активна ли подписка на текущий момент.
-// Примечание: Проверка учитывает ТОЛЬКО текущий момент. Она не
-// учитывает более сложные моменты с поиском по всей базе наложением дат.
-func (s *Storage) CheckSubscription(ctx context.Context, sub *model.Subscription) (bool, error) {
-	const fn = "psql.CheckSubscription"
-	log := s.log.With(
-		slog.String("fn", fn),
-		slog.String("userID", sub.UserID.String()),
-	)
-
-	var isActive bool
-
-	tx, err := s.db.Begin(ctx)
+// lockSubscription Чтение подписки с блокировкой строки до конца
+// транзакции и проверка ее версии. Возвращает storage.ErrSubNotFound,
+// если подписки нет или она удалена без includeDeleted, и
+// storage.ErrVersion, если версия не совпадает с version
+// (кроме storage.AnyVersion).
+func (s *Storage) lockSubscription(
+	ctx context.Context, tx pgx.Tx, subID, version int64, includeDeleted bool,
+) (*model.Subscription, error) {
+	sub, err := scanSubscription(tx.QueryRow(
+		ctx, storage.ReadSubscriptionForUpdateSchema, subID, includeDeleted,
+	))
 	if err != nil {
-		log.Error("failed to begin transaction", slog.String("err", err.Error()))
+		return nil, err
+	}
 
-		return isActive, fmt.Errorf("%w: %w", storage.ErrBeginTrans, err)
+	if version != storage.AnyVersion && sub.Version != version {
+		return nil, fmt.Errorf("%w: expected %d, current %d", storage.ErrVersion, version, sub.Version)
 	}
 
-	defer func() {
-		err := tx.Rollback(ctx)
-		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
-			log.Error("failed to rollback transaction", slog.String("err", err.Error()))
-		}
-	}()
+	return sub, nil
+}
 
-	err = tx.QueryRow(
-		ctx,
-		storage.SubscriptionActiveSchema,
-		sub.UserID,
-		sub.ServiceName,
-	).Scan(&isActive)
-	if err != nil {
-		log.Error("failed to exec schema", slog.String("err", err.Error()))
+// exclusionViolation Код ошибки PostgreSQL при нарушении
+// ограничения EXCLUDE.
+const exclusionViolation = "23P01"
 
-		return isActive, fmt.Errorf("%w: %w", storage.ErrExecSchema, err)
+// checkOverlap Проверка пересечения периода подписки с другими
+// подписками того же пользователя на тот же сервис при восстановлении
+// подписки. subID исключается из проверки.
+func (s *Storage) checkOverlap(ctx context.Context, tx pgx.Tx, subID int64, sub *model.Subscription) error {
+	startDate, endDate, err := sub.Dates()
+	if err != nil {
+		return err
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		log.Error("failed to commit transaction", slog.String("err", err.Error()))
-
-		return isActive, fmt.Errorf("%w: %w", storage.ErrCommitTrans, err)
+	conflictID, err := txState{tx}.FindOverlap(ctx, sub, subID, startDate, endDate)
+	if err != nil {
+		return err
 	}
 
-	log.Info("Subscription is checked!")
+	if conflictID != 0 {
+		return &storage.OverlapError{ConflictID: conflictID}
+	}
 
-	return isActive, nil
+	return nil
 }
 
-// CheckSubscriptionForUpdate Проверка валидности обновления подписки.
-// Проверяет обновляемое end_date > start_date.
-func (s *Storage) CheckSubscriptionForUpdate(ctx context.Context, subID int64, sub *model.Subscription) (bool, error) {
-	const fn = "psql.CheckSubscription"
-	log := s.log.With(
-		slog.String("fn", fn),
-		slog.String("userID", sub.UserID.String()),
-	)
+// txState Данные базы данных внутри транзакции tx, по которым
+// storage.PrepareSubscription проверяет одиночные создание
+// и обновление подписки.
+type txState struct {
+	tx pgx.Tx
+}
 
-	if sub.Price < 0 {
-		log.Error("update price is negetive", slog.Int("price", sub.Price))
+// UserExists Проверка пользователя. Пользователь блокируется
+// от удаления до конца транзакции.
+func (s txState) UserExists(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var id uuid.UUID
 
+	err := s.tx.QueryRow(ctx, storage.LockUserSchema, userID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
 		return false, nil
 	}
 
-	if sub.EndDate == "" {
-		return true, nil
+	if err != nil {
+		return false, storageErr(storage.ErrExecSchema, err)
 	}
 
-	layout := "01-2006"
+	return true, nil
+}
 
-	endDateFromSub, err := time.Parse(layout, sub.EndDate)
-	if err != nil {
-		log.Error("failed to parse date", slog.String("err", err.Error()))
+// FindService Поиск сервиса каталога по ключу названия.
+func (s txState) FindService(ctx context.Context, name string) (*model.Service, error) {
+	return scanService(s.tx.QueryRow(ctx, storage.FindServiceSchema, model.ServiceKey(name)))
+}
 
-		return false, fmt.Errorf("failed to parse date: %w", err)
-	}
+// FindOverlap Поиск пересекающейся подписки запросом
+// SubscriptionOverlapSchema.
+func (s txState) FindOverlap(
+	ctx context.Context, sub *model.Subscription, subID int64, start time.Time, end *time.Time,
+) (int64, error) {
+	var conflictID int64
 
-	startDateFromDB := s.getSubscriptionStartDate(ctx, subID)
+	err := s.tx.QueryRow(
+		ctx,
+		storage.SubscriptionOverlapSchema,
+		sub.UserID,
+		sub.ServiceName,
+		subID,
+		start,
+		end,
+	).Scan(&conflictID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
 
-	if !endDateFromSub.After(*startDateFromDB) {
-		return false, nil
+	if err != nil {
+		return 0, storageErr(storage.ErrExecSchema, err)
 	}
 
-	log.Info("Subscription is checked!")
+	return conflictID, nil
+}
 
-	return true, nil
+// overlapError Преобразование нарушения ограничения subscriptions_no_overlap
+// в *storage.OverlapError. Остальные ошибки возвращаются без изменений.
+func overlapError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == exclusionViolation {
+		return &storage.OverlapError{}
+	}
+
+	return err
 }
@@ -0,0 +1,97 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+// ExportSubscriptions Выгрузка подписок по фильтрам списка.
+// Строки читаются из базы по мере вызова export, поэтому выгрузка
+// не держит весь результат в памяти.
+func (s *Storage) ExportSubscriptions(
+	ctx context.Context, filter *model.ListParams, export storage.ExportFunc,
+) error {
+	const fn = "psql.ExportSubscriptions"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.Any("userID", filter.UserID),
+		slog.String("serviceName", filter.ServiceName),
+		slog.String("sort", filter.SortKey()),
+	)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	where, orderBy, args, err := prepareListFilter(filter)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to prepare filter", slog.String("err", err.Error()))
+
+		return err
+	}
+
+	rows, err := tx.Query(ctx, fmt.Sprintf(storage.ExportSubscriptionsSchema, where, orderBy), args...)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return storageErr(storage.ErrExecSchema, err)
+	}
+
+	defer rows.Close()
+
+	count := 0
+
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to scan rows", slog.String("err", err.Error()))
+
+			return err
+		}
+
+		if err := export(sub); err != nil {
+			log.ErrorContext(ctx, "failed to export subscription", slog.String("err", err.Error()))
+
+			return err
+		}
+
+		count++
+	}
+
+	if rows.Err() != nil {
+		log.ErrorContext(ctx, "failed to read rows", slog.String("err", rows.Err().Error()))
+
+		return storageErr(storage.ErrExecSchema, rows.Err())
+	}
+
+	rows.Close()
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return storageErr(storage.ErrCommitTrans, err)
+	}
+
+	log.InfoContext(ctx, "Subscriptions are exported!", slog.Int("count", count))
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateAPIKey Сохранение API-ключа. В базу записывается только хеш ключа,
+// пользователь ключа должен существовать.
+func (s *Storage) CreateAPIKey(ctx context.Context, key *model.APIKey) (*model.APIKey, error) {
+	const fn = "psql.CreateAPIKey"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.String("name", key.Name),
+	)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	if key.UserID != nil {
+		if err := checkUser(ctx, tx, *key.UserID); err != nil {
+			log.ErrorContext(ctx, "bad key user", slog.String("err", err.Error()))
+
+			return nil, err
+		}
+	}
+
+	created, err := scanAPIKey(tx.QueryRow(
+		ctx, storage.CreateAPIKeySchema, key.Name, key.Prefix, key.Hash, key.Role, key.UserID,
+	))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to create API key", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrCommitTrans, err)
+	}
+
+	log.InfoContext(ctx, "API key is created!", slog.Int64("keyID", created.ID))
+
+	return created, nil
+}
+
+// FindAPIKey Поиск действующего API-ключа по хешу среди ключей всех
+// арендаторов. Арендатор запроса еще не известен, поэтому запрос
+// выполняется от владельца таблиц без транзакции арендатора.
+func (s *Storage) FindAPIKey(ctx context.Context, hash string) (*model.APIKey, error) {
+	const fn = "psql.FindAPIKey"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	return scanAPIKey(s.db.QueryRow(ctx, storage.FindAPIKeySchema, hash))
+}
+
+// ListAPIKeys Получение всех API-ключей арендатора, в том числе отозванных.
+func (s *Storage) ListAPIKeys(ctx context.Context) ([]*model.APIKey, error) {
+	const fn = "psql.ListAPIKeys"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(slog.String("fn", fn))
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	rows, err := tx.Query(ctx, storage.ListAPIKeysSchema)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
+
+	keys, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*model.APIKey, error) {
+		return scanAPIKey(row)
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to scan rows", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrCommitTrans, err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey Отзыв API-ключа арендатора. Отозванный ключ остается в списке.
+func (s *Storage) RevokeAPIKey(ctx context.Context, keyID int64) error {
+	const fn = "psql.RevokeAPIKey"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.Int64("keyID", keyID),
+	)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, storage.RevokeAPIKeySchema, keyID)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return storageErr(storage.ErrExecSchema, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storage.ErrAPIKeyNotFound
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return storageErr(storage.ErrCommitTrans, err)
+	}
+
+	log.InfoContext(ctx, "API key is revoked!")
+
+	return nil
+}
+
+// scanAPIKey Сканирование строки API-ключа. Если строки нет,
+// возвращает storage.ErrAPIKeyNotFound.
+func scanAPIKey(row pgx.Row) (*model.APIKey, error) {
+	var key model.APIKey
+
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Role, &key.UserID, &key.TenantID, &key.CreatedAt, &key.RevokedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, storage.ErrAPIKeyNotFound
+	}
+
+	if err != nil {
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
+
+	return &key, nil
+}
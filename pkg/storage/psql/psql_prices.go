@@ -0,0 +1,138 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+// ListPrices Получение истории цен подписки.
+// Возвращает storage.ErrSubNotFound, если подписки нет или она удалена.
+func (s *Storage) ListPrices(ctx context.Context, subID int64) (model.PriceHistory, error) {
+	const fn = "psql.ListPrices"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.Int64("subID", subID),
+	)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	var exists bool
+
+	if err := tx.QueryRow(ctx, storage.SubscriptionExistsSchema, subID, false).Scan(&exists); err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
+
+	if !exists {
+		return nil, storage.ErrSubNotFound
+	}
+
+	rows, err := tx.Query(ctx, storage.ListPricesSchema, subID)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
+
+	history, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.PriceChange, error) {
+		var change model.PriceChange
+		err := row.Scan(&change.EffectiveFrom, &change.Price)
+
+		return change, err
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to scan rows", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrCommitTrans, err)
+	}
+
+	return history, nil
+}
+
+// updatePrices Изменение истории цен подписки внутри транзакции.
+// Ничего не делает, если u == nil.
+func updatePrices(ctx context.Context, tx pgx.Tx, subID int64, u *model.PriceUpdate) error {
+	if u == nil {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, storage.DeletePricesSinceSchema, subID, u.Since); err != nil {
+		return storageErr(storage.ErrExecSchema, err)
+	}
+
+	_, err := tx.Exec(ctx, storage.CreatePriceSchema, subID, u.Change.EffectiveFrom, int64(u.Change.Price))
+	if err != nil {
+		return storageErr(storage.ErrExecSchema, err)
+	}
+
+	return nil
+}
+
+// costPrices Заполнение истории цен источников подсчета стоимости
+// внутри транзакции.
+func costPrices(ctx context.Context, tx pgx.Tx, sources []*model.CostSource) error {
+	if len(sources) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(sources))
+	sourceIdx := make(map[int64]*model.CostSource, len(sources))
+
+	for i, src := range sources {
+		ids[i] = src.SubscriptionID
+		sourceIdx[src.SubscriptionID] = src
+	}
+
+	rows, err := tx.Query(ctx, storage.CostPricesSchema, ids)
+	if err != nil {
+		return storageErr(storage.ErrExecSchema, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			subID  int64
+			change model.PriceChange
+		)
+
+		if err := rows.Scan(&subID, &change.EffectiveFrom, &change.Price); err != nil {
+			return storageErr(storage.ErrExecSchema, err)
+		}
+
+		src := sourceIdx[subID]
+		src.Prices = append(src.Prices, change)
+	}
+
+	if rows.Err() != nil {
+		return storageErr(storage.ErrExecSchema, rows.Err())
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package psql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/big"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ListRates Получение всех курсов валют.
+func (s *Storage) ListRates(ctx context.Context) ([]*model.Rate, error) {
+	const fn = "psql.ListRates"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+	)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	rates, err := listRates(ctx, tx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get rates", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrCommitTrans, err)
+	}
+
+	return rates, nil
+}
+
+// SetRate Создание или замена курса пары валют.
+func (s *Storage) SetRate(ctx context.Context, from, to string, rate *big.Rat) (*model.Rate, error) {
+	const fn = "psql.SetRate"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.String("from", from),
+		slog.String("to", to),
+	)
+
+	var value pgtype.Numeric
+	if err := value.Scan(model.FormatRate(rate).String()); err != nil {
+		log.ErrorContext(ctx, "failed to prepare rate", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	saved, err := scanRate(tx.QueryRow(ctx, storage.SetRateSchema, from, to, value))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrCommitTrans, err)
+	}
+
+	log.InfoContext(ctx, "Rate is set!", slog.String("rate", saved.Rate.String()))
+
+	return saved, nil
+}
+
+// DeleteRate Удаление курса пары валют.
+func (s *Storage) DeleteRate(ctx context.Context, from, to string) error {
+	const fn = "psql.DeleteRate"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.String("from", from),
+		slog.String("to", to),
+	)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, storage.DeleteRateSchema, from, to)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return storageErr(storage.ErrExecSchema, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storage.ErrRateNotFound
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return storageErr(storage.ErrCommitTrans, err)
+	}
+
+	log.InfoContext(ctx, "Rate is deleted!")
+
+	return nil
+}
+
+// listRates Получение всех курсов валют внутри транзакции.
+func listRates(ctx context.Context, tx pgx.Tx) ([]*model.Rate, error) {
+	rows, err := tx.Query(ctx, storage.ListRatesSchema)
+	if err != nil {
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
+
+	defer rows.Close()
+
+	rates := []*model.Rate{}
+
+	for rows.Next() {
+		rate, err := scanRate(rows)
+		if err != nil {
+			return nil, storageErr(storage.ErrExecSchema, err)
+		}
+
+		rates = append(rates, rate)
+	}
+
+	if rows.Err() != nil {
+		return nil, storageErr(storage.ErrExecSchema, rows.Err())
+	}
+
+	return rates, nil
+}
+
+// scanRate Сканирование курса из строки ответа.
+func scanRate(row pgx.Row) (*model.Rate, error) {
+	var (
+		rate  model.Rate
+		value string
+	)
+
+	if err := row.Scan(&rate.From, &rate.To, &value, &rate.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	rate.Rate = json.Number(value)
+
+	return &rate, nil
+}
@@ -0,0 +1,461 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateService Создание сервиса в каталоге.
+// Возвращает storage.ErrServiceExists, если название или синоним заняты.
+func (s *Storage) CreateService(ctx context.Context, svc *model.Service) (*model.Service, error) {
+	const fn = "psql.CreateService"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.String("name", svc.Name),
+	)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	created, err := createService(ctx, tx, svc)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to create service", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrCommitTrans, err)
+	}
+
+	log.InfoContext(ctx, "Service is created!", slog.Int64("serviceID", created.ID))
+
+	return created, nil
+}
+
+// ReadService Чтение сервиса из каталога по ID.
+func (s *Storage) ReadService(ctx context.Context, serviceID int64) (*model.Service, error) {
+	const fn = "psql.ReadService"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.Int64("serviceID", serviceID),
+	)
+
+	return s.readService(ctx, log, storage.ReadServiceSchema, serviceID)
+}
+
+// FindService Поиск сервиса в каталоге по названию или синониму
+// без учета регистра и лишних пробелов.
+func (s *Storage) FindService(ctx context.Context, name string) (*model.Service, error) {
+	const fn = "psql.FindService"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.String("name", name),
+	)
+
+	return s.readService(ctx, log, storage.FindServiceSchema, model.ServiceKey(name))
+}
+
+// UpdateService Замена сервиса в каталоге. Сервис блокируется на время
+// транзакции, синонимы заменяются целиком, а при переименовании его
+// подписки получают новое название и событие update в журнале.
+func (s *Storage) UpdateService(
+	ctx context.Context, serviceID int64, svc *model.Service,
+) (*model.Service, error) {
+	const fn = "psql.UpdateService"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.Int64("serviceID", serviceID),
+	)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	if _, err := scanService(tx.QueryRow(ctx, storage.ReadServiceForUpdateSchema, serviceID)); err != nil {
+		log.ErrorContext(ctx, "failed to lock service", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	updated, err := scanService(tx.QueryRow(
+		ctx,
+		storage.UpdateServiceSchema,
+		svc.Name,
+		svc.Aliases,
+		svc.Category,
+		defaultPrice(svc),
+		svc.Currency,
+		serviceID,
+	))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, storage.DeleteServiceNamesSchema, serviceID); err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
+
+	if err := writeServiceNames(ctx, tx, updated); err != nil {
+		log.ErrorContext(ctx, "failed to write service names", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	events, err := renameServiceSubscriptions(ctx, tx, serviceID, updated.Name)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to rename subscriptions", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	if err := s.writeEvents(ctx, tx, events); err != nil {
+		log.ErrorContext(ctx, "failed to write events", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrCommitTrans, err)
+	}
+
+	log.InfoContext(ctx, "Service is updated!")
+
+	return updated, nil
+}
+
+// renameServiceSubscriptions Новое название сервиса в его подписках.
+// Возвращает события update переименованных подписок.
+func renameServiceSubscriptions(
+	ctx context.Context, tx pgx.Tx, serviceID int64, name string,
+) ([]*model.SubscriptionEvent, error) {
+	rows, err := tx.Query(ctx, storage.LockServiceSubscriptionsSchema, serviceID, name)
+	if err != nil {
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
+
+	before, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*model.Subscription, error) {
+		return scanSubscription(row)
+	})
+	if err != nil || len(before) == 0 {
+		return nil, err
+	}
+
+	rows, err = tx.Query(ctx, storage.RenameServiceSubscriptionsSchema, serviceID, name)
+	if err != nil {
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
+
+	after, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*model.Subscription, error) {
+		return scanSubscription(row)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	renamed := make(map[int64]*model.Subscription, len(after))
+	for _, sub := range after {
+		renamed[sub.ID] = sub
+	}
+
+	events := make([]*model.SubscriptionEvent, 0, len(before))
+
+	for _, sub := range before {
+		if a, ok := renamed[sub.ID]; ok {
+			events = append(events, storage.NewEvent(ctx, model.EventUpdated, sub.ID, sub, a))
+		}
+	}
+
+	return events, nil
+}
+
+// DeleteService Удаление сервиса из каталога. Возвращает
+// storage.ErrServiceInUse, если на сервис ссылаются подписки.
+func (s *Storage) DeleteService(ctx context.Context, serviceID int64) error {
+	const fn = "psql.DeleteService"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.Int64("serviceID", serviceID),
+	)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	if _, err := scanService(tx.QueryRow(ctx, storage.ReadServiceForUpdateSchema, serviceID)); err != nil {
+		log.ErrorContext(ctx, "failed to lock service", slog.String("err", err.Error()))
+
+		return err
+	}
+
+	var inUse bool
+
+	if err := tx.QueryRow(ctx, storage.ServiceInUseSchema, serviceID).Scan(&inUse); err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return storageErr(storage.ErrExecSchema, err)
+	}
+
+	if inUse {
+		return storage.ErrServiceInUse
+	}
+
+	if _, err := tx.Exec(ctx, storage.DeleteServiceSchema, serviceID); err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return storageErr(storage.ErrExecSchema, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return storageErr(storage.ErrCommitTrans, err)
+	}
+
+	log.InfoContext(ctx, "Service is deleted!")
+
+	return nil
+}
+
+// ListServices Получение сервисов каталога, отсортированных
+// по названию. Пустая category означает все категории.
+func (s *Storage) ListServices(ctx context.Context, category string) ([]*model.Service, error) {
+	const fn = "psql.ListServices"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.String("category", category),
+	)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	rows, err := tx.Query(ctx, storage.ListServicesSchema, category)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
+
+	services, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*model.Service, error) {
+		return scanService(row)
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to scan rows", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrCommitTrans, err)
+	}
+
+	return services, nil
+}
+
+// readService Чтение одного сервиса по запросу schema в отдельной
+// транзакции.
+func (s *Storage) readService(ctx context.Context, log *slog.Logger, schema string, arg any) (*model.Service, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	svc, err := scanService(tx.QueryRow(ctx, schema, arg))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrCommitTrans, err)
+	}
+
+	return svc, nil
+}
+
+// createService Создание сервиса и ключей его названий внутри
+// транзакции. Если ключ занят, созданная строка удаляется
+// и возвращается storage.ErrServiceExists.
+func createService(ctx context.Context, tx pgx.Tx, svc *model.Service) (*model.Service, error) {
+	created, err := scanService(tx.QueryRow(
+		ctx,
+		storage.CreateServiceSchema,
+		svc.Name,
+		svc.Aliases,
+		svc.Category,
+		defaultPrice(svc),
+		svc.Currency,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeServiceNames(ctx, tx, created); err != nil {
+		if _, delErr := tx.Exec(ctx, storage.DeleteServiceSchema, created.ID); delErr != nil {
+			return nil, storageErr(storage.ErrExecSchema, delErr)
+		}
+
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// ensureService Сервис подписки с ID. Сервис без ID, который
+// storage.PrepareSubscription вернул для нового названия, создается
+// внутри транзакции. Если сервис с тем же названием одновременно
+// создала другая транзакция, используется он.
+func ensureService(ctx context.Context, tx pgx.Tx, svc *model.Service) (*model.Service, error) {
+	if svc.ID != 0 {
+		return svc, nil
+	}
+
+	created, err := createService(ctx, tx, svc)
+	if !errors.Is(err, storage.ErrServiceExists) {
+		return created, err
+	}
+
+	return scanService(tx.QueryRow(ctx, storage.FindServiceSchema, model.ServiceKey(svc.Name)))
+}
+
+// writeServiceNames Запись ключей названия и синонимов сервиса внутри
+// транзакции. Возвращает storage.ErrServiceExists, если ключ занят.
+func writeServiceNames(ctx context.Context, tx pgx.Tx, svc *model.Service) error {
+	for _, key := range svc.Keys() {
+		tag, err := tx.Exec(ctx, storage.CreateServiceNameSchema, key, svc.ID)
+		if err != nil {
+			return storageErr(storage.ErrExecSchema, err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("%w: %q", storage.ErrServiceExists, key)
+		}
+	}
+
+	return nil
+}
+
+// scanService Сканирование строки сервиса. Если строки нет,
+// возвращает storage.ErrServiceNotFound.
+func scanService(row pgx.Row) (*model.Service, error) {
+	var svc model.Service
+
+	err := row.Scan(
+		&svc.ID,
+		&svc.Name,
+		&svc.Aliases,
+		&svc.Category,
+		&svc.DefaultPrice,
+		&svc.Currency,
+		&svc.CreatedAt,
+		&svc.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, storage.ErrServiceNotFound
+	}
+
+	if err != nil {
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
+
+	return &svc, nil
+}
+
+// defaultPrice Цена сервиса по умолчанию для записи в базу данных.
+func defaultPrice(svc *model.Service) *int64 {
+	if svc.DefaultPrice == nil {
+		return nil
+	}
+
+	price := int64(*svc.DefaultPrice)
+
+	return &price
+}
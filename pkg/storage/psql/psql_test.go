@@ -0,0 +1,65 @@
+package psql
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/config"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/SHSanderland/EffMobTest/pkg/storage/memory"
+	"github.com/SHSanderland/EffMobTest/pkg/storage/storagetest"
+)
+
+// openTestDB Подключение к тестовой базе данных из TEST_DSN, без нее
+// тест пропускается. Миграции берутся из TEST_SURL, по умолчанию
+// из каталога migrations репозитория. Тесты работают в своих
+// арендаторах, поэтому база может быть не пустой.
+func openTestDB(t *testing.T) *Storage {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DSN is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	db, err := InitDB(ctx, slog.New(slog.DiscardHandler), &config.Config{
+		Database: config.Database{
+			DSN:       dsn,
+			SourceURL: cmp.Or(os.Getenv("TEST_SURL"), "file://../../../migrations"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("InitDB unexpected error: %v", err)
+	}
+
+	t.Cleanup(db.CloseConnection)
+
+	return db
+}
+
+func TestStorage(t *testing.T) {
+	db := openTestDB(t)
+
+	storagetest.Run(t, func(*testing.T) storage.Storage {
+		return db
+	})
+}
+
+func TestParity(t *testing.T) {
+	db := openTestDB(t)
+
+	want := storagetest.Transcript(t, memory.InitStorage(slog.New(slog.DiscardHandler)))
+	got := storagetest.Transcript(t, db)
+
+	if !slices.Equal(got, want) {
+		t.Errorf("psql transcript differs from memory:\n got: %q\nwant: %q", got, want)
+	}
+}
@@ -0,0 +1,87 @@
+package psql
+
+import (
+	"context"
+	"strings"
+
+	"github.com/SHSanderland/EffMobTest/pkg/tracing"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryTracer Трассировка запросов pgx: спан на каждый SQL-запрос
+// и пакет запросов, дочерний к спану метода Storage. Аргументы запросов
+// не записываются, так как содержат данные пользователей.
+type queryTracer struct{}
+
+// startSpan Спан метода Storage с именем fn.
+func startSpan(ctx context.Context, fn string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, fn, trace.WithSpanKind(trace.SpanKindInternal))
+}
+
+// TraceQueryStart Начало спана запроса.
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	query := strings.Join(strings.Fields(data.SQL), " ")
+	operation, _, _ := strings.Cut(query, " ")
+
+	ctx, _ = tracing.Tracer().Start(ctx, "SQL "+strings.ToUpper(operation),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(strings.ToUpper(operation)),
+			semconv.DBQueryText(query),
+		),
+	)
+
+	return ctx
+}
+
+// TraceQueryEnd Завершение спана запроса с ошибкой, если она есть.
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	endSpan(trace.SpanFromContext(ctx), data.Err, attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+}
+
+// TraceBatchStart Начало спана пакета запросов.
+func (queryTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	size := 0
+	if data.Batch != nil {
+		size = data.Batch.Len()
+	}
+
+	ctx, _ = tracing.Tracer().Start(ctx, "SQL BATCH",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationBatchSize(size),
+		),
+	)
+
+	return ctx
+}
+
+// TraceBatchQuery Ошибка запроса из пакета записывается в спан пакета.
+func (queryTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	if data.Err != nil {
+		trace.SpanFromContext(ctx).RecordError(data.Err)
+	}
+}
+
+// TraceBatchEnd Завершение спана пакета запросов.
+func (queryTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	endSpan(trace.SpanFromContext(ctx), data.Err)
+}
+
+// endSpan Завершение спана с ошибкой err.
+func endSpan(span trace.Span, err error, attrs ...attribute.KeyValue) {
+	span.SetAttributes(attrs...)
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
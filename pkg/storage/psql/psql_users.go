@@ -0,0 +1,330 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Коды ошибок PostgreSQL при нарушении ограничений UNIQUE
+// и FOREIGN KEY.
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// CreateUser Создание пользователя. Возвращает storage.ErrUserExists,
+// если ID или email заняты.
+func (s *Storage) CreateUser(ctx context.Context, user *model.User) (*model.User, error) {
+	const fn = "psql.CreateUser"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.String("userID", user.ID.String()),
+	)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	created, err := scanUser(tx.QueryRow(
+		ctx,
+		storage.CreateUserSchema,
+		user.ID,
+		user.Email,
+		user.DisplayName,
+		user.Timezone,
+		user.Currency,
+	))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to create user", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrCommitTrans, err)
+	}
+
+	log.InfoContext(ctx, "User is created!")
+
+	return created, nil
+}
+
+// ReadUser Чтение пользователя по ID.
+func (s *Storage) ReadUser(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+	const fn = "psql.ReadUser"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.String("userID", userID.String()),
+	)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	user, err := scanUser(tx.QueryRow(ctx, storage.ReadUserSchema, userID))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrCommitTrans, err)
+	}
+
+	return user, nil
+}
+
+// UpdateUser Замена данных пользователя. ID пользователя не меняется.
+func (s *Storage) UpdateUser(ctx context.Context, userID uuid.UUID, user *model.User) (*model.User, error) {
+	const fn = "psql.UpdateUser"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.String("userID", userID.String()),
+	)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	updated, err := scanUser(tx.QueryRow(
+		ctx,
+		storage.UpdateUserSchema,
+		user.Email,
+		user.DisplayName,
+		user.Timezone,
+		user.Currency,
+		userID,
+	))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to update user", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrCommitTrans, err)
+	}
+
+	log.InfoContext(ctx, "User is updated!")
+
+	return updated, nil
+}
+
+// DeleteUser Удаление пользователя. Возвращает storage.ErrUserInUse,
+// если у пользователя есть подписки.
+func (s *Storage) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	const fn = "psql.DeleteUser"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("fn", fn),
+		slog.String("userID", userID.String()),
+	)
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	var inUse bool
+
+	if err := tx.QueryRow(ctx, storage.UserInUseSchema, userID).Scan(&inUse); err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return storageErr(storage.ErrExecSchema, err)
+	}
+
+	if inUse {
+		return storage.ErrUserInUse
+	}
+
+	var deletedID uuid.UUID
+
+	err = tx.QueryRow(ctx, storage.DeleteUserSchema, userID).Scan(&deletedID)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return userError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return storageErr(storage.ErrCommitTrans, err)
+	}
+
+	log.InfoContext(ctx, "User is deleted!")
+
+	return nil
+}
+
+// ListUsers Получение всех пользователей в порядке создания.
+func (s *Storage) ListUsers(ctx context.Context) ([]*model.User, error) {
+	const fn = "psql.ListUsers"
+	ctx, span := startSpan(ctx, fn)
+	defer span.End()
+
+	log := s.log.With(slog.String("fn", fn))
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to begin transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrBeginTrans, err)
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.ErrorContext(ctx, "failed to rollback transaction", slog.String("err", err.Error()))
+		}
+	}()
+
+	rows, err := tx.Query(ctx, storage.ListUsersSchema)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to exec schema", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrExecSchema, err)
+	}
+
+	users, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*model.User, error) {
+		return scanUser(row)
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to scan rows", slog.String("err", err.Error()))
+
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to commit transaction", slog.String("err", err.Error()))
+
+		return nil, storageErr(storage.ErrCommitTrans, err)
+	}
+
+	return users, nil
+}
+
+// checkUser Проверка, что пользователь существует, внутри транзакции
+// создания ключа API. Пользователь блокируется от удаления до конца
+// транзакции.
+func checkUser(ctx context.Context, tx pgx.Tx, userID uuid.UUID) error {
+	exists, err := txState{tx}.UserExists(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return errUserNotExist()
+	}
+
+	return nil
+}
+
+// errUserNotExist Ошибка подписки на несуществующего пользователя.
+func errUserNotExist() error {
+	return model.NewValidationError("user_id", model.RuleExists, "user does not exist")
+}
+
+// scanUser Сканирование строки пользователя. Если строки нет,
+// возвращает storage.ErrUserNotFound.
+func scanUser(row pgx.Row) (*model.User, error) {
+	var user model.User
+
+	err := row.Scan(
+		&user.ID,
+		&user.Email,
+		&user.DisplayName,
+		&user.Timezone,
+		&user.Currency,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, userError(err)
+	}
+
+	return &user, nil
+}
+
+// userError Преобразование ошибки запроса к пользователям: нет строки -
+// storage.ErrUserNotFound, занятые ID или email - storage.ErrUserExists,
+// ссылка из подписок - storage.ErrUserInUse.
+func userError(err error) error {
+	var pgErr *pgconn.PgError
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return storage.ErrUserNotFound
+	case errors.As(err, &pgErr) && pgErr.Code == uniqueViolation:
+		return fmt.Errorf("%w: %s", storage.ErrUserExists, pgErr.ConstraintName)
+	case errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation:
+		return storage.ErrUserInUse
+	}
+
+	return storageErr(storage.ErrExecSchema, err)
+}
@@ -0,0 +1,110 @@
+package storagetest
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/SHSanderland/EffMobTest/pkg/actor"
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+)
+
+func testEvents(t *testing.T, st storage.Storage) {
+	ctx := actor.WithActor(Context(NewTenant()), "auditor")
+	userID := NewUser(ctx, t, st)
+
+	sub := Create(ctx, t, st, NewSubscription(userID, "Netflix", "01-2025", ""))
+	other := Create(ctx, t, st, NewSubscription(userID, "Spotify", "01-2025", ""))
+
+	if _, err := st.UpdateSubscription(ctx, sub.ID, storage.AnyVersion, nil, setPrice(500)); err != nil {
+		t.Fatalf("UpdateSubscription unexpected error: %v", err)
+	}
+
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{name: "delete", run: func() error { return st.DeleteSubscription(ctx, sub.ID, storage.AnyVersion, false) }},
+		{name: "restore", run: func() error {
+			_, err := st.RestoreSubscription(ctx, sub.ID, storage.AnyVersion)
+
+			return err
+		}},
+		{name: "purge", run: func() error { return st.DeleteSubscription(ctx, sub.ID, storage.AnyVersion, true) }},
+	}
+
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s unexpected error: %v", step.name, err)
+		}
+	}
+
+	// Журнал подписки переживает ее удаление навсегда.
+	page, err := st.GetListEvents(ctx, &model.EventParams{SubscriptionID: &sub.ID, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetListEvents unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		action     string
+		wantBefore bool
+		wantAfter  bool
+	}{
+		{action: model.EventCreated, wantAfter: true},
+		{action: model.EventUpdated, wantBefore: true, wantAfter: true},
+		{action: model.EventDeleted, wantBefore: true, wantAfter: true},
+		{action: model.EventRestored, wantBefore: true, wantAfter: true},
+		{action: model.EventPurged, wantBefore: true},
+	}
+
+	if len(page.Events) != len(tests) {
+		t.Fatalf("events = %d, want %d", len(page.Events), len(tests))
+	}
+
+	for i, tt := range tests {
+		event := page.Events[i]
+
+		if event.Action != tt.action || event.Actor != "auditor" || event.SubscriptionID != sub.ID ||
+			(event.Before != nil) != tt.wantBefore || (event.After != nil) != tt.wantAfter {
+			t.Errorf("event %d = %+v, want %s by auditor", i, event, tt.action)
+		}
+	}
+
+	if before, after := page.Events[1].Before, page.Events[1].After; before.Price != 400 || after.Price != 500 {
+		t.Errorf("updated event prices = %d -> %d, want 400 -> 500", before.Price, after.Price)
+	}
+
+	// Страницы по одному событию с фильтром по исполнителю.
+	var subIDs []int64
+
+	filter := model.EventParams{Actor: "auditor", Limit: 1}
+
+	for {
+		page, err := st.GetListEvents(ctx, &filter)
+		if err != nil {
+			t.Fatalf("GetListEvents unexpected error: %v", err)
+		}
+
+		for _, event := range page.Events {
+			if event.ID <= filter.AfterID {
+				t.Fatalf("event %d is not after %d", event.ID, filter.AfterID)
+			}
+
+			filter.AfterID = event.ID
+			subIDs = append(subIDs, event.SubscriptionID)
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+	}
+
+	if want := []int64{sub.ID, other.ID, sub.ID, sub.ID, sub.ID, sub.ID}; !slices.Equal(subIDs, want) {
+		t.Errorf("event subscription IDs = %v, want %v", subIDs, want)
+	}
+
+	page, err = st.GetListEvents(ctx, &model.EventParams{Actor: "somebody", Limit: 10})
+	if err != nil || len(page.Events) != 0 {
+		t.Errorf("GetListEvents other actor = %d events, %v, want none", len(page.Events), err)
+	}
+}
@@ -0,0 +1,204 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/SHSanderland/EffMobTest/pkg/tenant"
+	"github.com/google/uuid"
+)
+
+// stored Подписка без полей, которые назначает хранилище, и без
+// пользователя для сравнения подписок из разных арендаторов.
+func stored(sub *model.Subscription) model.Subscription {
+	got := *sub
+	got.ID, got.ServiceID, got.UserID = 0, 0, uuid.Nil
+	got.CreatedAt, got.UpdatedAt = time.Time{}, time.Time{}
+
+	return got
+}
+
+func testBatchCreate(t *testing.T, st storage.Storage) {
+	// Одна и та же подписка создается одиночным запросом и пакетом
+	// в разных арендаторах, где сервиса еще нет.
+	create := map[string]func(ctx context.Context, sub *model.Subscription) (*model.Subscription, error){
+		"single": st.CreateSubscription,
+		"batch": func(ctx context.Context, sub *model.Subscription) (*model.Subscription, error) {
+			results, err := st.BatchSubscriptions(ctx, []*model.BatchOperation{
+				{Op: model.BatchCreate, Subscription: sub},
+			}, false)
+			if err != nil {
+				return nil, err
+			}
+
+			return results[0].Subscription, results[0].Err
+		},
+	}
+
+	got := make(map[string]model.Subscription, len(create))
+	prices := make(map[string]model.PriceHistory, len(create))
+
+	for name, run := range create {
+		ctx := Context(NewTenant())
+		userID := NewUser(ctx, t, st)
+
+		created, err := run(ctx, NewSubscription(userID, "  yandex   PLUS ", "2025-01-15", "07-2025"))
+		if err != nil {
+			t.Fatalf("%s create unexpected error: %v", name, err)
+		}
+
+		read, err := st.ReadSubscription(ctx, created.ID, false)
+		if err != nil {
+			t.Fatalf("ReadSubscription unexpected error: %v", err)
+		}
+
+		if stored(read) != stored(created) {
+			t.Errorf("%s created = %+v, read %+v", name, created, read)
+		}
+
+		got[name] = stored(read)
+
+		if prices[name], err = st.ListPrices(ctx, read.ID); err != nil {
+			t.Fatalf("ListPrices unexpected error: %v", err)
+		}
+	}
+
+	if got["single"] != got["batch"] {
+		t.Errorf("batch stored %+v, single stored %+v", got["batch"], got["single"])
+	}
+
+	if !slices.EqualFunc(prices["single"], prices["batch"], func(a, b model.PriceChange) bool {
+		return a.Price == b.Price && a.EffectiveFrom.Equal(b.EffectiveFrom)
+	}) {
+		t.Errorf("batch prices = %v, single prices = %v", prices["batch"], prices["single"])
+	}
+}
+
+func testBatch(t *testing.T, st storage.Storage) {
+	ctx := Context(NewTenant())
+	userID := NewUser(ctx, t, st)
+	existing := Create(ctx, t, st, NewSubscription(userID, "Netflix", "01-2024", "01-2025"))
+	extended := NewSubscription(userID, "Netflix", "01-2024", "")
+
+	ops := []*model.BatchOperation{
+		{Op: model.BatchCreate, Subscription: NewSubscription(userID, "Netflix", "01-2025", "")},
+		{Op: model.BatchCreate, Subscription: NewSubscription(userID, "netflix", "03-2025", "05-2025")},
+		{Op: model.BatchCreate, Subscription: NewSubscription(uuid.New(), "Spotify", "01-2025", "")},
+		{Op: model.BatchUpdate, ID: existing.ID, Version: 5, Subscription: extended},
+		{Op: model.BatchUpdate, ID: existing.ID, Version: 1, Subscription: extended},
+		{Op: model.BatchDelete, ID: existing.ID + 1000},
+		{Op: model.BatchDelete, ID: existing.ID, Version: 1},
+	}
+
+	results, err := st.BatchSubscriptions(ctx, ops, false)
+	if err != nil {
+		t.Fatalf("BatchSubscriptions unexpected error: %v", err)
+	}
+
+	created := results[0].Subscription
+	if created == nil {
+		t.Fatalf("create result = %v, want subscription", results[0].Err)
+	}
+
+	// Операции пакета видят результат предыдущих операций: update
+	// продлевает подписку до пересечения с созданной в пакете.
+	tests := []struct {
+		name         string
+		wantErr      error
+		wantConflict int64
+		wantField    string
+	}{
+		{name: "create"},
+		{name: "create overlapping batch create", wantConflict: created.ID},
+		{name: "create for unknown user", wantField: "user_id"},
+		{name: "update stale version", wantErr: storage.ErrVersion},
+		{name: "update overlapping batch create", wantConflict: created.ID},
+		{name: "delete missing", wantErr: storage.ErrSubNotFound},
+		{name: "delete"},
+	}
+
+	for i, tt := range tests {
+		err := results[i].Err
+
+		var overlap *storage.OverlapError
+
+		switch {
+		case tt.wantErr != nil:
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s error = %v, want %v", tt.name, err, tt.wantErr)
+			}
+		case tt.wantConflict != 0:
+			if !errors.As(err, &overlap) || overlap.ConflictID != tt.wantConflict {
+				t.Errorf("%s error = %v, want overlap with %d", tt.name, err, tt.wantConflict)
+			}
+		case tt.wantField != "":
+			if field := violationField(err); field != tt.wantField {
+				t.Errorf("%s error = %v, want violation of %s", tt.name, err, tt.wantField)
+			}
+		case err != nil:
+			t.Errorf("%s unexpected error: %v", tt.name, err)
+		}
+	}
+
+	if _, err := st.ReadSubscription(ctx, existing.ID, false); !errors.Is(err, storage.ErrSubNotFound) {
+		t.Errorf("ReadSubscription deleted error = %v, want %v", err, storage.ErrSubNotFound)
+	}
+}
+
+func testBatchAtomic(t *testing.T, st storage.Storage) {
+	tenantID := NewTenant()
+	ctx := Context(tenantID)
+	userID := NewUser(ctx, t, st)
+	existing := Create(ctx, t, st, NewSubscription(userID, "Spotify", "01-2020", "01-2021"))
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		sub       *model.Subscription
+		wantField string
+	}{
+		{
+			name:      "unknown user",
+			ctx:       ctx,
+			sub:       NewSubscription(uuid.New(), "Netflix", "01-2025", ""),
+			wantField: "user_id",
+		},
+		{
+			// Без WithServiceCreation пакет не создает сервисы,
+			// как и одиночный запрос.
+			name:      "new service",
+			ctx:       tenant.WithTenant(context.Background(), tenantID),
+			sub:       NewSubscription(userID, "Kinopoisk", "01-2025", ""),
+			wantField: "service_name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := st.BatchSubscriptions(tt.ctx, []*model.BatchOperation{
+				{Op: model.BatchCreate, Subscription: NewSubscription(userID, "Spotify", "01-2025", "")},
+				{Op: model.BatchCreate, Subscription: tt.sub},
+			}, true)
+			if err != nil {
+				t.Fatalf("BatchSubscriptions unexpected error: %v", err)
+			}
+
+			if !errors.Is(results[0].Err, storage.ErrBatchAbort) {
+				t.Errorf("first error = %v, want %v", results[0].Err, storage.ErrBatchAbort)
+			}
+
+			if field := violationField(results[1].Err); field != tt.wantField {
+				t.Errorf("second error = %v, want violation of %s", results[1].Err, tt.wantField)
+			}
+		})
+	}
+
+	if ids := listAll(ctx, t, st, model.ListParams{}); !equalIDs(ids, []int64{existing.ID}) {
+		t.Errorf("subscriptions after aborted batches = %v, want %v", ids, []int64{existing.ID})
+	}
+}
@@ -0,0 +1,114 @@
+package storagetest
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+)
+
+// newService Сервис каталога с синонимами в категории category.
+func newService(name, category string, aliases ...string) *model.Service {
+	svc := model.Service{Name: name, Aliases: aliases, Category: category}
+	svc.SetDefaults()
+
+	return &svc
+}
+
+func testCatalog(t *testing.T, st storage.Storage) {
+	ctx := Context(NewTenant())
+	userID := NewUser(ctx, t, st)
+
+	svc, err := st.CreateService(ctx, newService("Kinopoisk", "video", "KP", "kinopoisk.ru"))
+	if err != nil {
+		t.Fatalf("CreateService unexpected error: %v", err)
+	}
+
+	if _, err := st.CreateService(ctx, newService("Yandex Music", "music")); err != nil {
+		t.Fatalf("CreateService unexpected error: %v", err)
+	}
+
+	errTests := []struct {
+		name    string
+		svc     *model.Service
+		wantErr error
+	}{
+		{name: "name taken by alias", svc: newService(" kp ", ""), wantErr: storage.ErrServiceExists},
+		{name: "alias taken by name", svc: newService("Okko", "", "KINOPOISK"), wantErr: storage.ErrServiceExists},
+	}
+
+	for _, tt := range errTests {
+		if _, err := st.CreateService(ctx, tt.svc); !errors.Is(err, tt.wantErr) {
+			t.Errorf("CreateService %s error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	for _, name := range []string{"kinopoisk", "  Kinopoisk.RU ", "kp"} {
+		found, err := st.FindService(ctx, name)
+		if err != nil || found.ID != svc.ID {
+			t.Errorf("FindService(%q) = %+v, %v, want service %d", name, found, err, svc.ID)
+		}
+	}
+
+	if _, err := st.FindService(ctx, "okko"); !errors.Is(err, storage.ErrServiceNotFound) {
+		t.Errorf("FindService unknown error = %v, want %v", err, storage.ErrServiceNotFound)
+	}
+
+	// Подписка на синоним получает каноническое название сервиса.
+	sub := Create(ctx, t, st, NewSubscription(userID, "kinopoisk.ru", "01-2025", ""))
+	if sub.ServiceID != svc.ID || sub.ServiceName != "Kinopoisk" {
+		t.Errorf("created = %+v, want service %d Kinopoisk", sub, svc.ID)
+	}
+
+	services, err := st.ListServices(ctx, "video")
+	if err != nil || len(services) != 1 || services[0].ID != svc.ID {
+		t.Errorf("ListServices video = %v, %v, want service %d", services, err, svc.ID)
+	}
+
+	// Переименование меняет название в подписках сервиса.
+	renamed := newService("Kinopoisk HD", "video", "KP")
+	if _, err := st.UpdateService(ctx, svc.ID, renamed); err != nil {
+		t.Fatalf("UpdateService unexpected error: %v", err)
+	}
+
+	read, err := st.ReadSubscription(ctx, sub.ID, false)
+	if err != nil || read.ServiceName != "Kinopoisk HD" || read.Version != sub.Version+1 {
+		t.Errorf("ReadSubscription = %+v, %v, want Kinopoisk HD at version %d", read, err, sub.Version+1)
+	}
+
+	if _, err := st.FindService(ctx, "kinopoisk.ru"); !errors.Is(err, storage.ErrServiceNotFound) {
+		t.Errorf("FindService removed alias error = %v, want %v", err, storage.ErrServiceNotFound)
+	}
+
+	if err := st.DeleteService(ctx, svc.ID); !errors.Is(err, storage.ErrServiceInUse) {
+		t.Errorf("DeleteService in use error = %v, want %v", err, storage.ErrServiceInUse)
+	}
+
+	if err := st.DeleteSubscription(ctx, sub.ID, storage.AnyVersion, true); err != nil {
+		t.Fatalf("DeleteSubscription unexpected error: %v", err)
+	}
+
+	if err := st.DeleteService(ctx, svc.ID); err != nil {
+		t.Fatalf("DeleteService unexpected error: %v", err)
+	}
+
+	if _, err := st.ReadService(ctx, svc.ID); !errors.Is(err, storage.ErrServiceNotFound) {
+		t.Errorf("ReadService deleted error = %v, want %v", err, storage.ErrServiceNotFound)
+	}
+
+	services, err = st.ListServices(ctx, "")
+	if err != nil {
+		t.Fatalf("ListServices unexpected error: %v", err)
+	}
+
+	names := make([]string, 0, len(services))
+	for _, svc := range services {
+		names = append(names, svc.Name)
+	}
+
+	if !slices.Equal(names, []string{"Yandex Music"}) {
+		t.Errorf("services = %q, want Yandex Music", names)
+	}
+}
@@ -0,0 +1,85 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// newAPIKey Ключ роли user пользователя userID с уникальным хешем.
+func newAPIKey(userID uuid.UUID) *model.APIKey {
+	hash := uuid.NewString()
+
+	return &model.APIKey{Name: "test", Prefix: "emk_" + hash[:8], Role: model.RoleUser, UserID: &userID, Hash: hash}
+}
+
+func testAPIKeys(t *testing.T, st storage.Storage) {
+	tenantID := NewTenant()
+	ctx := Context(tenantID)
+	other := Context(NewTenant())
+	userID := NewUser(ctx, t, st)
+
+	key := newAPIKey(userID)
+
+	created, err := st.CreateAPIKey(ctx, key)
+	if err != nil {
+		t.Fatalf("CreateAPIKey unexpected error: %v", err)
+	}
+
+	if created.ID == 0 || created.TenantID != tenantID || created.Hash != "" || created.RevokedAt != nil {
+		t.Errorf("created = %+v, want ID in tenant %s without hash", created, tenantID)
+	}
+
+	if _, err := st.CreateAPIKey(other, newAPIKey(userID)); violationField(err) != "user_id" {
+		t.Errorf("CreateAPIKey for user of other tenant error = %v, want violation of user_id", err)
+	}
+
+	// Ключ ищется до того, как известен арендатор запроса.
+	found, err := st.FindAPIKey(context.Background(), key.Hash)
+	if err != nil || found.ID != created.ID || found.TenantID != tenantID || found.Hash != "" {
+		t.Errorf("FindAPIKey = %+v, %v, want key %d of tenant %s", found, err, created.ID, tenantID)
+	}
+
+	if keys, err := st.ListAPIKeys(other); err != nil || len(keys) != 0 {
+		t.Errorf("ListAPIKeys other tenant = %d keys, %v, want none", len(keys), err)
+	}
+
+	if err := st.RevokeAPIKey(other, created.ID); !errors.Is(err, storage.ErrAPIKeyNotFound) {
+		t.Errorf("RevokeAPIKey other tenant error = %v, want %v", err, storage.ErrAPIKeyNotFound)
+	}
+
+	if err := st.RevokeAPIKey(ctx, created.ID); err != nil {
+		t.Fatalf("RevokeAPIKey unexpected error: %v", err)
+	}
+
+	if err := st.RevokeAPIKey(ctx, created.ID); !errors.Is(err, storage.ErrAPIKeyNotFound) {
+		t.Errorf("RevokeAPIKey revoked error = %v, want %v", err, storage.ErrAPIKeyNotFound)
+	}
+
+	if _, err := st.FindAPIKey(context.Background(), key.Hash); !errors.Is(err, storage.ErrAPIKeyNotFound) {
+		t.Errorf("FindAPIKey revoked error = %v, want %v", err, storage.ErrAPIKeyNotFound)
+	}
+
+	// Отозванный ключ остается в списке, ключи удаленного пользователя удаляются.
+	removed := newAPIKey(NewUser(ctx, t, st))
+	if _, err := st.CreateAPIKey(ctx, removed); err != nil {
+		t.Fatalf("CreateAPIKey unexpected error: %v", err)
+	}
+
+	if err := st.DeleteUser(ctx, *removed.UserID); err != nil {
+		t.Fatalf("DeleteUser unexpected error: %v", err)
+	}
+
+	if _, err := st.FindAPIKey(context.Background(), removed.Hash); !errors.Is(err, storage.ErrAPIKeyNotFound) {
+		t.Errorf("FindAPIKey of deleted user error = %v, want %v", err, storage.ErrAPIKeyNotFound)
+	}
+
+	keys, err := st.ListAPIKeys(ctx)
+	if err != nil || len(keys) != 1 || keys[0].ID != created.ID || keys[0].RevokedAt == nil || keys[0].Hash != "" {
+		t.Errorf("ListAPIKeys = %v, %v, want revoked key %d", keys, err, created.ID)
+	}
+}
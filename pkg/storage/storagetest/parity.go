@@ -0,0 +1,156 @@
+package storagetest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// transcript Запись результатов сценария. ID подписок заменяются
+// номерами по порядку создания, так как последовательности ID
+// у драйверов различаются.
+type transcript struct {
+	lines []string
+	ids   []int64
+}
+
+// ref Номер подписки subID в сценарии, 0 - неизвестная подписка.
+func (tr *transcript) ref(subID int64) int {
+	return slices.Index(tr.ids, subID) + 1
+}
+
+// sub Запись подписки или ошибки шага step.
+func (tr *transcript) sub(step string, sub *model.Subscription, err error) {
+	if err != nil {
+		tr.err(step, err)
+
+		return
+	}
+
+	if !slices.Contains(tr.ids, sub.ID) {
+		tr.ids = append(tr.ids, sub.ID)
+	}
+
+	tr.add(step, "#%d %s %d %s %s/%d %s..%s v%d deleted=%t",
+		tr.ref(sub.ID), sub.ServiceName, sub.Price, sub.Currency, sub.BillingPeriod.Unit, sub.BillingPeriod.Count,
+		sub.StartDate, sub.EndDate, sub.Version, sub.DeletedAt != nil,
+	)
+}
+
+// err Запись ошибки шага step без подробностей, которые у драйверов
+// различаются.
+func (tr *transcript) err(step string, err error) {
+	var (
+		overlap *storage.OverlapError
+		verr    *model.ValidationError
+	)
+
+	switch {
+	case err == nil:
+		tr.add(step, "ok")
+	case errors.As(err, &overlap):
+		tr.add(step, "overlap #%d", tr.ref(overlap.ConflictID))
+	case errors.As(err, &verr):
+		tr.add(step, "validation %s", violationField(err))
+	case errors.Is(err, storage.ErrVersion):
+		tr.add(step, "%v", storage.ErrVersion)
+	default:
+		tr.add(step, "%v", err)
+	}
+}
+
+// add Запись строки шага step.
+func (tr *transcript) add(step, format string, args ...any) {
+	tr.lines = append(tr.lines, step+": "+fmt.Sprintf(format, args...))
+}
+
+// Transcript Выполнение одного сценария над хранилищем st и запись
+// результатов каждого шага без ID и времени. Одинаковые записи
+// двух драйверов означают одинаковое поведение в сценарии.
+func Transcript(t *testing.T, st storage.Storage) []string {
+	t.Helper()
+
+	ctx := Context(NewTenant())
+	userID := NewUser(ctx, t, st)
+	tr := transcript{}
+
+	netflix, err := st.CreateSubscription(ctx, NewSubscription(userID, "Netflix", "01-2025", ""))
+	tr.sub("create", netflix, err)
+
+	if err != nil {
+		t.Fatalf("CreateSubscription unexpected error: %v", err)
+	}
+
+	old, err := st.CreateSubscription(ctx, NewSubscription(userID, "  NETFLIX ", "01-2024", "2024-06-15"))
+	tr.sub("create by other spelling", old, err)
+
+	if err != nil {
+		t.Fatalf("CreateSubscription unexpected error: %v", err)
+	}
+
+	_, err = st.CreateSubscription(ctx, NewSubscription(userID, "netflix", "03-2025", "05-2025"))
+	tr.err("create overlapping", err)
+
+	_, err = st.CreateSubscription(ctx, NewSubscription(uuid.New(), "Netflix", "01-2020", ""))
+	tr.err("create for unknown user", err)
+
+	priceFrom := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	updated, err := st.UpdateSubscription(ctx, netflix.ID, 1, &priceFrom, setPrice(500))
+	tr.sub("update price", updated, err)
+
+	_, err = st.UpdateSubscription(ctx, netflix.ID, 1, nil, setPrice(600))
+	tr.err("update stale version", err)
+
+	tr.err("delete", st.DeleteSubscription(ctx, old.ID, storage.AnyVersion, false))
+
+	_, err = st.ReadSubscription(ctx, old.ID, false)
+	tr.err("read deleted", err)
+
+	restored, err := st.RestoreSubscription(ctx, old.ID, storage.AnyVersion)
+	tr.sub("restore", restored, err)
+
+	page, err := st.GetListSubscription(ctx, &model.ListParams{Sort: "start_date", Limit: 10})
+	if err != nil {
+		t.Fatalf("GetListSubscription unexpected error: %v", err)
+	}
+
+	for _, sub := range page.Subscriptions {
+		tr.sub("list", sub, nil)
+	}
+
+	prices, err := st.ListPrices(ctx, netflix.ID)
+	if err != nil {
+		t.Fatalf("ListPrices unexpected error: %v", err)
+	}
+
+	for _, change := range prices {
+		tr.add("prices", "%s %d", change.EffectiveFrom.Format(time.DateOnly), change.Price)
+	}
+
+	from := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, time.June, 30, 0, 0, 0, 0, time.UTC)
+
+	report, err := st.CostSubscription(ctx, &model.CostParams{StartDate: &from, EndDate: &to})
+	if err != nil {
+		t.Fatalf("CostSubscription unexpected error: %v", err)
+	}
+
+	for _, cost := range report.Subscriptions {
+		tr.add("cost", "#%d %d cycles %d", tr.ref(cost.SubscriptionID), cost.Cycles, cost.Cost)
+	}
+
+	tr.add("cost", "total %v", report.Total)
+
+	tr.err("purge", st.DeleteSubscription(ctx, old.ID, storage.AnyVersion, true))
+
+	_, err = st.ReadSubscription(ctx, old.ID, true)
+	tr.err("read purged", err)
+
+	return tr.lines
+}
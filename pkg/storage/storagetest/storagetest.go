@@ -0,0 +1,551 @@
+// Пакет storagetest содержит общие тесты контракта storage.Storage.
+// Тесты запускаются для каждого драйвера хранилища, поэтому драйверы
+// обязаны вести себя одинаково.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/SHSanderland/EffMobTest/pkg/tenant"
+	"github.com/google/uuid"
+)
+
+// OpenFunc Функция, которая возвращает хранилище для теста. Хранилище
+// может быть общим для нескольких тестов: каждый тест работает
+// в своем арендаторе.
+type OpenFunc func(t *testing.T) storage.Storage
+
+// Run Запуск тестов контракта для хранилища из open.
+func Run(t *testing.T, open OpenFunc) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, st storage.Storage)
+	}{
+		{name: "create and read", run: testCreateRead},
+		{name: "create validation", run: testCreateValidation},
+		{name: "update", run: testUpdate},
+		{name: "delete and restore", run: testDelete},
+		{name: "list", run: testList},
+		{name: "export", run: testExport},
+		{name: "cost", run: testCost},
+		{name: "prices", run: testPrices},
+		{name: "count active", run: testCountActive},
+		{name: "catalog", run: testCatalog},
+		{name: "users", run: testUsers},
+		{name: "API keys", run: testAPIKeys},
+		{name: "events", run: testEvents},
+		{name: "batch", run: testBatch},
+		{name: "batch atomic", run: testBatchAtomic},
+		{name: "batch create as single", run: testBatchCreate},
+		{name: "tenant isolation", run: testTenantIsolation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, open(t))
+		})
+	}
+}
+
+// NewTenant ID нового арендатора, данные которого видны только
+// одному тесту.
+func NewTenant() string {
+	return "test-" + uuid.NewString()
+}
+
+// Context Контекст арендатора tenantID, в котором можно создавать
+// сервисы каталога.
+func Context(tenantID string) context.Context {
+	return storage.WithServiceCreation(tenant.WithTenant(context.Background(), tenantID))
+}
+
+// NewUser Создание пользователя для подписок теста.
+func NewUser(ctx context.Context, t *testing.T, st storage.Storage) uuid.UUID {
+	t.Helper()
+
+	id := uuid.New()
+	user := model.User{ID: id, Email: id.String() + "@example.com"}
+	user.SetDefaults()
+
+	if _, err := st.CreateUser(ctx, &user); err != nil {
+		t.Fatalf("CreateUser unexpected error: %v", err)
+	}
+
+	return id
+}
+
+// NewSubscription Подписка пользователя userID на service за 400
+// в валюте и с периодом по умолчанию. Пустой end означает бессрочную
+// подписку.
+func NewSubscription(userID uuid.UUID, service, start, end string) *model.Subscription {
+	sub := model.Subscription{
+		ServiceName: service,
+		Price:       400,
+		UserID:      userID,
+		StartDate:   start,
+		EndDate:     end,
+	}
+	sub.SetDefaults()
+
+	return &sub
+}
+
+// Create Создание подписки, ошибка завершает тест.
+func Create(ctx context.Context, t *testing.T, st storage.Storage, sub *model.Subscription) *model.Subscription {
+	t.Helper()
+
+	created, err := st.CreateSubscription(ctx, sub)
+	if err != nil {
+		t.Fatalf("CreateSubscription unexpected error: %v", err)
+	}
+
+	return created
+}
+
+// violationField Поле первого нарушения ошибки проверки или пустая
+// строка, если err не *model.ValidationError.
+func violationField(err error) string {
+	var verr *model.ValidationError
+	if !errors.As(err, &verr) || len(verr.Violations) == 0 {
+		return ""
+	}
+
+	return verr.Violations[0].Field
+}
+
+// setPrice Функция обновления, которая меняет цену подписки.
+func setPrice(price model.Amount) storage.UpdateFunc {
+	return func(sub *model.Subscription) error {
+		sub.Price = price
+
+		return nil
+	}
+}
+
+func testCreateRead(t *testing.T, st storage.Storage) {
+	ctx := Context(NewTenant())
+	userID := NewUser(ctx, t, st)
+
+	created := Create(ctx, t, st, NewSubscription(userID, "Netflix", "07-2025", "01-2026"))
+	if created.ID == 0 || created.ServiceID == 0 || created.Version != 1 {
+		t.Fatalf("created = %+v, want ID, service ID and version 1", created)
+	}
+
+	read, err := st.ReadSubscription(ctx, created.ID, false)
+	if err != nil {
+		t.Fatalf("ReadSubscription unexpected error: %v", err)
+	}
+
+	if read.ServiceName != "Netflix" || read.Price != 400 || read.UserID != userID ||
+		read.StartDate != created.StartDate || read.EndDate != created.EndDate || read.Version != 1 {
+		t.Errorf("read = %+v, want %+v", read, created)
+	}
+
+	if _, err := st.ReadSubscription(ctx, created.ID+1000, false); !errors.Is(err, storage.ErrSubNotFound) {
+		t.Errorf("ReadSubscription missing error = %v, want %v", err, storage.ErrSubNotFound)
+	}
+
+	tests := []struct {
+		id   int64
+		want bool
+	}{
+		{id: created.ID, want: true},
+		{id: created.ID + 1000, want: false},
+	}
+
+	for _, tt := range tests {
+		if ok, err := st.CheckSubscriptionID(ctx, tt.id, false); err != nil || ok != tt.want {
+			t.Errorf("CheckSubscriptionID(%d) = %v, %v, want %v", tt.id, ok, err, tt.want)
+		}
+	}
+}
+
+func testCreateValidation(t *testing.T, st storage.Storage) {
+	ctx := Context(NewTenant())
+	userID := NewUser(ctx, t, st)
+	existing := Create(ctx, t, st, NewSubscription(userID, "Netflix", "01-2025", "07-2025"))
+
+	tests := []struct {
+		name         string
+		sub          *model.Subscription
+		wantConflict int64
+		wantField    string
+	}{
+		{
+			name:      "unknown user",
+			sub:       NewSubscription(uuid.New(), "Netflix", "01-2026", ""),
+			wantField: "user_id",
+		},
+		{
+			name:         "overlapping period",
+			sub:          NewSubscription(userID, "Netflix", "03-2025", "09-2025"),
+			wantConflict: existing.ID,
+		},
+		{
+			name:         "open end over existing",
+			sub:          NewSubscription(userID, "Netflix", "12-2024", ""),
+			wantConflict: existing.ID,
+		},
+		{name: "adjacent period", sub: NewSubscription(userID, "Netflix", "07-2025", "09-2025")},
+		{name: "other service", sub: NewSubscription(userID, "Spotify", "03-2025", "09-2025")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := st.CreateSubscription(ctx, tt.sub)
+
+			var overlap *storage.OverlapError
+
+			switch {
+			case tt.wantConflict != 0:
+				if !errors.As(err, &overlap) || overlap.ConflictID != tt.wantConflict {
+					t.Errorf("error = %v, want overlap with %d", err, tt.wantConflict)
+				}
+			case tt.wantField != "":
+				if field := violationField(err); field != tt.wantField {
+					t.Errorf("error = %v, want violation of %s", err, tt.wantField)
+				}
+			case err != nil:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func testUpdate(t *testing.T, st storage.Storage) {
+	ctx := Context(NewTenant())
+	userID := NewUser(ctx, t, st)
+	sub := Create(ctx, t, st, NewSubscription(userID, "Netflix", "01-2025", ""))
+	Create(ctx, t, st, NewSubscription(userID, "Netflix", "01-2020", "01-2021"))
+
+	errUpdate := errors.New("update failed")
+
+	// Обновления выполняются по порядку: успешное обновление
+	// увеличивает версию подписки.
+	tests := []struct {
+		name        string
+		version     int64
+		update      storage.UpdateFunc
+		wantErr     error
+		wantVersion int64
+	}{
+		{name: "stale version", version: 5, update: setPrice(500), wantErr: storage.ErrVersion},
+		{
+			name:    "update error",
+			version: 1,
+			update:  func(*model.Subscription) error { return errUpdate },
+			wantErr: errUpdate,
+		},
+		{name: "current version", version: 1, update: setPrice(500), wantVersion: 2},
+		{name: "any version", version: storage.AnyVersion, update: setPrice(600), wantVersion: 3},
+		{
+			name:    "overlapping period",
+			version: 3,
+			update: func(sub *model.Subscription) error {
+				sub.StartDate = "06-2020"
+
+				return nil
+			},
+			wantErr: storage.ErrSubOverlap,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := st.UpdateSubscription(ctx, sub.ID, tt.version, nil, tt.update)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && updated.Version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", updated.Version, tt.wantVersion)
+			}
+		})
+	}
+
+	if _, err := st.UpdateSubscription(ctx, sub.ID+1000, storage.AnyVersion, nil, setPrice(1)); !errors.Is(
+		err, storage.ErrSubNotFound,
+	) {
+		t.Errorf("UpdateSubscription missing error = %v, want %v", err, storage.ErrSubNotFound)
+	}
+}
+
+func testDelete(t *testing.T, st storage.Storage) {
+	ctx := Context(NewTenant())
+	userID := NewUser(ctx, t, st)
+	sub := Create(ctx, t, st, NewSubscription(userID, "Netflix", "01-2025", ""))
+
+	if err := st.DeleteSubscription(ctx, sub.ID, 5, false); !errors.Is(err, storage.ErrVersion) {
+		t.Errorf("DeleteSubscription stale error = %v, want %v", err, storage.ErrVersion)
+	}
+
+	if _, err := st.RestoreSubscription(ctx, sub.ID, storage.AnyVersion); !errors.Is(err, storage.ErrNotDeleted) {
+		t.Errorf("RestoreSubscription active error = %v, want %v", err, storage.ErrNotDeleted)
+	}
+
+	if err := st.DeleteSubscription(ctx, sub.ID, 1, false); err != nil {
+		t.Fatalf("DeleteSubscription unexpected error: %v", err)
+	}
+
+	if _, err := st.ReadSubscription(ctx, sub.ID, false); !errors.Is(err, storage.ErrSubNotFound) {
+		t.Errorf("ReadSubscription deleted error = %v, want %v", err, storage.ErrSubNotFound)
+	}
+
+	deleted, err := st.ReadSubscription(ctx, sub.ID, true)
+	if err != nil || deleted.DeletedAt == nil || deleted.Version != 2 {
+		t.Fatalf("ReadSubscription with deleted = %+v, %v, want deleted version 2", deleted, err)
+	}
+
+	if _, err := st.UpdateSubscription(ctx, sub.ID, storage.AnyVersion, nil, setPrice(1)); !errors.Is(
+		err, storage.ErrSubNotFound,
+	) {
+		t.Errorf("UpdateSubscription deleted error = %v, want %v", err, storage.ErrSubNotFound)
+	}
+
+	// Удаленная подписка не мешает новой, но не восстанавливается поверх нее.
+	replacement := Create(ctx, t, st, NewSubscription(userID, "Netflix", "01-2025", ""))
+
+	if _, err := st.RestoreSubscription(ctx, sub.ID, 2); !errors.Is(err, storage.ErrSubOverlap) {
+		t.Errorf("RestoreSubscription overlapping error = %v, want %v", err, storage.ErrSubOverlap)
+	}
+
+	if err := st.DeleteSubscription(ctx, replacement.ID, storage.AnyVersion, true); err != nil {
+		t.Fatalf("DeleteSubscription hard unexpected error: %v", err)
+	}
+
+	if ok, err := st.CheckSubscriptionID(ctx, replacement.ID, true); err != nil || ok {
+		t.Errorf("CheckSubscriptionID purged = %v, %v, want false", ok, err)
+	}
+
+	restored, err := st.RestoreSubscription(ctx, sub.ID, 2)
+	if err != nil || restored.DeletedAt != nil || restored.Version != 3 {
+		t.Errorf("RestoreSubscription = %+v, %v, want active version 3", restored, err)
+	}
+}
+
+func testList(t *testing.T, st storage.Storage) {
+	ctx := Context(NewTenant())
+	userID := NewUser(ctx, t, st)
+	otherID := NewUser(ctx, t, st)
+
+	netflix := Create(ctx, t, st, NewSubscription(userID, "Netflix", "01-2025", ""))
+	spotify := Create(ctx, t, st, NewSubscription(userID, "Spotify", "02-2025", ""))
+	other := Create(ctx, t, st, NewSubscription(otherID, "Netflix", "03-2025", ""))
+	deleted := Create(ctx, t, st, NewSubscription(otherID, "Spotify", "04-2025", ""))
+
+	if err := st.DeleteSubscription(ctx, deleted.ID, storage.AnyVersion, false); err != nil {
+		t.Fatalf("DeleteSubscription unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter model.ListParams
+		want   []int64
+	}{
+		{name: "all", filter: model.ListParams{}, want: []int64{netflix.ID, spotify.ID, other.ID}},
+		{name: "user", filter: model.ListParams{UserID: &otherID}, want: []int64{other.ID}},
+		{name: "service prefix", filter: model.ListParams{ServiceName: "net"}, want: []int64{netflix.ID, other.ID}},
+		{
+			name:   "start date descending",
+			filter: model.ListParams{Sort: "start_date", Desc: true},
+			want:   []int64{other.ID, spotify.ID, netflix.ID},
+		},
+		{
+			name:   "with deleted",
+			filter: model.ListParams{Deleted: model.DeletedInclude},
+			want:   []int64{netflix.ID, spotify.ID, other.ID, deleted.ID},
+		},
+		{name: "only deleted", filter: model.ListParams{Deleted: model.DeletedOnly}, want: []int64{deleted.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := listAll(ctx, t, st, tt.filter); !equalIDs(got, tt.want) {
+				t.Errorf("IDs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// listAll ID всех подписок списка, прочитанных страницами по одной
+// подписке через курсор.
+func listAll(ctx context.Context, t *testing.T, st storage.Storage, filter model.ListParams) []int64 {
+	t.Helper()
+
+	var ids []int64
+
+	filter.Limit = 1
+
+	for {
+		page, err := st.GetListSubscription(ctx, &filter)
+		if err != nil {
+			t.Fatalf("GetListSubscription unexpected error: %v", err)
+		}
+
+		for _, sub := range page.Subscriptions {
+			ids = append(ids, sub.ID)
+		}
+
+		if page.NextCursor == "" {
+			return ids
+		}
+
+		if filter.Cursor, err = model.DecodeListCursor(page.NextCursor, &filter); err != nil {
+			t.Fatalf("invalid next cursor %q: %v", page.NextCursor, err)
+		}
+	}
+}
+
+// equalIDs Сравнение списков ID с учетом порядка.
+func equalIDs(got, want []int64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func testExport(t *testing.T, st storage.Storage) {
+	ctx := Context(NewTenant())
+	userID := NewUser(ctx, t, st)
+
+	want := []int64{
+		Create(ctx, t, st, NewSubscription(userID, "Netflix", "01-2025", "")).ID,
+		Create(ctx, t, st, NewSubscription(userID, "Spotify", "01-2025", "")).ID,
+	}
+
+	var got []int64
+
+	err := st.ExportSubscriptions(ctx, &model.ListParams{Limit: 1}, func(sub *model.Subscription) error {
+		got = append(got, sub.ID)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportSubscriptions unexpected error: %v", err)
+	}
+
+	if !equalIDs(got, want) {
+		t.Errorf("exported IDs = %v, want %v", got, want)
+	}
+
+	errStop := errors.New("stop")
+
+	err = st.ExportSubscriptions(ctx, &model.ListParams{}, func(*model.Subscription) error { return errStop })
+	if !errors.Is(err, errStop) {
+		t.Errorf("ExportSubscriptions error = %v, want %v", err, errStop)
+	}
+}
+
+func testCost(t *testing.T, st storage.Storage) {
+	ctx := Context(NewTenant())
+	userID := NewUser(ctx, t, st)
+	otherID := NewUser(ctx, t, st)
+
+	Create(ctx, t, st, NewSubscription(userID, "Netflix", "01-2025", "04-2025"))
+	Create(ctx, t, st, NewSubscription(otherID, "Spotify", "02-2025", ""))
+
+	deleted := Create(ctx, t, st, NewSubscription(otherID, "Netflix", "01-2025", ""))
+	if err := st.DeleteSubscription(ctx, deleted.ID, storage.AnyVersion, false); err != nil {
+		t.Fatalf("DeleteSubscription unexpected error: %v", err)
+	}
+
+	from := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, time.June, 30, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		filter model.CostParams
+		want   model.Amount
+	}{
+		{name: "all", filter: model.CostParams{}, want: 3*400 + 5*400},
+		{name: "user", filter: model.CostParams{UserID: &userID}, want: 3 * 400},
+		{name: "service alias case", filter: model.CostParams{ServiceNames: []string{"spotify"}}, want: 5 * 400},
+		{name: "with deleted", filter: model.CostParams{IncludeDeleted: true}, want: 3*400 + 5*400 + 6*400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			filter.StartDate, filter.EndDate = &from, &to
+
+			report, err := st.CostSubscription(ctx, &filter)
+			if err != nil {
+				t.Fatalf("CostSubscription unexpected error: %v", err)
+			}
+
+			if got := report.Total[model.DefaultCurrency]; got != tt.want {
+				t.Errorf("total = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func testPrices(t *testing.T, st storage.Storage) {
+	ctx := Context(NewTenant())
+	userID := NewUser(ctx, t, st)
+	sub := Create(ctx, t, st, NewSubscription(userID, "Netflix", "01-2025", ""))
+
+	priceFrom := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := st.UpdateSubscription(ctx, sub.ID, storage.AnyVersion, &priceFrom, setPrice(500)); err != nil {
+		t.Fatalf("UpdateSubscription unexpected error: %v", err)
+	}
+
+	prices, err := st.ListPrices(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("ListPrices unexpected error: %v", err)
+	}
+
+	if len(prices) != 2 || prices[0].Price != 400 || !prices[1].EffectiveFrom.Equal(priceFrom) ||
+		prices[1].Price != 500 {
+		t.Fatalf("prices = %+v, want 400 from start and 500 from %s", prices, priceFrom.Format(time.DateOnly))
+	}
+
+	from := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, time.April, 30, 0, 0, 0, 0, time.UTC)
+
+	report, err := st.CostSubscription(ctx, &model.CostParams{StartDate: &from, EndDate: &to})
+	if err != nil {
+		t.Fatalf("CostSubscription unexpected error: %v", err)
+	}
+
+	if got, want := report.Total[model.DefaultCurrency], model.Amount(2*400+2*500); got != want {
+		t.Errorf("total = %d, want %d", got, want)
+	}
+}
+
+func testCountActive(t *testing.T, st storage.Storage) {
+	tenantID := NewTenant()
+	ctx := Context(tenantID)
+	userID := NewUser(ctx, t, st)
+
+	Create(ctx, t, st, NewSubscription(userID, "Netflix", "01-2020", ""))
+	Create(ctx, t, st, NewSubscription(userID, "Spotify", "01-2020", "01-2021"))
+	Create(ctx, t, st, NewSubscription(userID, "Kinopoisk", "01-2999", ""))
+
+	deleted := Create(ctx, t, st, NewSubscription(userID, "Okko", "01-2020", ""))
+	if err := st.DeleteSubscription(ctx, deleted.ID, storage.AnyVersion, false); err != nil {
+		t.Fatalf("DeleteSubscription unexpected error: %v", err)
+	}
+
+	counts, err := st.CountActiveSubscriptions(ctx)
+	if err != nil {
+		t.Fatalf("CountActiveSubscriptions unexpected error: %v", err)
+	}
+
+	if counts[tenantID] != 1 {
+		t.Errorf("active subscriptions = %d, want 1", counts[tenantID])
+	}
+}
@@ -0,0 +1,138 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/SHSanderland/EffMobTest/pkg/tenant"
+)
+
+func testTenantIsolation(t *testing.T, st storage.Storage) {
+	owner := Context(NewTenant())
+	userID := NewUser(owner, t, st)
+	sub := Create(owner, t, st, NewSubscription(userID, "Netflix", "01-2025", ""))
+
+	other := Context(NewTenant())
+	// Без арендатора хранилище не подставляет арендатора по умолчанию.
+	none := storage.WithServiceCreation(context.Background())
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		run     func(ctx context.Context) error
+		wantErr error
+	}{
+		{
+			name: "read", ctx: other, wantErr: storage.ErrSubNotFound,
+			run: func(ctx context.Context) error {
+				_, err := st.ReadSubscription(ctx, sub.ID, true)
+
+				return err
+			},
+		},
+		{
+			name: "update", ctx: other, wantErr: storage.ErrSubNotFound,
+			run: func(ctx context.Context) error {
+				_, err := st.UpdateSubscription(ctx, sub.ID, storage.AnyVersion, nil, setPrice(500))
+
+				return err
+			},
+		},
+		{
+			name: "delete", ctx: other, wantErr: storage.ErrSubNotFound,
+			run: func(ctx context.Context) error {
+				return st.DeleteSubscription(ctx, sub.ID, storage.AnyVersion, true)
+			},
+		},
+		{
+			name: "read user", ctx: other, wantErr: storage.ErrUserNotFound,
+			run: func(ctx context.Context) error {
+				_, err := st.ReadUser(ctx, userID)
+
+				return err
+			},
+		},
+		{
+			name: "create for user", ctx: other,
+			run: func(ctx context.Context) error {
+				_, err := st.CreateSubscription(ctx, NewSubscription(userID, "Netflix", "01-2025", ""))
+				if field := violationField(err); field != "user_id" {
+					return fmt.Errorf("%v, want violation of user_id", err)
+				}
+
+				return nil
+			},
+		},
+		{
+			name: "list", ctx: other,
+			run: func(ctx context.Context) error {
+				if ids := listAll(ctx, t, st, model.ListParams{}); len(ids) != 0 {
+					return errors.New("subscriptions of another tenant are listed")
+				}
+
+				return nil
+			},
+		},
+		{
+			name: "list services", ctx: other,
+			run: func(ctx context.Context) error {
+				services, err := st.ListServices(ctx, "")
+				if err == nil && len(services) != 0 {
+					return errors.New("services of another tenant are listed")
+				}
+
+				return err
+			},
+		},
+		{
+			name: "create without tenant", ctx: none, wantErr: tenant.ErrNoTenant,
+			run: func(ctx context.Context) error {
+				_, err := st.CreateSubscription(ctx, NewSubscription(userID, "Spotify", "01-2025", ""))
+
+				return err
+			},
+		},
+		{
+			name: "read without tenant", ctx: none, wantErr: tenant.ErrNoTenant,
+			run: func(ctx context.Context) error {
+				_, err := st.ReadSubscription(ctx, sub.ID, true)
+
+				return err
+			},
+		},
+		{
+			name: "list without tenant", ctx: none, wantErr: tenant.ErrNoTenant,
+			run: func(ctx context.Context) error {
+				_, err := st.GetListSubscription(ctx, &model.ListParams{Limit: 10})
+
+				return err
+			},
+		},
+		{
+			name: "list keys without tenant", ctx: none, wantErr: tenant.ErrNoTenant,
+			run: func(ctx context.Context) error {
+				_, err := st.ListAPIKeys(ctx)
+
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(tt.ctx); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Подписка арендатора не изменилась.
+	read, err := st.ReadSubscription(owner, sub.ID, false)
+	if err != nil || read.Version != sub.Version || read.Price != sub.Price {
+		t.Errorf("ReadSubscription = %+v, %v, want %+v", read, err, sub)
+	}
+}
@@ -0,0 +1,112 @@
+package storagetest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// newUser Новый пользователь с email.
+func newUser(email string) *model.User {
+	user := model.User{ID: uuid.New(), Email: email}
+	user.SetDefaults()
+
+	return &user
+}
+
+func testUsers(t *testing.T, st storage.Storage) {
+	ctx := Context(NewTenant())
+	email := uuid.NewString() + "@example.com"
+
+	user, err := st.CreateUser(ctx, newUser(email))
+	if err != nil {
+		t.Fatalf("CreateUser unexpected error: %v", err)
+	}
+
+	other := Context(NewTenant())
+	taken := *user
+
+	errTests := []struct {
+		name    string
+		run     func() error
+		wantErr error
+	}{
+		{
+			name:    "create with taken ID",
+			run:     func() error { _, err := st.CreateUser(ctx, &taken); return err },
+			wantErr: storage.ErrUserExists,
+		},
+		{
+			// ID пользователей уникальны среди всех арендаторов.
+			name:    "create with ID taken in other tenant",
+			run:     func() error { _, err := st.CreateUser(other, &taken); return err },
+			wantErr: storage.ErrUserExists,
+		},
+		{
+			name:    "create with taken email",
+			run:     func() error { _, err := st.CreateUser(ctx, newUser(email)); return err },
+			wantErr: storage.ErrUserExists,
+		},
+		{
+			name:    "create with taken email in other case",
+			run:     func() error { _, err := st.CreateUser(ctx, newUser(strings.ToUpper(email))); return err },
+			wantErr: storage.ErrUserExists,
+		},
+		{
+			// Email уникален только в арендаторе.
+			name: "create with email taken in other tenant",
+			run:  func() error { _, err := st.CreateUser(other, newUser(email)); return err },
+		},
+		{
+			name:    "update missing",
+			run:     func() error { _, err := st.UpdateUser(ctx, uuid.New(), newUser("")); return err },
+			wantErr: storage.ErrUserNotFound,
+		},
+		{
+			name:    "delete missing",
+			run:     func() error { return st.DeleteUser(ctx, uuid.New()) },
+			wantErr: storage.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range errTests {
+		if err := tt.run(); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	update := *user
+	update.DisplayName = "Иван Петров"
+
+	updated, err := st.UpdateUser(ctx, user.ID, &update)
+	if err != nil || updated.DisplayName != update.DisplayName || !updated.CreatedAt.Equal(user.CreatedAt) {
+		t.Errorf("UpdateUser = %+v, %v, want display name and created_at %v", updated, err, user.CreatedAt)
+	}
+
+	sub := Create(ctx, t, st, NewSubscription(user.ID, "Netflix", "01-2025", ""))
+
+	if err := st.DeleteUser(ctx, user.ID); !errors.Is(err, storage.ErrUserInUse) {
+		t.Errorf("DeleteUser with subscriptions error = %v, want %v", err, storage.ErrUserInUse)
+	}
+
+	if err := st.DeleteSubscription(ctx, sub.ID, storage.AnyVersion, true); err != nil {
+		t.Fatalf("DeleteSubscription unexpected error: %v", err)
+	}
+
+	if err := st.DeleteUser(ctx, user.ID); err != nil {
+		t.Fatalf("DeleteUser unexpected error: %v", err)
+	}
+
+	if _, err := st.ReadUser(ctx, user.ID); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Errorf("ReadUser deleted error = %v, want %v", err, storage.ErrUserNotFound)
+	}
+
+	// Email удаленного пользователя освобождается.
+	if _, err := st.CreateUser(ctx, newUser(email)); err != nil {
+		t.Errorf("CreateUser with freed email unexpected error: %v", err)
+	}
+}
@@ -5,27 +5,156 @@ package storage
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/big"
+	"time"
 
+	"github.com/SHSanderland/EffMobTest/pkg/actor"
 	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
 )
 
 var (
-	ErrBeginTrans  = errors.New("failed to begin transaction")
-	ErrCommitTrans = errors.New("failed to commit transaction")
-	ErrExecSchema  = errors.New("failed to exec schema")
-	ErrEmptySub    = errors.New("nothing to update")
+	ErrBeginTrans   = errors.New("failed to begin transaction")
+	ErrCommitTrans  = errors.New("failed to commit transaction")
+	ErrExecSchema   = errors.New("failed to exec schema")
+	ErrEmptySub     = errors.New("nothing to update")
+	ErrSubNotFound  = errors.New("subscription not found")
+	ErrSubOverlap   = errors.New("subscription overlaps existing subscription")
+	ErrVersion      = errors.New("subscription version mismatch")
+	ErrNotDeleted   = errors.New("subscription is not deleted")
+	ErrBatchAbort   = errors.New("batch is rolled back")
+	ErrRateNotFound = errors.New("exchange rate not found")
+
+	ErrServiceNotFound = errors.New("service not found")
+	ErrServiceExists   = errors.New("service name or alias is already taken")
+	ErrServiceInUse    = errors.New("service has subscriptions")
+
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserExists   = errors.New("user ID or email is already taken")
+	ErrUserInUse    = errors.New("user has subscriptions")
+
+	ErrAPIKeyNotFound = errors.New("API key not found")
+
+	ErrUnavailable   = errors.New("storage is unavailable")
+	ErrSchemaVersion = errors.New("unexpected database schema version")
 )
 
+// ExportFunc Функция, которая получает подписки выгрузки по одной.
+// Ошибка прерывает выгрузку и возвращается вызывающему без изменений.
+type ExportFunc func(sub *model.Subscription) error
+
+// AnyVersion Значение версии, при котором версия подписки не проверяется.
+const AnyVersion int64 = 0
+
+// UpdateFunc Функция изменения подписки внутри транзакции обновления.
+// Получает текущее состояние подписки и меняет его на месте. Ошибка
+// отменяет обновление и возвращается вызывающему без изменений.
+type UpdateFunc func(sub *model.Subscription) error
+
+// OverlapError Ошибка пересечения подписки с уже существующей подпиской
+// того же пользователя на тот же сервис. ConflictID равен 0, если
+// пересечение обнаружено ограничением базы данных и ID неизвестен.
+type OverlapError struct {
+	ConflictID int64
+}
+
+// Error Описание ошибки с ID конфликтующей подписки.
+func (e *OverlapError) Error() string {
+	if e.ConflictID == 0 {
+		return ErrSubOverlap.Error()
+	}
+
+	return fmt.Sprintf("%s: %d", ErrSubOverlap, e.ConflictID)
+}
+
+// Unwrap Позволяет проверять ошибку через errors.Is(err, ErrSubOverlap).
+func (e *OverlapError) Unwrap() error {
+	return ErrSubOverlap
+}
+
 // Storage Интерефейс со всеми методами, которые используют хендлеры,
-// для обращения в базу данных.
+// для обращения в базу данных. Каждое изменение подписки записывается
+// в журнал изменений в той же транзакции.
 type Storage interface {
-	CreateSubscription(ctx context.Context, sub *model.Subscription) error
-	ReadSubscription(ctx context.Context, subID int64) (*model.Subscription, error)
-	UpdateSubscription(ctx context.Context, subID int64, sub *model.Subscription) error
-	DeleteSubscription(ctx context.Context, subID int64) error
-	GetListSubscription(ctx context.Context, userID uuid.UUID, serviceName string) ([]*model.Subscription, error)
-	CostSubscription(ctx context.Context, filter *model.CostParams) (int64, error)
+	// CreateSubscription Создание подписки, проверенной через PrepareSubscription.
+	CreateSubscription(ctx context.Context, sub *model.Subscription) (*model.Subscription, error)
+	// ReadSubscription Чтение подписки, удаленной - только с includeDeleted.
+	ReadSubscription(ctx context.Context, subID int64, includeDeleted bool) (*model.Subscription, error)
+	// UpdateSubscription Обновление подписки через update. Возвращает
+	// ErrVersion, если версия не совпадает с version (кроме AnyVersion).
+	// Новая цена действует с priceFrom, см. model.NewPriceUpdate.
+	UpdateSubscription(
+		ctx context.Context, subID, version int64, priceFrom *time.Time, update UpdateFunc,
+	) (*model.Subscription, error)
+	// DeleteSubscription Перемещение подписки в корзину, с hard - удаление
+	// навсегда. Возвращает ErrVersion, как UpdateSubscription.
+	DeleteSubscription(ctx context.Context, subID, version int64, hard bool) error
+	// RestoreSubscription Восстановление подписки из корзины.
+	RestoreSubscription(ctx context.Context, subID, version int64) (*model.Subscription, error)
+	// BatchSubscriptions Выполнение операций по порядку в одной транзакции.
+	// С atomic ошибка операции отменяет пакет, остальные получают ErrBatchAbort.
+	BatchSubscriptions(ctx context.Context, ops []*model.BatchOperation, atomic bool) ([]*model.BatchResult, error)
+	// GetListSubscription Страница подписок по фильтрам.
+	GetListSubscription(ctx context.Context, filter *model.ListParams) (*model.SubscriptionPage, error)
+	// ExportSubscriptions Передача в export всех подписок по фильтрам
+	// списка без Limit по мере чтения.
+	ExportSubscriptions(ctx context.Context, filter *model.ListParams, export ExportFunc) error
+	// CostSubscription Стоимость подписок по истории цен и курсам валют.
+	CostSubscription(ctx context.Context, filter *model.CostParams) (*model.CostReport, error)
+	// GetListEvents Страница журнала изменений.
+	GetListEvents(ctx context.Context, filter *model.EventParams) (*model.EventPage, error)
+	// ListPrices История цен подписки.
+	ListPrices(ctx context.Context, subID int64) (model.PriceHistory, error)
+	// CreateService Создание сервиса каталога. CreateService и UpdateService
+	// возвращают ErrServiceExists, если название или синоним заняты.
+	CreateService(ctx context.Context, svc *model.Service) (*model.Service, error)
+	// ReadService Чтение сервиса каталога.
+	ReadService(ctx context.Context, serviceID int64) (*model.Service, error)
+	// FindService Поиск сервиса по названию или синониму.
+	FindService(ctx context.Context, name string) (*model.Service, error)
+	// UpdateService Обновление сервиса вместе с service_name его подписок.
+	UpdateService(ctx context.Context, serviceID int64, svc *model.Service) (*model.Service, error)
+	// DeleteService Удаление сервиса. Возвращает ErrServiceInUse,
+	// если на него ссылаются подписки, в том числе удаленные.
+	DeleteService(ctx context.Context, serviceID int64) error
+	// ListServices Сервисы каталога, пустая category - все.
+	ListServices(ctx context.Context, category string) ([]*model.Service, error)
+	// CreateUser Создание пользователя. CreateUser и UpdateUser возвращают
+	// ErrUserExists, если ID или email заняты.
+	CreateUser(ctx context.Context, user *model.User) (*model.User, error)
+	// ReadUser Чтение пользователя.
+	ReadUser(ctx context.Context, userID uuid.UUID) (*model.User, error)
+	// UpdateUser Обновление пользователя.
+	UpdateUser(ctx context.Context, userID uuid.UUID, user *model.User) (*model.User, error)
+	// DeleteUser Удаление пользователя. Возвращает ErrUserInUse,
+	// если у него есть подписки, в том числе удаленные.
+	DeleteUser(ctx context.Context, userID uuid.UUID) error
+	// ListUsers Все пользователи.
+	ListUsers(ctx context.Context) ([]*model.User, error)
+	// CreateAPIKey Создание ключа API.
+	CreateAPIKey(ctx context.Context, key *model.APIKey) (*model.APIKey, error)
+	// FindAPIKey Поиск действующего ключа по хешу.
+	FindAPIKey(ctx context.Context, hash string) (*model.APIKey, error)
+	// ListAPIKeys Все ключи API.
+	ListAPIKeys(ctx context.Context) ([]*model.APIKey, error)
+	// RevokeAPIKey Отзыв ключа. Возвращает ErrAPIKeyNotFound,
+	// если ключа нет или он уже отозван.
+	RevokeAPIKey(ctx context.Context, keyID int64) error
+	// ListRates Все курсы валют.
+	ListRates(ctx context.Context) ([]*model.Rate, error)
+	// SetRate Создание или замена курса пары валют.
+	SetRate(ctx context.Context, from, to string, rate *big.Rat) (*model.Rate, error)
+	// DeleteRate Удаление курса. Возвращает ErrRateNotFound, если его нет.
+	DeleteRate(ctx context.Context, from, to string) error
+	// CountActiveSubscriptions Число действующих сейчас подписок
+	// по арендаторам.
+	CountActiveSubscriptions(ctx context.Context) (map[string]int64, error)
+	// Ping Проверка готовности хранилища обслуживать запросы. Возвращает
+	// ErrUnavailable, если база данных недоступна, и ErrSchemaVersion,
+	// если версия миграций отличается от примененной при запуске.
+	Ping(ctx context.Context) error
 	CloseConnection()
 	CheckStorage
 }
@@ -33,65 +162,454 @@ type Storage interface {
 // CheckStorage Интерефейс со всеми методами, которые использует Service,
 // для проверок в базе данных.
 type CheckStorage interface {
-	CheckSubscription(ctx context.Context, sub *model.Subscription) (bool, error)
-	CheckSubscriptionID(ctx context.Context, subID int64) (bool, error)
-	CheckSubscriptionForUpdate(ctx context.Context, subID int64, sub *model.Subscription) (bool, error)
+	CheckSubscriptionID(ctx context.Context, subID int64, includeDeleted bool) (bool, error)
+}
+
+// NewEvent Создание записи журнала изменений подписки. Исполнитель
+// и ID запроса берутся из контекста. before и after копируются.
+func NewEvent(
+	ctx context.Context, action string, subID int64, before, after *model.Subscription,
+) *model.SubscriptionEvent {
+	event := model.SubscriptionEvent{
+		SubscriptionID: subID,
+		Action:         action,
+		Actor:          actor.FromContext(ctx),
+		RequestID:      middleware.GetReqID(ctx),
+	}
+
+	if before != nil {
+		b := *before
+		event.Before = &b
+	}
+
+	if after != nil {
+		a := *after
+		event.After = &a
+	}
+
+	return &event
+}
+
+// serviceCreationKey Тип ключа контекста с разрешением создавать сервисы.
+type serviceCreationKey struct{}
+
+// WithServiceCreation Разрешение создавать в каталоге сервисы, которых
+// нет, при создании и обновлении подписок.
+func WithServiceCreation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, serviceCreationKey{}, true)
+}
+
+// CanCreateService Проверка, что контекст разрешает создавать сервисы.
+func CanCreateService(ctx context.Context) bool {
+	allowed, _ := ctx.Value(serviceCreationKey{}).(bool)
+
+	return allowed
+}
+
+// AbortBatch Замена результатов атомарного пакета после ошибки
+// операции failed: остальные операции получают ErrBatchAbort.
+func AbortBatch(results []*model.BatchResult, failed int) {
+	for i := range results {
+		if i != failed {
+			results[i] = &model.BatchResult{Err: ErrBatchAbort}
+		}
+	}
 }
 
+// TenantRole Роль PostgreSQL без обхода row-level security, от которой
+// выполняются запросы к данным арендаторов. Создается миграцией.
+const TenantRole = "subscriptions_tenant"
+
 const (
+	SetTenantSchema = `
+		SELECT set_config('app.tenant_id', $1, true), set_config('role', $2, true);
+	`
 	CreateSubscriptionSchema = `
 		INSERT INTO subscriptions (
-			service_name, price, user_id, start_date, end_date
+			service_name, price_minor, currency, billing_unit, billing_count,
+			user_id, start_date, end_date, service_id
 		)
-		VALUES (
-			$1, $2, $3, TO_DATE($4, 'MM-YYYY'), TO_DATE($5, 'MM-YYYY')
-		);
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, service_id, service_name, price_minor, currency, billing_unit, billing_count,
+			user_id, start_date, end_date, created_at, updated_at, version, deleted_at;
 	`
-	SubscriptionActiveSchema = `
-		SELECT EXISTS (
-			SELECT 1
-			FROM subscriptions
-			WHERE user_id = $1
-				AND service_name = $2
-				AND (
-					end_date IS NULL 
-					OR end_date > CURRENT_DATE
-				)
-				AND CURRENT_DATE >= start_date
-		);
+	SubscriptionOverlapSchema = `
+		SELECT id
+		FROM subscriptions
+		WHERE user_id = $1
+			AND service_name = $2
+			AND id <> $3
+			AND deleted_at IS NULL
+			AND daterange(start_date, end_date, '[)')
+				&& daterange($4::DATE, $5::DATE, '[)')
+		ORDER BY start_date, id
+		LIMIT 1;
 	`
 	ReadSubscriptionSchema = `
-		SELECT service_name, price, user_id, start_date, end_date
+		SELECT id, service_id, service_name, price_minor, currency, billing_unit, billing_count,
+			user_id, start_date, end_date, created_at, updated_at, version, deleted_at
 		FROM subscriptions
-		WHERE id = $1;
+		WHERE id = $1
+			AND ($2 OR deleted_at IS NULL);
+	`
+	UpdateSubscriptionSchema = `
+		UPDATE subscriptions
+		SET service_name = $1,
+			price_minor = $2,
+			currency = $3,
+			billing_unit = $4,
+			billing_count = $5,
+			user_id = $6,
+			start_date = $7,
+			end_date = $8,
+			service_id = $9,
+			updated_at = NOW(),
+			version = version + 1
+		WHERE id = $10
+		RETURNING id, service_id, service_name, price_minor, currency, billing_unit, billing_count,
+			user_id, start_date, end_date, created_at, updated_at, version, deleted_at;
 	`
-	ReadSubscriptionStartDateSchema = `
-		SELECT start_date
+	ReadSubscriptionForUpdateSchema = `
+		SELECT id, service_id, service_name, price_minor, currency, billing_unit, billing_count,
+			user_id, start_date, end_date, created_at, updated_at, version, deleted_at
 		FROM subscriptions
-		WHERE id = $1;
+		WHERE id = $1
+			AND ($2 OR deleted_at IS NULL)
+		FOR UPDATE;
+	`
+	// LockSubscriptionsSchema Блокировка подписок пакета, включая
+	// удаленные. Порядок по ID исключает взаимные блокировки пакетов.
+	LockSubscriptionsSchema = `
+		SELECT id, service_id, service_name, price_minor, currency, billing_unit, billing_count,
+			user_id, start_date, end_date, created_at, updated_at, version, deleted_at
+		FROM subscriptions
+		WHERE id = ANY($1)
+		ORDER BY id
+		FOR UPDATE;
+	`
+	// ListOverlapCandidatesSchema Действующие подписки пользователей
+	// пакета на его сервисы для проверки пересечения периодов.
+	ListOverlapCandidatesSchema = `
+		SELECT id, service_id, service_name, price_minor, currency, billing_unit, billing_count,
+			user_id, start_date, end_date, created_at, updated_at, version, deleted_at
+		FROM subscriptions
+		WHERE user_id = ANY($1)
+			AND service_name = ANY($2)
+			AND deleted_at IS NULL;
+	`
+	// ReserveSubscriptionIDsSchema Выдача $1 ID для подписок пакета
+	// заранее, чтобы ссылаться на них до вставки.
+	ReserveSubscriptionIDsSchema = `
+		SELECT nextval(pg_get_serial_sequence('subscriptions', 'id'))
+		FROM generate_series(1, $1);
+	`
+	BatchCreateSubscriptionSchema = `
+		INSERT INTO subscriptions (
+			service_name, price_minor, currency, billing_unit, billing_count,
+			user_id, start_date, end_date, service_id, id
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, service_id, service_name, price_minor, currency, billing_unit, billing_count,
+			user_id, start_date, end_date, created_at, updated_at, version, deleted_at;
 	`
 	SubscriptionExistsSchema = `
 		SELECT EXISTS (
 			SELECT 1
 			FROM subscriptions
 			WHERE id = $1
-		);	
+				AND ($2 OR deleted_at IS NULL)
+		);
+	`
+	SoftDeleteSubscriptionSchema = `
+		UPDATE subscriptions
+		SET deleted_at = NOW(),
+			updated_at = NOW(),
+			version = version + 1
+		WHERE id = $1
+		RETURNING id, service_id, service_name, price_minor, currency, billing_unit, billing_count,
+			user_id, start_date, end_date, created_at, updated_at, version, deleted_at;
+	`
+	RestoreSubscriptionSchema = `
+		UPDATE subscriptions
+		SET deleted_at = NULL,
+			updated_at = NOW(),
+			version = version + 1
+		WHERE id = $1
+		RETURNING id, service_id, service_name, price_minor, currency, billing_unit, billing_count,
+			user_id, start_date, end_date, created_at, updated_at, version, deleted_at;
 	`
 	DeleteSubscriptionSchema = `
 		DELETE FROM subscriptions
 		WHERE id = $1;
 	`
+	// ListSubscriptionSchema Условие WHERE и порядок сортировки
+	// собираются динамически по заданным фильтрам.
 	ListSubscriptionSchema = `
-		SELECT service_name, price, user_id, start_date, end_date
+		SELECT id, service_id, service_name, price_minor, currency, billing_unit, billing_count,
+			user_id, start_date, end_date, created_at, updated_at, version, deleted_at
+		FROM subscriptions
+		%s
+		ORDER BY %s
+		LIMIT $%d;
+	`
+	// ExportSubscriptionsSchema Условие WHERE и порядок сортировки
+	// собираются так же, как для ListSubscriptionSchema.
+	ExportSubscriptionsSchema = `
+		SELECT id, service_id, service_name, price_minor, currency, billing_unit, billing_count,
+			user_id, start_date, end_date, created_at, updated_at, version, deleted_at
 		FROM subscriptions
-		WHERE user_id = $1 AND service_name = $2;
+		%s
+		ORDER BY %s;
 	`
+	// CountSubscriptionsSchema Условие WHERE собирается динамически
+	// по заданным фильтрам.
 	CountSubscriptionsSchema = `
-		SELECT sum(price)
+		SELECT id, user_id, service_id, service_name, price_minor, currency,
+			billing_unit, billing_count, start_date, end_date
 		FROM subscriptions
-		WHERE user_id = $1 
-			AND service_name = $2
-			AND start_date >= $3
-			AND (end_date <= $4 OR end_date IS NULL);
+		%s
+		ORDER BY id;
+	`
+	CreateServiceSchema = `
+		INSERT INTO services (name, aliases, category, default_price_minor, currency)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, name, aliases, category, default_price_minor, currency, created_at, updated_at;
+	`
+	CreateServiceNameSchema = `
+		INSERT INTO service_names (name_key, service_id)
+		VALUES ($1, $2)
+		ON CONFLICT (tenant_id, name_key) DO NOTHING;
+	`
+	DeleteServiceNamesSchema = `
+		DELETE FROM service_names
+		WHERE service_id = $1;
+	`
+	FindServiceSchema = `
+		SELECT s.id, s.name, s.aliases, s.category, s.default_price_minor, s.currency,
+			s.created_at, s.updated_at
+		FROM service_names n
+		JOIN services s ON s.id = n.service_id
+		WHERE n.name_key = $1;
+	`
+	FindServicesSchema = `
+		SELECT DISTINCT s.id, s.name, s.aliases, s.category, s.default_price_minor, s.currency,
+			s.created_at, s.updated_at
+		FROM service_names n
+		JOIN services s ON s.id = n.service_id
+		WHERE n.name_key = ANY($1);
+	`
+	ReadServiceSchema = `
+		SELECT id, name, aliases, category, default_price_minor, currency, created_at, updated_at
+		FROM services
+		WHERE id = $1;
+	`
+	ReadServiceForUpdateSchema = `
+		SELECT id, name, aliases, category, default_price_minor, currency, created_at, updated_at
+		FROM services
+		WHERE id = $1
+		FOR UPDATE;
+	`
+	UpdateServiceSchema = `
+		UPDATE services
+		SET name = $1,
+			aliases = $2,
+			category = $3,
+			default_price_minor = $4,
+			currency = $5,
+			updated_at = NOW()
+		WHERE id = $6
+		RETURNING id, name, aliases, category, default_price_minor, currency, created_at, updated_at;
+	`
+	// LockServiceSubscriptionsSchema Блокировка подписок сервиса, которые
+	// получат новое название, для записи их прежнего состояния в журнал.
+	LockServiceSubscriptionsSchema = `
+		SELECT id, service_id, service_name, price_minor, currency, billing_unit, billing_count,
+			user_id, start_date, end_date, created_at, updated_at, version, deleted_at
+		FROM subscriptions
+		WHERE service_id = $1
+			AND service_name <> $2
+		ORDER BY id
+		FOR UPDATE;
+	`
+	// RenameServiceSubscriptionsSchema Подписки получают новое название
+	// сервиса и новую версию, так как их представление изменилось.
+	RenameServiceSubscriptionsSchema = `
+		UPDATE subscriptions
+		SET service_name = $2,
+			updated_at = NOW(),
+			version = version + 1
+		WHERE service_id = $1
+			AND service_name <> $2
+		RETURNING id, service_id, service_name, price_minor, currency, billing_unit, billing_count,
+			user_id, start_date, end_date, created_at, updated_at, version, deleted_at;
+	`
+	ServiceInUseSchema = `
+		SELECT EXISTS (
+			SELECT 1
+			FROM subscriptions
+			WHERE service_id = $1
+		);
+	`
+	DeleteServiceSchema = `
+		DELETE FROM services
+		WHERE id = $1;
+	`
+	ListServicesSchema = `
+		SELECT id, name, aliases, category, default_price_minor, currency, created_at, updated_at
+		FROM services
+		WHERE $1::TEXT = '' OR category = $1
+		ORDER BY name, id;
+	`
+	CreateUserSchema = `
+		INSERT INTO users (id, email, display_name, timezone, currency)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, email, display_name, timezone, currency, created_at, updated_at;
+	`
+	ReadUserSchema = `
+		SELECT id, email, display_name, timezone, currency, created_at, updated_at
+		FROM users
+		WHERE id = $1;
+	`
+	// LockUserSchema Блокировка пользователя от удаления до конца
+	// транзакции, в которой на него ссылается подписка.
+	LockUserSchema = `
+		SELECT id
+		FROM users
+		WHERE id = $1
+		FOR KEY SHARE;
+	`
+	LockUsersSchema = `
+		SELECT id
+		FROM users
+		WHERE id = ANY($1)
+		FOR KEY SHARE;
+	`
+	UpdateUserSchema = `
+		UPDATE users
+		SET email = $1,
+			display_name = $2,
+			timezone = $3,
+			currency = $4,
+			updated_at = NOW()
+		WHERE id = $5
+		RETURNING id, email, display_name, timezone, currency, created_at, updated_at;
+	`
+	UserInUseSchema = `
+		SELECT EXISTS (
+			SELECT 1
+			FROM subscriptions
+			WHERE user_id = $1
+		);
+	`
+	DeleteUserSchema = `
+		DELETE FROM users
+		WHERE id = $1
+		RETURNING id;
+	`
+	ListUsersSchema = `
+		SELECT id, email, display_name, timezone, currency, created_at, updated_at
+		FROM users
+		ORDER BY created_at, id;
+	`
+	CreateAPIKeySchema = `
+		INSERT INTO api_keys (name, prefix, key_hash, role, user_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, name, prefix, role, user_id, tenant_id, created_at, revoked_at;
+	`
+	FindAPIKeySchema = `
+		SELECT id, name, prefix, role, user_id, tenant_id, created_at, revoked_at
+		FROM api_keys
+		WHERE key_hash = $1
+			AND revoked_at IS NULL;
+	`
+	ListAPIKeysSchema = `
+		SELECT id, name, prefix, role, user_id, tenant_id, created_at, revoked_at
+		FROM api_keys
+		WHERE tenant_id = current_setting('app.tenant_id')
+		ORDER BY id;
+	`
+	RevokeAPIKeySchema = `
+		UPDATE api_keys
+		SET revoked_at = NOW()
+		WHERE id = $1
+			AND tenant_id = current_setting('app.tenant_id')
+			AND revoked_at IS NULL;
+	`
+	CreatePriceSchema = `
+		INSERT INTO subscription_prices (subscription_id, effective_from, price_minor)
+		VALUES ($1, $2, $3);
+	`
+	DeletePricesSinceSchema = `
+		DELETE FROM subscription_prices
+		WHERE subscription_id = $1
+			AND effective_from >= $2;
+	`
+	ListPricesSchema = `
+		SELECT effective_from, price_minor
+		FROM subscription_prices
+		WHERE subscription_id = $1
+		ORDER BY effective_from;
+	`
+	CostPricesSchema = `
+		SELECT subscription_id, effective_from, price_minor
+		FROM subscription_prices
+		WHERE subscription_id = ANY($1)
+		ORDER BY subscription_id, effective_from;
+	`
+	// MigrationVersionSchema Версия схемы из таблицы мигратора.
+	MigrationVersionSchema = `
+		SELECT version, dirty
+		FROM schema_migrations
+		LIMIT 1;
+	`
+	// CountActiveSubscriptionsSchema Выполняется без роли арендатора,
+	// чтобы видеть подписки всех арендаторов.
+	CountActiveSubscriptionsSchema = `
+		SELECT tenant_id, COUNT(*)
+		FROM subscriptions
+		WHERE deleted_at IS NULL
+			AND start_date <= CURRENT_DATE
+			AND (end_date IS NULL OR end_date > CURRENT_DATE)
+		GROUP BY tenant_id;
+	`
+	ListRatesSchema = `
+		SELECT from_currency, to_currency, trim_scale(rate)::TEXT, updated_at
+		FROM exchange_rates
+		ORDER BY from_currency, to_currency;
+	`
+	SetRateSchema = `
+		INSERT INTO exchange_rates (from_currency, to_currency, rate)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (tenant_id, from_currency, to_currency) DO UPDATE
+		SET rate = EXCLUDED.rate,
+			updated_at = NOW()
+		RETURNING from_currency, to_currency, trim_scale(rate)::TEXT, updated_at;
+	`
+	DeleteRateSchema = `
+		DELETE FROM exchange_rates
+		WHERE from_currency = $1
+			AND to_currency = $2;
+	`
+	CreateEventSchema = `
+		INSERT INTO subscription_events (
+			subscription_id, action, actor, request_id, before, after
+		)
+		VALUES ($1, $2, $3, $4, $5, $6);
+	`
+	// ListEventsSchema Условие WHERE собирается динамически
+	// по заданным фильтрам. Вместе с событием возвращается текущий
+	// сервис подписки для старых снимков без service_id.
+	ListEventsSchema = `
+		SELECT id, subscription_id, action, actor, request_id,
+			before, after, created_at,
+			COALESCE((
+				SELECT s.service_id
+				FROM subscriptions s
+				WHERE s.id = subscription_events.subscription_id
+			), 0)
+		FROM subscription_events
+		%s
+		ORDER BY id
+		LIMIT $%d;
 	`
 )
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/google/uuid"
+)
+
+// SubscriptionState Данные хранилища, по которым PrepareSubscription
+// проверяет подписку. Драйвер вызывает PrepareSubscription внутри
+// транзакции или под блокировкой, поэтому данные не меняются
+// до записи подписки.
+type SubscriptionState interface {
+	// UserExists Проверка, что пользователь существует.
+	UserExists(ctx context.Context, userID uuid.UUID) (bool, error)
+	// FindService Сервис каталога по названию или синониму.
+	// Возвращает ErrServiceNotFound, если сервиса нет.
+	FindService(ctx context.Context, name string) (*model.Service, error)
+	// FindOverlap ID действующей подписки того же пользователя на тот же
+	// сервис, период которой пересекается с [start, end), или 0.
+	// Подписка subID не проверяется. Из нескольких подписок выбирается
+	// самая ранняя по start_date и ID.
+	FindOverlap(
+		ctx context.Context, sub *model.Subscription, subID int64, start time.Time, end *time.Time,
+	) (int64, error)
+}
+
+// SubscriptionChange Проверенная подписка, которую драйвер записывает
+// в хранилище. Сервис без ID драйвер создает вместе с подпиской.
+// Price задан, если обновление меняет историю цен.
+type SubscriptionChange struct {
+	Sub     *model.Subscription
+	Service *model.Service
+	Start   time.Time
+	End     *time.Time
+	Price   *model.PriceUpdate
+}
+
+// PrepareSubscription Проверка создаваемой (before == nil) или
+// обновляемой подписки sub по правилам, общим для одиночных и пакетных
+// запросов всех драйверов. Пользователь должен существовать, сервис
+// ищется в каталоге или создается, если контекст разрешает это через
+// WithServiceCreation, обновление цены дает изменение истории цен
+// по model.NewPriceUpdate на момент now, а период не должен пересекаться
+// с другими подписками. Возвращает *model.ValidationError по полям
+// user_id и service_name и *OverlapError. sub не изменяется.
+func PrepareSubscription(
+	ctx context.Context, state SubscriptionState, before, sub *model.Subscription, priceFrom *time.Time, now time.Time,
+) (*SubscriptionChange, error) {
+	exists, err := state.UserExists(ctx, sub.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, model.NewValidationError("user_id", model.RuleExists, "user does not exist")
+	}
+
+	svc, err := state.FindService(ctx, sub.ServiceName)
+
+	switch {
+	case errors.Is(err, ErrServiceNotFound):
+		if !CanCreateService(ctx) {
+			return nil, model.NewValidationError("service_name", model.RuleExists, "service does not exist")
+		}
+
+		svc = &model.Service{Name: sub.ServiceName}
+		svc.SetDefaults()
+	case err != nil:
+		return nil, err
+	}
+
+	prepared := *sub
+	prepared.ServiceID, prepared.ServiceName = svc.ID, svc.Name
+	change := SubscriptionChange{Sub: &prepared, Service: svc}
+
+	var subID int64
+
+	if before != nil {
+		subID = before.ID
+
+		if change.Price, err = model.NewPriceUpdate(before, &prepared, priceFrom, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if change.Start, change.End, err = prepared.Dates(); err != nil {
+		return nil, err
+	}
+
+	// Даты записываются в том виде, в котором их возвращает хранилище.
+	prepared.StartDate = model.FormatDate(change.Start)
+	if change.End != nil {
+		prepared.EndDate = model.FormatDate(*change.End)
+	}
+
+	conflictID, err := state.FindOverlap(ctx, &prepared, subID, change.Start, change.End)
+	if err != nil {
+		return nil, err
+	}
+
+	if conflictID != 0 {
+		return nil, &OverlapError{ConflictID: conflictID}
+	}
+
+	return &change, nil
+}
+
+// ServiceFinder Поиск сервиса каталога по названию или синониму.
+type ServiceFinder interface {
+	FindService(ctx context.Context, name string) (*model.Service, error)
+}
+
+// Catalog Заполнение создаваемых подписок по каталогу сервисов через
+// model.ApplyService. Одиночное создание, пакет и импорт заполняют
+// подписки одинаково, до проверки подписки. Найденные сервисы
+// запоминаются, чтобы пакет искал каждое название один раз.
+type Catalog struct {
+	finder   ServiceFinder
+	services map[string]*model.Service
+}
+
+// NewCatalog Создание Catalog, который ищет сервисы через finder.
+func NewCatalog(finder ServiceFinder) *Catalog {
+	return &Catalog{finder: finder, services: make(map[string]*model.Service)}
+}
+
+// Apply Заполнение подписки sub по ее сервису. Подписка на сервис,
+// которого нет в каталоге, не меняется.
+func (c *Catalog) Apply(ctx context.Context, sub *model.Subscription) error {
+	key := model.ServiceKey(sub.ServiceName)
+
+	svc, ok := c.services[key]
+	if !ok {
+		var err error
+
+		svc, err = c.finder.FindService(ctx, sub.ServiceName)
+		if err != nil && !errors.Is(err, ErrServiceNotFound) {
+			return err
+		}
+
+		c.services[key] = svc
+	}
+
+	if svc != nil {
+		sub.ApplyService(svc)
+	}
+
+	return nil
+}
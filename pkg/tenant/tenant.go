@@ -0,0 +1,70 @@
+// Пакет tenant хранит в контексте запроса арендатора - бизнес-подразделение,
+// с данными которого работает запрос. Хранилища не показывают одному
+// арендатору данные другого.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Header Заголовок запроса с ID арендатора.
+const Header = "X-Tenant-ID"
+
+// Default Арендатор, если он не указан в запросе. Ему же принадлежат
+// данные, созданные до разделения по арендаторам.
+const Default = "default"
+
+// MaxLength Максимальная длина ID арендатора, совпадает с размером
+// колонки tenant_id VARCHAR(64).
+const MaxLength = 64
+
+var (
+	ErrInvalidTenant = errors.New("invalid tenant")
+	ErrNoTenant      = errors.New("tenant is not set")
+)
+
+// pattern Допустимый ID арендатора: строчные латинские буквы, цифры,
+// дефис и подчеркивание, начиная с буквы или цифры.
+var pattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Parse Проверка ID арендатора. Возвращает ErrInvalidTenant.
+func Parse(id string) (string, error) {
+	if len(id) > MaxLength || !pattern.MatchString(id) {
+		return "", fmt.Errorf(
+			"%w: %q must be at most %d lowercase letters, digits, '-' or '_'", ErrInvalidTenant, id, MaxLength,
+		)
+	}
+
+	return id, nil
+}
+
+// ctxKey Тип ключа контекста, чтобы не пересекаться с другими пакетами.
+type ctxKey struct{}
+
+// WithTenant Добавление арендатора в контекст.
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext Получение арендатора из контекста. Возвращает false,
+// если арендатор не задан: Default подставляет только middleware,
+// которое разбирает запрос.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(ctxKey{}).(string)
+
+	return id, ok && id != ""
+}
+
+// Require Получение арендатора из контекста для хранилищ, которые
+// без арендатора не работают. Возвращает ErrNoTenant.
+func Require(ctx context.Context) (string, error) {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return "", ErrNoTenant
+	}
+
+	return id, nil
+}
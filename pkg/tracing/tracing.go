@@ -0,0 +1,121 @@
+// Пакет tracing для трассировки запросов через OpenTelemetry.
+// Init настраивает глобальный TracerProvider по конфигу, Middleware
+// создает серверный спан на каждый запрос, остальные пакеты получают
+// tracer через Tracer и создают дочерние спаны из контекста запроса.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/SHSanderland/EffMobTest/pkg/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation Имя инструментирования, с которым создаются спаны.
+const instrumentation = "github.com/SHSanderland/EffMobTest"
+
+// ShutdownFunc Функция, которая отправляет оставшиеся спаны
+// и останавливает экспортер.
+type ShutdownFunc func(ctx context.Context) error
+
+// Tracer Tracer сервиса из глобального TracerProvider. Без Init
+// спаны не записываются.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
+
+// Init Настройка глобального TracerProvider и распространения контекста
+// в формате W3C Trace Context. Контекст трассировки принимается всегда,
+// даже с выключенной трассировкой, чтобы его ID попадали в логи.
+func Init(ctx context.Context, cfg *config.Tracing) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	if cfg.Exporter == config.ExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, closeOutput, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		return errors.Join(provider.Shutdown(ctx), closeOutput())
+	}, nil
+}
+
+// newExporter Создание экспортера по конфигу. Возвращает также функцию
+// закрытия файла для экспортера file.
+func newExporter(ctx context.Context, cfg *config.Tracing) (sdktrace.SpanExporter, func() error, error) {
+	noClose := func() error { return nil }
+
+	switch cfg.Exporter {
+	case config.ExporterOTLP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+
+		return exporter, noClose, nil
+	case config.ExporterStdout:
+		exporter, err := newWriterExporter(os.Stdout)
+
+		return exporter, noClose, err
+	case config.ExporterFile:
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+
+		exporter, err := newWriterExporter(f)
+		if err != nil {
+			return nil, nil, errors.Join(err, f.Close())
+		}
+
+		return exporter, f.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown tracing exporter: %s", cfg.Exporter)
+	}
+}
+
+// newWriterExporter Экспортер спанов в формате JSON в w.
+func newWriterExporter(w io.Writer) (sdktrace.SpanExporter, error) {
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout exporter: %w", err)
+	}
+
+	return exporter, nil
+}
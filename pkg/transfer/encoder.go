@@ -0,0 +1,72 @@
+package transfer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+)
+
+// csvEncoder Запись подписок в CSV. Заголовок пишется вместе
+// с первой подпиской или при flush пустой выгрузки.
+type csvEncoder struct {
+	w      *csv.Writer
+	header bool
+}
+
+// newCSVEncoder Создание csvEncoder.
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+// encode Запись подписки.
+func (e *csvEncoder) encode(sub *model.Subscription) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	return e.w.Write(csvRecord(sub))
+}
+
+// flush Запись буфера в writer.
+func (e *csvEncoder) flush() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	e.w.Flush()
+
+	return e.w.Error()
+}
+
+// writeHeader Запись заголовка, если он еще не записан.
+func (e *csvEncoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+
+	e.header = true
+
+	return e.w.Write(Columns)
+}
+
+// jsonlEncoder Запись подписок в JSON Lines, одна подписка на строку.
+type jsonlEncoder struct {
+	enc *json.Encoder
+}
+
+// newJSONLEncoder Создание jsonlEncoder.
+func newJSONLEncoder(w io.Writer) *jsonlEncoder {
+	return &jsonlEncoder{enc: json.NewEncoder(w)}
+}
+
+// encode Запись подписки.
+func (e *jsonlEncoder) encode(sub *model.Subscription) error {
+	return e.enc.Encode(sub)
+}
+
+// flush Ничего не делает, json.Encoder пишет строки сразу.
+func (e *jsonlEncoder) flush() error {
+	return nil
+}
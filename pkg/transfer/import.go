@@ -0,0 +1,364 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// LineResult Результат импорта одной строки файла. Subscription
+// задана у принятой строки, Err - у отклоненной.
+type LineResult struct {
+	Line         int
+	Subscription *model.Subscription
+	Err          error
+}
+
+// Report Отчет импорта. Lines отсортированы по номеру строки.
+type Report struct {
+	Lines    []*LineResult
+	Accepted int
+	Rejected int
+}
+
+// importer Интерефейс с методами к базе данных,
+// который использует Import.
+type importer interface {
+	BatchSubscriptions(ctx context.Context, ops []*model.BatchOperation, atomic bool) ([]*model.BatchResult, error)
+	FindService(ctx context.Context, name string) (*model.Service, error)
+}
+
+// CheckFunc Проверка подписки перед созданием.
+type CheckFunc func(sub *model.Subscription) error
+
+// Import Создание подписок из r. Каждая строка заполняется по каталогу
+// сервисов, как в POST /subscriptions, и проверяется через check,
+// подходящие строки создаются неатомарными пакетами по MaxBatchSize,
+// поэтому ошибка одной строки не мешает остальным.
+//
+// Примечание: ошибка метода означает, что файл не удалось дочитать,
+// при этом пакеты, созданные до ошибки, остаются в базе данных.
+func Import(ctx context.Context, st importer, check CheckFunc, r io.Reader, format string) (*Report, error) {
+	var dec decoder
+
+	switch format {
+	case model.FormatCSV:
+		d, err := newCSVDecoder(r)
+		if err != nil {
+			return nil, err
+		}
+
+		dec = d
+	case model.FormatJSONL:
+		dec = newJSONLDecoder(r)
+	default:
+		return nil, fmt.Errorf("unknown format: %s", format)
+	}
+
+	imp := batchImport{st: st, report: &Report{}}
+	catalog := storage.NewCatalog(st)
+
+	for {
+		line, sub, err := dec.decode()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var lineErr *lineError
+
+		switch {
+		case errors.As(err, &lineErr):
+			imp.reject(line, lineErr.err)
+
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		if err := catalog.Apply(ctx, sub); err != nil {
+			return nil, err
+		}
+
+		if err := check(sub); err != nil {
+			imp.reject(line, err)
+
+			continue
+		}
+
+		if err := imp.add(ctx, line, sub); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := imp.flush(ctx); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(imp.report.Lines, func(a, b *LineResult) int {
+		return a.Line - b.Line
+	})
+
+	return imp.report, nil
+}
+
+// batchImport Накопление проверенных строк в пакеты создания.
+type batchImport struct {
+	st     importer
+	report *Report
+	lines  []int
+	ops    []*model.BatchOperation
+}
+
+// reject Запись отклоненной строки в отчет.
+func (b *batchImport) reject(line int, err error) {
+	b.report.Lines = append(b.report.Lines, &LineResult{Line: line, Err: err})
+	b.report.Rejected++
+}
+
+// add Добавление строки в пакет. Полный пакет сразу отправляется
+// в базу данных.
+func (b *batchImport) add(ctx context.Context, line int, sub *model.Subscription) error {
+	b.lines = append(b.lines, line)
+	b.ops = append(b.ops, &model.BatchOperation{Op: model.BatchCreate, Subscription: sub})
+
+	if len(b.ops) < model.MaxBatchSize {
+		return nil
+	}
+
+	return b.flush(ctx)
+}
+
+// flush Создание накопленного пакета и запись результатов в отчет.
+func (b *batchImport) flush(ctx context.Context) error {
+	if len(b.ops) == 0 {
+		return nil
+	}
+
+	results, err := b.st.BatchSubscriptions(ctx, b.ops, false)
+	if err != nil {
+		return err
+	}
+
+	for i, res := range results {
+		if res.Err != nil {
+			b.reject(b.lines[i], res.Err)
+
+			continue
+		}
+
+		b.report.Lines = append(b.report.Lines, &LineResult{Line: b.lines[i], Subscription: res.Subscription})
+		b.report.Accepted++
+	}
+
+	b.lines = b.lines[:0]
+	b.ops = b.ops[:0]
+
+	return nil
+}
+
+// lineError Ошибка одной строки файла, которая не мешает
+// читать следующие строки.
+type lineError struct {
+	err error
+}
+
+// Error Описание ошибки строки.
+func (e *lineError) Error() string {
+	return e.err.Error()
+}
+
+// decoder Чтение подписок из файла импорта. Возвращает номер строки,
+// io.EOF в конце файла и *lineError для ошибки одной строки.
+type decoder interface {
+	decode() (int, *model.Subscription, error)
+}
+
+// requiredColumns Колонки, без которых CSV не импортируется.
+var requiredColumns = []string{"service_name", "price", "user_id", "start_date"}
+
+// csvDecoder Чтение подписок из CSV с заголовком.
+type csvDecoder struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+// newCSVDecoder Создание csvDecoder и проверка заголовка. Неизвестные
+// колонки и отсутствие обязательных колонок возвращаются как ErrBadBody.
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: CSV header is missing", model.ErrBadBody)
+		}
+
+		return nil, readError(err)
+	}
+
+	columns := make(map[string]int, len(header))
+
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+
+		if !slices.Contains(Columns, name) {
+			return nil, fmt.Errorf("%w: unknown CSV column %q", model.ErrBadBody, name)
+		}
+
+		columns[name] = i
+	}
+
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: CSV column %q is required", model.ErrBadBody, name)
+		}
+	}
+
+	cr.FieldsPerRecord = len(header)
+
+	return &csvDecoder{r: cr, columns: columns}, nil
+}
+
+// decode Чтение следующей строки CSV.
+func (d *csvDecoder) decode() (int, *model.Subscription, error) {
+	record, err := d.r.Read()
+	line, _ := d.r.FieldPos(0)
+
+	var parseErr *csv.ParseError
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return 0, nil, io.EOF
+	case errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount):
+		return parseErr.StartLine, nil, &lineError{fmt.Errorf("%w: %w", model.ErrBadBody, parseErr.Err)}
+	case err != nil:
+		return 0, nil, readError(err)
+	}
+
+	sub := model.Subscription{
+		ServiceName: d.field(record, "service_name"),
+		Currency:    d.field(record, "currency"),
+		StartDate:   d.field(record, "start_date"),
+		EndDate:     d.field(record, "end_date"),
+	}
+
+	sub.BillingPeriod.Unit = d.field(record, "billing_unit")
+
+	var violations []model.Violation
+
+	if count := d.field(record, "billing_count"); count != "" {
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			violations = append(violations, model.Violation{
+				Field: "billing_period.count", Rule: model.RuleType, Message: "must be an integer",
+			})
+		}
+
+		sub.BillingPeriod.Count = n
+	}
+
+	sub.SetDefaults()
+
+	// Пустая цена заполняется ценой сервиса по умолчанию.
+	var price model.Amount
+
+	if raw := d.field(record, "price"); raw != "" {
+		if price, err = model.ParseAmount(raw, sub.Currency); err != nil {
+			violations = append(violations, model.Violation{Field: "price", Rule: model.RuleFormat, Message: err.Error()})
+		}
+	}
+
+	userID, err := uuid.Parse(d.field(record, "user_id"))
+	if err != nil {
+		violations = append(violations, model.Violation{Field: "user_id", Rule: model.RuleFormat, Message: "must be a UUID"})
+	}
+
+	if len(violations) > 0 {
+		return line, nil, &lineError{&model.ValidationError{Violations: violations}}
+	}
+
+	sub.Price = price
+	sub.UserID = userID
+
+	return line, &sub, nil
+}
+
+// field Значение колонки name, пустое, если колонки нет в файле.
+func (d *csvDecoder) field(record []string, name string) string {
+	i, ok := d.columns[name]
+	if !ok {
+		return ""
+	}
+
+	return strings.TrimSpace(record[i])
+}
+
+// readError Преобразование ошибки чтения файла в ошибку для пользователя.
+func readError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return fmt.Errorf("%w: limit is %d bytes", model.ErrBodyTooLarge, maxBytesErr.Limit)
+	}
+
+	return fmt.Errorf("%w: %w", model.ErrBadBody, err)
+}
+
+// jsonlDecoder Чтение подписок из JSON Lines. Пустые строки пропускаются.
+type jsonlDecoder struct {
+	r    *bufio.Reader
+	line int
+}
+
+// newJSONLDecoder Создание jsonlDecoder.
+func newJSONLDecoder(r io.Reader) *jsonlDecoder {
+	return &jsonlDecoder{r: bufio.NewReader(r)}
+}
+
+// decode Чтение следующей непустой строки JSON Lines. Строка длиннее
+// MaxBodySize отклоняется так же, как слишком большое тело запроса.
+func (d *jsonlDecoder) decode() (int, *model.Subscription, error) {
+	for {
+		data, err := d.r.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, nil, readError(err)
+		}
+
+		if len(data) == 0 && errors.Is(err, io.EOF) {
+			return 0, nil, io.EOF
+		}
+
+		d.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		if len(data) > model.MaxBodySize {
+			return d.line, nil, &lineError{fmt.Errorf("%w: limit is %d bytes", model.ErrBodyTooLarge, model.MaxBodySize)}
+		}
+
+		sub, err := model.GetSubFromJSON(data)
+		if err != nil {
+			return d.line, nil, &lineError{err}
+		}
+
+		return d.line, sub, nil
+	}
+}
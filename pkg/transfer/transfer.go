@@ -0,0 +1,106 @@
+// Пакет transfer нужен для импорта и экспорта подписок в форматах
+// CSV и JSON Lines. Используется хендлерами и командами import и
+// export в cmd.
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+)
+
+// Columns Колонки CSV. При импорте обязательны service_name, price,
+// user_id и start_date, без currency, billing_unit и billing_count
+// используются model.DefaultCurrency и model.DefaultBillingPeriod.
+// Колонки, которые заполняет база данных, игнорируются, поэтому
+// выгрузку можно загрузить обратно.
+var Columns = []string{
+	"id", "service_id", "service_name", "price", "currency", "billing_unit", "billing_count",
+	"user_id", "start_date", "end_date", "created_at", "updated_at", "version", "deleted_at",
+}
+
+// ContentType Тип содержимого для формата выгрузки.
+func ContentType(format string) string {
+	if format == model.FormatJSONL {
+		return "application/jsonl"
+	}
+
+	return "text/csv; charset=utf-8"
+}
+
+// exporter Интерефейс с методами к базе данных,
+// который использует Export.
+type exporter interface {
+	ExportSubscriptions(ctx context.Context, filter *model.ListParams, export storage.ExportFunc) error
+}
+
+// Export Выгрузка подписок по фильтрам списка в w. Возвращает
+// количество выгруженных подписок. Если ошибка произошла до первой
+// подписки, в w ничего не записано.
+func Export(ctx context.Context, st exporter, filter *model.ListParams, w io.Writer, format string) (int, error) {
+	enc, err := newEncoder(w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+
+	err = st.ExportSubscriptions(ctx, filter, func(sub *model.Subscription) error {
+		count++
+
+		return enc.encode(sub)
+	})
+	if err != nil {
+		return count, err
+	}
+
+	return count, enc.flush()
+}
+
+// encoder Запись подписок в одном из форматов выгрузки.
+type encoder interface {
+	encode(sub *model.Subscription) error
+	flush() error
+}
+
+// newEncoder Создание encoder для формата format.
+func newEncoder(w io.Writer, format string) (encoder, error) {
+	switch format {
+	case model.FormatCSV:
+		return newCSVEncoder(w), nil
+	case model.FormatJSONL:
+		return newJSONLEncoder(w), nil
+	default:
+		return nil, fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+// csvRecord Преобразование подписки в строку CSV в порядке Columns.
+func csvRecord(sub *model.Subscription) []string {
+	deletedAt := ""
+	if sub.DeletedAt != nil {
+		deletedAt = sub.DeletedAt.Format(time.RFC3339)
+	}
+
+	return []string{
+		strconv.FormatInt(sub.ID, 10),
+		strconv.FormatInt(sub.ServiceID, 10),
+		sub.ServiceName,
+		sub.Price.Format(sub.Currency),
+		sub.Currency,
+		sub.BillingPeriod.Unit,
+		strconv.Itoa(sub.BillingPeriod.Count),
+		sub.UserID.String(),
+		sub.StartDate,
+		sub.EndDate,
+		sub.CreatedAt.Format(time.RFC3339),
+		sub.UpdatedAt.Format(time.RFC3339),
+		strconv.FormatInt(sub.Version, 10),
+		deletedAt,
+	}
+}
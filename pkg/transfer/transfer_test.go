@@ -0,0 +1,171 @@
+package transfer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/SHSanderland/EffMobTest/pkg/model"
+	"github.com/SHSanderland/EffMobTest/pkg/storage"
+	"github.com/SHSanderland/EffMobTest/pkg/storage/memory"
+	"github.com/SHSanderland/EffMobTest/pkg/storage/storagetest"
+)
+
+func TestImport(t *testing.T) {
+	st := memory.InitStorage(slog.New(slog.DiscardHandler))
+	ctx := storagetest.Context(storagetest.NewTenant())
+	userID := storagetest.NewUser(ctx, t, st).String()
+
+	price := model.Amount(29900)
+	svc := model.Service{Name: "Kinopoisk", DefaultPrice: &price, Currency: "RUB"}
+
+	if _, err := st.CreateService(ctx, &svc); err != nil {
+		t.Fatalf("CreateService unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		format    string
+		input     string
+		wantErr   error
+		wantLines []string
+	}{
+		{
+			name:   "csv",
+			format: model.FormatCSV,
+			input: "\ufeffservice_name,price,user_id,start_date,end_date\n" +
+				"Netflix,4.5," + userID + ",01-2020,01-2021\n" +
+				"kinopoisk,," + userID + ",01-2020,01-2021\n" +
+				"Netflix,abc," + userID + ",01-2020,\n" +
+				"Netflix,4," + userID + ",06-2020,\n" +
+				"Netflix,4,not-a-uuid,01-2020,\n" +
+				"Netflix,4\n",
+			wantLines: []string{
+				"2: Netflix 450", "3: Kinopoisk 29900", "4: price", "5: overlap", "6: user_id", "7: bad body",
+			},
+		},
+		{
+			name:   "jsonl",
+			format: model.FormatJSONL,
+			input: `{"service_name":"Spotify","price":3,"user_id":"` + userID + `","start_date":"01-2020"}` + "\n\n" +
+				`{"service_name":"Kinopoisk","user_id":"` + userID + `","start_date":"01-2030"}` + "\n" +
+				`{"service_name":"Spotify","price":0,"user_id":"` + userID + `","start_date":"01-2021"}` + "\n" +
+				"{not json}\n",
+			wantLines: []string{"1: Spotify 300", "3: Kinopoisk 29900", "4: price", "5: bad body"},
+		},
+		{name: "missing column", format: model.FormatCSV, input: "service_name,user_id\n", wantErr: model.ErrBadBody},
+		{name: "unknown column", format: model.FormatCSV, input: "service_name,color\n", wantErr: model.ErrBadBody},
+		{name: "empty csv", format: model.FormatCSV, input: "", wantErr: model.ErrBadBody},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Import(ctx, st, model.ValidateSubscription, strings.NewReader(tt.input), tt.format)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			lines := make([]string, 0, len(report.Lines))
+			accepted := 0
+
+			for _, line := range report.Lines {
+				lines = append(lines, describeLine(line))
+
+				if line.Err == nil {
+					accepted++
+				}
+			}
+
+			if !slices.Equal(lines, tt.wantLines) {
+				t.Errorf("lines = %q, want %q", lines, tt.wantLines)
+			}
+
+			if report.Accepted != accepted || report.Rejected != len(lines)-accepted {
+				t.Errorf("report = %d accepted, %d rejected, want %d, %d",
+					report.Accepted, report.Rejected, accepted, len(lines)-accepted)
+			}
+		})
+	}
+}
+
+// describeLine Строка отчета импорта: подписка с ценой или поле первого
+// нарушения, для остальных ошибок - overlap или bad body.
+func describeLine(line *LineResult) string {
+	var verr *model.ValidationError
+
+	switch {
+	case line.Err == nil:
+		return fmt.Sprintf("%d: %s %d", line.Line, line.Subscription.ServiceName, line.Subscription.Price)
+	case errors.Is(line.Err, storage.ErrSubOverlap):
+		return fmt.Sprintf("%d: overlap", line.Line)
+	case errors.As(line.Err, &verr) && len(verr.Violations) > 0:
+		return fmt.Sprintf("%d: %s", line.Line, verr.Violations[0].Field)
+	case errors.Is(line.Err, model.ErrBadBody):
+		return fmt.Sprintf("%d: bad body", line.Line)
+	default:
+		return fmt.Sprintf("%d: %v", line.Line, line.Err)
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	st := memory.InitStorage(slog.New(slog.DiscardHandler))
+	ctx := storagetest.Context(storagetest.NewTenant())
+	userID := storagetest.NewUser(ctx, t, st)
+
+	subs := []*model.Subscription{
+		storagetest.NewSubscription(userID, "Netflix", "01-2025", "07-2025"),
+		storagetest.NewSubscription(userID, "Spotify", "2025-02-15", ""),
+	}
+	subs[1].Price, subs[1].Currency = 999, "USD"
+
+	for _, format := range []string{model.FormatCSV, model.FormatJSONL} {
+		t.Run(format, func(t *testing.T) {
+			want := make([]*model.Subscription, 0, len(subs))
+			for _, sub := range subs {
+				want = append(want, storagetest.Create(ctx, t, st, sub))
+			}
+
+			var buf bytes.Buffer
+
+			count, err := Export(ctx, st, &model.ListParams{Sort: "id"}, &buf, format)
+			if err != nil || count != len(want) {
+				t.Fatalf("Export = %d, %v, want %d", count, err, len(want))
+			}
+
+			// Выгрузка загружается обратно после удаления подписок.
+			for _, sub := range want {
+				if err := st.DeleteSubscription(ctx, sub.ID, storage.AnyVersion, true); err != nil {
+					t.Fatalf("DeleteSubscription unexpected error: %v", err)
+				}
+			}
+
+			report, err := Import(ctx, st, model.ValidateSubscription, &buf, format)
+			if err != nil || report.Accepted != len(want) || report.Rejected != 0 {
+				t.Fatalf("Import = %+v, %v, want %d accepted", report, err, len(want))
+			}
+
+			for i, line := range report.Lines {
+				got := line.Subscription
+				if got.ServiceName != want[i].ServiceName || got.Price != want[i].Price ||
+					got.Currency != want[i].Currency || got.BillingPeriod != want[i].BillingPeriod ||
+					got.StartDate != want[i].StartDate || got.EndDate != want[i].EndDate {
+					t.Errorf("line %d = %+v, want %+v", line.Line, got, want[i])
+				}
+			}
+
+			for _, line := range report.Lines {
+				if err := st.DeleteSubscription(ctx, line.Subscription.ID, storage.AnyVersion, true); err != nil {
+					t.Fatalf("DeleteSubscription unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}